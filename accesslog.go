@@ -0,0 +1,211 @@
+package generpc
+
+import (
+	"context"
+	"log/slog"
+	"math/rand"
+	"time"
+
+	"github.com/dwlnetnl/generpc/coder"
+)
+
+// redacted replaces redacted values in the access log.
+const redacted = "[REDACTED]"
+
+// AccessLog configures structured logging of calls, see Server.AccessLog.
+//
+// Each call is logged with the method, request ID, duration, principal, media
+// type, batch index (for batches) and error code and message (on failure).
+// Successful calls are logged at level Info, failed calls at level Warn.
+//
+// Params and Results enable logging of params and results. Values of object
+// members named in RedactFields are replaced with "[REDACTED]", this includes
+// by-position params whose name (see Method.ParamNames) is listed. Redact is
+// optionally called for the params and result of each logged call after that,
+// for example to redact values of Go types, and returns the value to log.
+//
+// Sample optionally limits the fraction of logged successful calls per
+// method, for example 0.01 logs 1% of the calls. Failed calls are always
+// logged.
+type AccessLog struct {
+	Logger       *slog.Logger
+	Params       bool
+	Results      bool
+	RedactFields []string
+	Redact       func(method string, v interface{}) interface{}
+	Sample       map[string]float64
+}
+
+type batchIndexKey struct{}
+
+// withBatchIndex returns ctx with the index of the entry in the batch, so it
+// can be logged.
+func (s *Server) withBatchIndex(ctx context.Context, batch bool, i int) context.Context {
+	if !batch || s.AccessLog == nil {
+		return ctx
+	}
+
+	return context.WithValue(ctx, batchIndexKey{}, i)
+}
+
+func (s *Server) logCall(ctx context.Context, t methodTable, start time.Time, req *coder.Request, resp *coder.Response) {
+	l := s.AccessLog
+	if l == nil || l.Logger == nil {
+		return
+	}
+
+	if rate, ok := l.Sample[req.Method]; ok && resp.Error == nil && rand.Float64() >= rate {
+		return
+	}
+
+	attrs := []slog.Attr{
+		slog.String("method", req.Method),
+		slog.Duration("duration", time.Since(start)),
+		slog.String("mediaType", MediaTypeFromContext(ctx)),
+	}
+
+	if *req.ID != nil {
+		attrs = append(attrs, slog.String("id", string(*req.ID)))
+	}
+
+	if i, ok := ctx.Value(batchIndexKey{}).(int); ok {
+		attrs = append(attrs, slog.Int("batchIndex", i))
+	}
+
+	if p := PrincipalFromContext(ctx); p != nil {
+		attrs = append(attrs, slog.String("principal", p.Name))
+	}
+
+	if l.Params {
+		var names []string
+		if m, _ := t.resolve(req.Method); m != nil {
+			names = m.ParamNames
+		}
+
+		params := l.redactParams(names, req.Params)
+		attrs = append(attrs, slog.Any("params", l.redact(req.Method, params)))
+	}
+
+	level := slog.LevelInfo
+	if resp.Error != nil {
+		level = slog.LevelWarn
+		attrs = append(attrs,
+			slog.Int("errorCode", resp.Error.Code),
+			slog.String("errorMessage", resp.Error.Message))
+	} else if l.Results {
+		result := l.redactValue(canonical(resp.Result))
+		attrs = append(attrs, slog.Any("result", l.redact(req.Method, result)))
+	}
+
+	l.Logger.LogAttrs(ctx, level, "call", attrs...)
+}
+
+func (l *AccessLog) redact(method string, v interface{}) interface{} {
+	if l.Redact == nil {
+		return v
+	}
+
+	return l.Redact(method, v)
+}
+
+func (l *AccessLog) redactParams(names []string, params interface{}) interface{} {
+	return l.redactNames(names, l.redactValue(canonical(params)))
+}
+
+// redactNames redacts the by-position params in place whose name is listed in
+// RedactFields.
+func (l *AccessLog) redactNames(names []string, params interface{}) interface{} {
+	if s, ok := params.([]interface{}); ok {
+		for i := range s {
+			if i < len(names) && l.redactField(names[i]) {
+				s[i] = redacted
+			}
+		}
+	}
+
+	return params
+}
+
+// RedactParams returns a copy of the params of a call of the method with the
+// redaction of the access log applied (see AccessLog), for example to store
+// them. Unlike in the access log, numbers are kept as is. Params is returned
+// if the server has no access log.
+func (s *Server) RedactParams(method string, params interface{}) interface{} {
+	l := s.AccessLog
+	if l == nil {
+		return params
+	}
+
+	var names []string
+	if m, _ := s.methods().resolve(method); m != nil {
+		names = m.ParamNames
+	}
+
+	params = l.redactNames(names, l.redactValue(copyValue(params)))
+	return l.redact(method, params)
+}
+
+// copyValue returns a copy of the arrays and objects in v.
+func copyValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case []interface{}:
+		s := make([]interface{}, len(v))
+		for i, e := range v {
+			s[i] = copyValue(e)
+		}
+		return s
+
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			m[k] = copyValue(e)
+		}
+		return m
+	}
+
+	return v
+}
+
+// redactValue redacts v in place, v should be returned by canonical or
+// copyValue.
+func (l *AccessLog) redactValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case []interface{}:
+		for i, e := range v {
+			v[i] = l.redactValue(e)
+		}
+
+	case map[string]interface{}:
+		for k, e := range v {
+			if l.redactField(k) {
+				v[k] = redacted
+			} else {
+				v[k] = l.redactValue(e)
+			}
+		}
+	}
+
+	return v
+}
+
+func (l *AccessLog) redactField(name string) bool {
+	for _, f := range l.RedactFields {
+		if f == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// logWriteError logs that the response(s) could not be written.
+func (s *Server) logWriteError(ctx context.Context, err error) {
+	if s.AccessLog == nil || s.AccessLog.Logger == nil {
+		s.logf("generpc: write response: %v", err)
+		return
+	}
+
+	s.AccessLog.Logger.LogAttrs(ctx, slog.LevelError, "write response",
+		slog.String("mediaType", MediaTypeFromContext(ctx)),
+		slog.String("error", err.Error()))
+}
@@ -0,0 +1,139 @@
+package generpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func logLines(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	var lines []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var v map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(line), &v))
+		delete(v, "time")
+		delete(v, "duration")
+		lines = append(lines, v)
+	}
+	return lines
+}
+
+func TestAccessLog(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewServer()
+	h.Authenticator = tokenAuth()
+	h.AccessLog = &AccessLog{
+		Logger:       slog.New(slog.NewJSONHandler(&buf, nil)),
+		Params:       true,
+		Results:      true,
+		RedactFields: []string{"password", "subtrahend"},
+	}
+	h.Register("subtract", subtractMethod())
+	h.Register("login", Method{
+		Func: func(params []interface{}) interface{} {
+			return map[string]interface{}{"user": "alice", "password": "secret"}
+		},
+	})
+	h.Register("error", errorMethod())
+
+	serveAuth(t, h, "Bearer admin", `[
+		{"jsonrpc":"2.0","method":"subtract","params":[42,23],"id":1},
+		{"jsonrpc":"2.0","method":"login","params":[{"user":"alice","password":"secret"}],"id":"a"},
+		{"jsonrpc":"2.0","method":"error","params":[]}
+	]`)
+
+	assert.Equal(t, []map[string]interface{}{
+		{
+			"level": "INFO", "msg": "call", "method": "subtract", "mediaType": "application/json",
+			"id": "1", "batchIndex": 0.0, "principal": "alice",
+			"params": []interface{}{42.0, "[REDACTED]"}, "result": 19.0,
+		},
+		{
+			"level": "INFO", "msg": "call", "method": "login", "mediaType": "application/json",
+			"id": `"a"`, "batchIndex": 1.0, "principal": "alice",
+			"params": []interface{}{map[string]interface{}{"user": "alice", "password": "[REDACTED]"}},
+			"result": map[string]interface{}{"user": "alice", "password": "[REDACTED]"},
+		},
+		{
+			"level": "WARN", "msg": "call", "method": "error", "mediaType": "application/json",
+			"batchIndex": 2.0, "principal": "alice", "params": []interface{}{},
+			"errorCode": 1.0, "errorMessage": "Test error",
+		},
+	}, logLines(t, &buf))
+}
+
+func TestAccessLog_redact(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewServer()
+	h.AccessLog = &AccessLog{
+		Logger: slog.New(slog.NewJSONHandler(&buf, nil)),
+		Params: true,
+		Redact: func(method string, v interface{}) interface{} {
+			return method + " params"
+		},
+	}
+	h.Register("subtract", subtractMethod())
+
+	serveJSON(t, h, `{"jsonrpc":"2.0","method":"subtract","params":{"minuend":42,"subtrahend":23},"id":1}`)
+
+	lines := logLines(t, &buf)
+	require.Len(t, lines, 1)
+	assert.Equal(t, "subtract params", lines[0]["params"])
+	assert.NotContains(t, lines[0], "batchIndex")
+	assert.NotContains(t, lines[0], "principal")
+}
+
+func TestAccessLog_mount(t *testing.T) {
+	var buf bytes.Buffer
+	child := NewServer()
+	child.Register("login", Method{
+		ParamNames: []string{"user", "password"},
+		Func:       func([]interface{}) interface{} { return true },
+	})
+	child.Alias("signIn", "login")
+
+	h := NewServer()
+	h.AccessLog = &AccessLog{
+		Logger:       slog.New(slog.NewJSONHandler(&buf, nil)),
+		Params:       true,
+		RedactFields: []string{"password"},
+	}
+	h.Mount("auth", child)
+
+	serveJSON(t, h, `[
+		{"jsonrpc":"2.0","method":"auth.login","params":["alice","secret"],"id":1},
+		{"jsonrpc":"2.0","method":"auth.signIn","params":["alice","secret"],"id":2}
+	]`)
+
+	lines := logLines(t, &buf)
+	require.Len(t, lines, 2)
+	for _, line := range lines {
+		assert.Equal(t, []interface{}{"alice", "[REDACTED]"}, line["params"], line["method"])
+	}
+}
+
+func TestAccessLog_sample(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewServer()
+	h.AccessLog = &AccessLog{
+		Logger: slog.New(slog.NewJSONHandler(&buf, nil)),
+		Sample: map[string]float64{"subtract": 0, "error": 0},
+	}
+	h.Register("subtract", subtractMethod())
+	h.Register("error", errorMethod())
+
+	serveJSON(t, h, `[
+		{"jsonrpc":"2.0","method":"subtract","params":[42,23],"id":1},
+		{"jsonrpc":"2.0","method":"error","params":[],"id":2}
+	]`)
+
+	// Failed calls are always logged.
+	lines := logLines(t, &buf)
+	require.Len(t, lines, 1)
+	assert.Equal(t, "error", lines[0]["method"])
+}
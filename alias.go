@@ -0,0 +1,124 @@
+package generpc
+
+// deprecationExtension is the response extension that contains the
+// deprecation notice.
+const deprecationExtension = "deprecation"
+
+// lookup returns the method for name, aliases are resolved. The canonical
+// name of the method (the name of the alias target) and the deprecation notice
+// of the alias or method are returned as well.
+func (t methodTable) lookup(name string) (m *Method, canonical, notice string) {
+	m = t[name]
+	if m == nil || m.mount != nil {
+		return nil, "", ""
+	}
+
+	canonical, notice = name, m.Deprecated
+	if m.aliasOf != "" {
+		canonical = m.aliasOf
+		m = t[m.aliasOf]
+		if m == nil {
+			return nil, "", ""
+		}
+
+		if notice == "" {
+			notice = m.Deprecated
+		}
+	}
+
+	return m, canonical, notice
+}
+
+// resolve returns the method for name like lookup, including the methods of
+// mounted servers. The canonical name of a mounted method has the prefix of
+// its server.
+func (t methodTable) resolve(name string) (*Method, string) {
+	if m, canonical, _ := t.lookup(name); m != nil {
+		return m, canonical
+	}
+
+	if child, sub := t.mount(name); child != nil {
+		if m, canonical := child.methods().resolve(sub); m != nil {
+			return m, name[:len(name)-len(sub)] + canonical
+		}
+	}
+
+	return nil, ""
+}
+
+// Alias registers name as alias for the target method. Calls to the alias
+// invoke the method that is registered for target at the time of the call. It
+// panics if name is empty or already registered or if target isn't registered
+// or is an alias.
+func (s *Server) Alias(name, target string) {
+	if name == "" {
+		panic("generpc: name is empty")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t := s.methods()
+	if _, ok := t[name]; ok {
+		panic("generpc: method already exists: " + name)
+	}
+
+	m, ok := t[target]
+	if !ok {
+		panic("generpc: method doesn't exist: " + target)
+	}
+
+	if m.aliasOf != "" || m.mount != nil {
+		panic("generpc: method is an alias or mount point: " + target)
+	}
+
+	t = t.clone()
+	t[name] = &Method{aliasOf: target}
+	s.table.Store(t)
+}
+
+// Deprecate marks the method or alias as deprecated with the given notice, like
+// "use user.get.v2 instead". Calls to deprecated methods are counted (see
+// DeprecatedCalls) and logged. If Server.DeprecationNotices is set, responses
+// contain the notice. It panics if name isn't registered.
+func (s *Server) Deprecate(name, notice string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t := s.methods()
+	m, ok := t[name]
+	if !ok {
+		panic("generpc: method doesn't exist: " + name)
+	}
+
+	c := *m
+	c.Deprecated = notice
+
+	t = t.clone()
+	t[name] = &c
+	s.table.Store(t)
+}
+
+func (s *Server) deprecatedCall(name, notice string) {
+	s.deprecated.Lock()
+	if s.deprecated.calls == nil {
+		s.deprecated.calls = make(map[string]uint64)
+	}
+	s.deprecated.calls[name]++
+	s.deprecated.Unlock()
+
+	s.logf("generpc: deprecated method %q called: %s", name, notice)
+}
+
+// DeprecatedCalls returns the number of calls per deprecated method or alias.
+func (s *Server) DeprecatedCalls() map[string]uint64 {
+	s.deprecated.Lock()
+	defer s.deprecated.Unlock()
+
+	calls := make(map[string]uint64, len(s.deprecated.calls))
+	for name, n := range s.deprecated.calls {
+		calls[name] = n
+	}
+
+	return calls
+}
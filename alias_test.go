@@ -0,0 +1,113 @@
+package generpc
+
+import (
+	"bytes"
+	"log"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAlias(t *testing.T) {
+	var buf bytes.Buffer
+
+	h := NewServer()
+	h.ErrorLog = log.New(&buf, "", 0)
+	h.Register("subtract.v2", subtractMethod())
+	h.Alias("subtract", "subtract.v2")
+
+	w := serveJSON(t, h, `{"jsonrpc":"2.0","method":"subtract","params":[42,23],"id":1}`)
+	assert.Equal(t, `{"jsonrpc":"2.0","result":19,"id":1}`+"\n", w.Body.String())
+	assert.Empty(t, h.DeprecatedCalls())
+
+	h.Deprecate("subtract", "use subtract.v2")
+
+	w = serveJSON(t, h, `{"jsonrpc":"2.0","method":"subtract","params":[42,23],"id":1}`)
+	assert.Equal(t, `{"jsonrpc":"2.0","result":19,"id":1}`+"\n", w.Body.String())
+
+	h.DeprecationNotices = true
+
+	w = serveJSON(t, h, `{"jsonrpc":"2.0","method":"subtract","params":[42,23],"id":1}`)
+	want := `{"jsonrpc":"2.0","result":19,"id":1,"extensions":{"deprecation":"use subtract.v2"}}` + "\n"
+	assert.Equal(t, want, w.Body.String())
+
+	w = serveJSON(t, h, `{"jsonrpc":"2.0","method":"subtract.v2","params":[42,23],"id":1}`)
+	assert.Equal(t, `{"jsonrpc":"2.0","result":19,"id":1}`+"\n", w.Body.String())
+
+	assert.Equal(t, map[string]uint64{"subtract": 2}, h.DeprecatedCalls())
+	assert.Contains(t, buf.String(), `generpc: deprecated method "subtract" called: use subtract.v2`)
+}
+
+func TestAlias_replacedTarget(t *testing.T) {
+	h := NewServer()
+	h.Register("value.v1", valueMethod(1))
+	h.Alias("value", "value.v1")
+	h.Replace("value.v1", valueMethod(2))
+
+	w := serveJSON(t, h, `{"jsonrpc":"2.0","method":"value","params":[],"id":1}`)
+	assert.Equal(t, `{"jsonrpc":"2.0","result":2,"id":1}`+"\n", w.Body.String())
+
+	h.Unregister("value.v1")
+
+	w = serveJSON(t, h, `{"jsonrpc":"2.0","method":"value","params":[],"id":1}`)
+	want := `{"jsonrpc":"2.0","error":{"code":-32601,"message":"Method not found"},"id":1}` + "\n"
+	assert.Equal(t, want, w.Body.String())
+
+	// Aliases survive swapping the method table.
+	h.Register("value.v1", valueMethod(3))
+	h.SwapMethods(h.Methods())
+
+	w = serveJSON(t, h, `{"jsonrpc":"2.0","method":"value","params":[],"id":1}`)
+	assert.Equal(t, `{"jsonrpc":"2.0","result":3,"id":1}`+"\n", w.Body.String())
+}
+
+func TestAlias_panics(t *testing.T) {
+	h := NewServer()
+	h.Register("a", valueMethod("a"))
+	h.Alias("b", "a")
+
+	assert.Panics(t, func() { h.Alias("", "a") })
+	assert.Panics(t, func() { h.Alias("a", "a") })
+	assert.Panics(t, func() { h.Alias("c", "missing") })
+	assert.Panics(t, func() { h.Alias("c", "b") })
+	assert.Panics(t, func() { h.Deprecate("missing", "") })
+}
+
+func TestDiscover_aliases(t *testing.T) {
+	h := NewServer()
+	h.Register("subtract.v2", subtractMethod())
+	h.Alias("subtract", "subtract.v2")
+	h.Deprecate("subtract", "use subtract.v2")
+
+	w := serveJSON(t, h, `{"jsonrpc":"2.0","method":"rpc.discover","id":1}`)
+
+	want := `{"jsonrpc":"2.0","result":{
+		"openrpc":"1.2.6",
+		"info":{"title":"GeneRPC","version":"0.0.0"},
+		"methods":[
+			{
+				"name":"subtract",
+				"paramStructure":"either",
+				"params":[
+					{"name":"minuend","required":true,"schema":{}},
+					{"name":"subtrahend","required":true,"schema":{}}
+				],
+				"result":{"name":"result","schema":{}},
+				"deprecated":true,
+				"x-alias-of":"subtract.v2",
+				"x-deprecation":"use subtract.v2"
+			},
+			{
+				"name":"subtract.v2",
+				"paramStructure":"either",
+				"params":[
+					{"name":"minuend","required":true,"schema":{}},
+					{"name":"subtrahend","required":true,"schema":{}}
+				],
+				"result":{"name":"result","schema":{}},
+				"x-aliases":["subtract"]
+			}
+		]
+	},"id":1}`
+	assert.JSONEq(t, want, w.Body.String())
+}
@@ -0,0 +1,305 @@
+package generpc
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dwlnetnl/generpc/coder"
+)
+
+// Principal identifies an authenticated caller.
+type Principal struct {
+	Name   string
+	Roles  []string
+	Scopes []string
+}
+
+// HasRole reports if the principal has the role.
+func (p *Principal) HasRole(role string) bool {
+	return contains(p.Roles, role)
+}
+
+// HasScope reports if the principal has the scope.
+func (p *Principal) HasScope(scope string) bool {
+	return contains(p.Scopes, scope)
+}
+
+func contains(s []string, v string) bool {
+	for _, e := range s {
+		if e == v {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Authenticator authenticates HTTP requests. Authenticate should return a nil
+// principal and error if the request has no credentials and an error if the
+// credentials are invalid. The error isn't returned to the client.
+type Authenticator interface {
+	Authenticate(r *http.Request) (*Principal, error)
+}
+
+// AuthenticatorFunc is an adapter to use a function as Authenticator.
+type AuthenticatorFunc func(r *http.Request) (*Principal, error)
+
+// Authenticate calls f(r).
+func (f AuthenticatorFunc) Authenticate(r *http.Request) (*Principal, error) {
+	return f(r)
+}
+
+// Authorization describes the authorization requirements of a method. The
+// caller should be authenticated, have at least one of Roles (if any) and all
+// of Scopes.
+type Authorization struct {
+	Roles  []string
+	Scopes []string
+}
+
+type authKey struct{}
+
+type authInfo struct {
+	p   *Principal
+	err error
+}
+
+// PrincipalFromContext returns the authenticated principal of the call or nil
+// if the caller isn't authenticated.
+func PrincipalFromContext(ctx context.Context) *Principal {
+	a, _ := ctx.Value(authKey{}).(*authInfo)
+	if a == nil {
+		return nil
+	}
+
+	return a.p
+}
+
+// ContextWithPrincipal returns a copy of ctx with p as authenticated
+// principal, for calls that aren't received via HTTP (see Server.Invoke).
+func ContextWithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, authKey{}, &authInfo{p: p})
+}
+
+func (s *Server) authenticate(r *http.Request) context.Context {
+	ctx := r.Context()
+	if s.Authenticator == nil {
+		return ctx
+	}
+
+	p, err := s.Authenticator.Authenticate(r)
+	if err != nil {
+		s.logf("generpc: authentication failed: %v", err)
+		p = nil
+	}
+
+	return context.WithValue(ctx, authKey{}, &authInfo{p, err})
+}
+
+var (
+	unauthorized = coder.ServerError(UnauthorizedErrorCode).WithString("unauthorized")
+	forbidden    = coder.ServerError(ForbiddenErrorCode)
+)
+
+// authorize checks the authorization requirements of the method.
+func (m *Method) authorize(ctx context.Context) *coder.Error {
+	a, _ := ctx.Value(authKey{}).(*authInfo)
+	if a != nil && a.err != nil {
+		return unauthorized
+	}
+
+	req := m.Authorization
+	if req == nil {
+		return nil
+	}
+
+	if a == nil || a.p == nil {
+		return unauthorized
+	}
+
+	ok := len(req.Roles) == 0
+	for _, role := range req.Roles {
+		if a.p.HasRole(role) {
+			ok = true
+			break
+		}
+	}
+
+	for _, scope := range req.Scopes {
+		if !a.p.HasScope(scope) {
+			ok = false
+			break
+		}
+	}
+
+	if ok {
+		return nil
+	}
+
+	e := forbidden
+	e.Data = map[string]interface{}{
+		"roles":  stringsData(req.Roles),
+		"scopes": stringsData(req.Scopes),
+	}
+	return &e
+}
+
+// stringsData converts s for use in error data.
+func stringsData(s []string) []interface{} {
+	data := make([]interface{}, len(s))
+	for i, v := range s {
+		data[i] = v
+	}
+
+	return data
+}
+
+// BearerAuth returns an authenticator for bearer tokens (RFC 6750) in the
+// Authorization header. Validate is called with the token.
+func BearerAuth(validate func(token string) (*Principal, error)) Authenticator {
+	return AuthenticatorFunc(func(r *http.Request) (*Principal, error) {
+		h := r.Header.Get("Authorization")
+		if h == "" {
+			return nil, nil
+		}
+
+		const prefix = "Bearer "
+		if len(h) <= len(prefix) || !strings.EqualFold(h[:len(prefix)], prefix) {
+			return nil, errors.New("generpc: invalid bearer authorization")
+		}
+
+		return validate(h[len(prefix):])
+	})
+}
+
+// HMACScheme is the Authorization header scheme used by HMACAuth.
+const HMACScheme = "HMAC-SHA256"
+
+// HMACTimestampHeader is the HTTP header with the signing time of a request
+// signed for HMACAuth, in seconds since the Unix epoch.
+const HMACTimestampHeader = "X-HMAC-Timestamp"
+
+const (
+	// HMACMaxSkew is the maximum difference between the signing time of a
+	// request and the time it's authenticated by HMACAuth.
+	HMACMaxSkew = 5 * time.Minute
+
+	// HMACMaxBodySize is the maximum size of a request body verified by
+	// HMACAuth.
+	HMACMaxBodySize = 10 << 20
+)
+
+// HMACAuth returns an authenticator that verifies HMAC-SHA256 signatures of
+// requests. The Authorization header has the form
+//
+//	HMAC-SHA256 <key id>:<signature>
+//
+// where signature is the standard base64 encoding of the HMAC of the HTTP
+// method, the path, the timestamp in the HMACTimestampHeader and the body,
+// see SignHMAC and SignHMACRequest. Signatures older than HMACMaxSkew are
+// rejected, a captured request can be replayed within that window. Lookup
+// returns the key and principal for a key id.
+func HMACAuth(lookup func(keyID string) (key []byte, p *Principal, ok bool)) Authenticator {
+	return AuthenticatorFunc(func(r *http.Request) (*Principal, error) {
+		h := r.Header.Get("Authorization")
+		if h == "" {
+			return nil, nil
+		}
+
+		const prefix = HMACScheme + " "
+		if !strings.HasPrefix(h, prefix) {
+			return nil, errors.New("generpc: invalid HMAC authorization")
+		}
+
+		i := strings.LastIndexByte(h, ':')
+		if i < len(prefix) {
+			return nil, errors.New("generpc: invalid HMAC authorization")
+		}
+
+		sig, err := base64.StdEncoding.DecodeString(h[i+1:])
+		if err != nil {
+			return nil, errors.New("generpc: invalid HMAC signature encoding")
+		}
+
+		ts := r.Header.Get(HMACTimestampHeader)
+		sec, err := strconv.ParseInt(ts, 10, 64)
+		if err != nil {
+			return nil, errors.New("generpc: invalid HMAC timestamp")
+		}
+
+		if d := time.Since(time.Unix(sec, 0)); d > HMACMaxSkew || d < -HMACMaxSkew {
+			return nil, errors.New("generpc: stale HMAC signature")
+		}
+
+		key, p, ok := lookup(h[len(prefix):i])
+		if !ok {
+			return nil, errors.New("generpc: unknown HMAC key")
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(nil, r.Body, HMACMaxBodySize))
+		if err != nil {
+			return nil, err
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		if !hmac.Equal(sig, SignHMAC(key, r.Method, r.URL.EscapedPath(), ts, body)) {
+			return nil, errors.New("generpc: HMAC signature mismatch")
+		}
+
+		return p, nil
+	})
+}
+
+// SignHMAC returns the HMAC-SHA256 signature of a request as used by
+// HMACAuth.
+func SignHMAC(key []byte, method, path, timestamp string, body []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	io.WriteString(mac, method+"\n"+path+"\n"+timestamp+"\n")
+	mac.Write(body)
+	return mac.Sum(nil)
+}
+
+// SignHMACRequest signs r for HMACAuth with the current time. It sets the
+// Authorization and HMACTimestampHeader headers. The body is read and
+// replaced, so r can be sent afterwards.
+func SignHMACRequest(r *http.Request, keyID string, key []byte) error {
+	var body []byte
+	if r.Body != nil {
+		var err error
+		if body, err = io.ReadAll(r.Body); err != nil {
+			return err
+		}
+		r.Body.Close()
+		r.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+	sig := SignHMAC(key, r.Method, r.URL.EscapedPath(), ts, body)
+
+	r.Header.Set(HMACTimestampHeader, ts)
+	r.Header.Set("Authorization", HMACScheme+" "+keyID+":"+base64.StdEncoding.EncodeToString(sig))
+	return nil
+}
+
+// TLSAuth returns an authenticator for TLS client certificates. The
+// certificate should be verified by the TLS configuration of the HTTP server.
+// Principal is called with the leaf certificate of the peer.
+func TLSAuth(principal func(cert *x509.Certificate) (*Principal, error)) Authenticator {
+	return AuthenticatorFunc(func(r *http.Request) (*Principal, error) {
+		if r.TLS == nil || len(r.TLS.PeerCertificates) == 0 {
+			return nil, nil
+		}
+
+		return principal(r.TLS.PeerCertificates[0])
+	})
+}
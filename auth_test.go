@@ -0,0 +1,253 @@
+package generpc
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func whoamiMethod(a *Authorization) Method {
+	return Method{
+		FuncContext: func(ctx context.Context, params []interface{}) interface{} {
+			if p := PrincipalFromContext(ctx); p != nil {
+				return p.Name
+			}
+			return nil
+		},
+		Authorization: a,
+	}
+}
+
+func tokenAuth() Authenticator {
+	return BearerAuth(func(token string) (*Principal, error) {
+		switch token {
+		case "admin":
+			return &Principal{Name: "alice", Roles: []string{"admin"}, Scopes: []string{"read", "write"}}, nil
+		case "reader":
+			return &Principal{Name: "bob", Scopes: []string{"read"}}, nil
+		}
+		return nil, errors.New("invalid token")
+	})
+}
+
+func serveAuth(t *testing.T, h http.Handler, auth, body string) string {
+	r, err := http.NewRequest("POST", "/", strings.NewReader(body))
+	r.Header.Add("Content-Type", "application/json")
+	if auth != "" {
+		r.Header.Add("Authorization", auth)
+	}
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w.Body.String()
+}
+
+func TestAuthorization(t *testing.T) {
+	h := NewServer()
+	h.ErrorLog = discardLog
+	h.Authenticator = tokenAuth()
+	h.Register("public", whoamiMethod(nil))
+	h.Register("user", whoamiMethod(&Authorization{}))
+	h.Register("admin", whoamiMethod(&Authorization{Roles: []string{"admin"}, Scopes: []string{"write"}}))
+
+	batch := `[
+		{"jsonrpc":"2.0","method":"public","params":[],"id":1},
+		{"jsonrpc":"2.0","method":"user","params":[],"id":2},
+		{"jsonrpc":"2.0","method":"admin","params":[],"id":3}
+	]`
+
+	cases := []struct {
+		auth string
+		want string
+	}{
+		{"", `[
+			{"jsonrpc":"2.0","result":null,"id":1},
+			{"jsonrpc":"2.0","error":{"code":-32002,"message":"Server error","data":"unauthorized"},"id":2},
+			{"jsonrpc":"2.0","error":{"code":-32002,"message":"Server error","data":"unauthorized"},"id":3}
+		]`},
+		{"Bearer reader", `[
+			{"jsonrpc":"2.0","result":"bob","id":1},
+			{"jsonrpc":"2.0","result":"bob","id":2},
+			{"jsonrpc":"2.0","error":{"code":-32003,"message":"Server error","data":{"roles":["admin"],"scopes":["write"]}},"id":3}
+		]`},
+		{"Bearer admin", `[
+			{"jsonrpc":"2.0","result":"alice","id":1},
+			{"jsonrpc":"2.0","result":"alice","id":2},
+			{"jsonrpc":"2.0","result":"alice","id":3}
+		]`},
+		{"Bearer invalid", `[
+			{"jsonrpc":"2.0","error":{"code":-32002,"message":"Server error","data":"unauthorized"},"id":1},
+			{"jsonrpc":"2.0","error":{"code":-32002,"message":"Server error","data":"unauthorized"},"id":2},
+			{"jsonrpc":"2.0","error":{"code":-32002,"message":"Server error","data":"unauthorized"},"id":3}
+		]`},
+		{"Basic abc", `[
+			{"jsonrpc":"2.0","error":{"code":-32002,"message":"Server error","data":"unauthorized"},"id":1},
+			{"jsonrpc":"2.0","error":{"code":-32002,"message":"Server error","data":"unauthorized"},"id":2},
+			{"jsonrpc":"2.0","error":{"code":-32002,"message":"Server error","data":"unauthorized"},"id":3}
+		]`},
+	}
+
+	for _, c := range cases {
+		assert.JSONEq(t, c.want, serveAuth(t, h, c.auth, batch), c.auth)
+	}
+}
+
+func TestAuthorization_mount(t *testing.T) {
+	child := NewServer()
+	child.Register("admin", whoamiMethod(&Authorization{Roles: []string{"admin"}}))
+
+	h := NewServer()
+	h.Authenticator = tokenAuth()
+	h.Mount("child", child)
+
+	body := `{"jsonrpc":"2.0","method":"child.admin","params":[],"id":1}`
+	assert.JSONEq(t, `{"jsonrpc":"2.0","result":"alice","id":1}`, serveAuth(t, h, "Bearer admin", body))
+
+	want := `{"jsonrpc":"2.0","error":{"code":-32003,"message":"Server error","data":{"roles":["admin"],"scopes":[]}},"id":1}`
+	assert.JSONEq(t, want, serveAuth(t, h, "Bearer reader", body))
+}
+
+func TestHMACAuth(t *testing.T) {
+	key := []byte("secret")
+
+	h := NewServer()
+	h.ErrorLog = discardLog
+	h.Authenticator = HMACAuth(func(keyID string) ([]byte, *Principal, bool) {
+		if keyID != "key1" {
+			return nil, nil, false
+		}
+		return key, &Principal{Name: "service"}, true
+	})
+	h.Register("user", whoamiMethod(&Authorization{}))
+
+	body := `{"jsonrpc":"2.0","method":"user","params":[],"id":1}`
+	serve := func(sign func(r *http.Request)) string {
+		r := httptest.NewRequest("POST", "/rpc", strings.NewReader(body))
+		r.Header.Add("Content-Type", "application/json")
+		require.NoError(t, SignHMACRequest(r, "key1", key))
+		sign(r)
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w.Body.String()
+	}
+
+	got := serve(func(r *http.Request) {})
+	assert.JSONEq(t, `{"jsonrpc":"2.0","result":"service","id":1}`, got)
+
+	unauthorized := `{"jsonrpc":"2.0","error":{"code":-32002,"message":"Server error","data":"unauthorized"},"id":1}`
+	for name, sign := range map[string]func(r *http.Request){
+		"key": func(r *http.Request) {
+			r.Header.Set("Authorization", strings.Replace(r.Header.Get("Authorization"), "key1", "key2", 1))
+		},
+		"body": func(r *http.Request) {
+			r.Body = io.NopCloser(strings.NewReader(strings.Replace(body, "[]", "[1]", 1)))
+		},
+		"path": func(r *http.Request) {
+			r.URL.Path = "/other"
+		},
+		"timestamp": func(r *http.Request) {
+			r.Header.Set(HMACTimestampHeader, "x")
+		},
+		"stale": func(r *http.Request) {
+			ts := strconv.FormatInt(time.Now().Add(-HMACMaxSkew-time.Minute).Unix(), 10)
+			sig := SignHMAC(key, r.Method, r.URL.EscapedPath(), ts, []byte(body))
+			r.Header.Set(HMACTimestampHeader, ts)
+			r.Header.Set("Authorization", "HMAC-SHA256 key1:"+base64.StdEncoding.EncodeToString(sig))
+		},
+	} {
+		assert.JSONEq(t, unauthorized, serve(sign), name)
+	}
+}
+
+func TestHMACAuth_request(t *testing.T) {
+	key := []byte("secret")
+	auth := HMACAuth(func(keyID string) ([]byte, *Principal, bool) {
+		return key, &Principal{Name: "service"}, true
+	})
+
+	// The HTTP method is signed.
+	r := httptest.NewRequest("POST", "/", strings.NewReader("{}"))
+	require.NoError(t, SignHMACRequest(r, "key1", key))
+	r.Method = "PUT"
+	_, err := auth.Authenticate(r)
+	assert.EqualError(t, err, "generpc: HMAC signature mismatch")
+
+	// The body size is limited.
+	r = httptest.NewRequest("POST", "/", strings.NewReader(strings.Repeat(" ", HMACMaxBodySize+1)))
+	require.NoError(t, SignHMACRequest(r, "key1", key))
+	p, err := auth.Authenticate(r)
+	assert.Nil(t, p)
+	assert.Error(t, err)
+}
+
+func TestTLSAuth(t *testing.T) {
+	h := NewServer()
+	h.Authenticator = TLSAuth(func(cert *x509.Certificate) (*Principal, error) {
+		return &Principal{Name: cert.Subject.CommonName}, nil
+	})
+	h.Register("user", whoamiMethod(&Authorization{}))
+
+	body := `{"jsonrpc":"2.0","method":"user","params":[],"id":1}`
+	r, err := http.NewRequest("POST", "/", strings.NewReader(body))
+	r.Header.Add("Content-Type", "application/json")
+	require.NoError(t, err)
+
+	r.TLS = &tls.ConnectionState{
+		PeerCertificates: []*x509.Certificate{{Subject: pkix.Name{CommonName: "client"}}},
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	assert.JSONEq(t, `{"jsonrpc":"2.0","result":"client","id":1}`, w.Body.String())
+}
+
+func TestDiscover_authorization(t *testing.T) {
+	child := NewServer()
+	child.Register("admin", whoamiMethod(&Authorization{Roles: []string{"admin"}}))
+
+	h := NewServer()
+	h.ErrorLog = discardLog
+	h.Authenticator = tokenAuth()
+	h.Register("public", whoamiMethod(nil))
+	h.Register("user", whoamiMethod(&Authorization{}))
+	h.Register("admin", whoamiMethod(&Authorization{Roles: []string{"admin"}}))
+	h.Alias("root", "admin")
+	h.Mount("child", child)
+
+	names := func(auth string) []string {
+		var resp struct {
+			Result struct {
+				Methods []struct{ Name string }
+			}
+		}
+		body := serveAuth(t, h, auth, `{"jsonrpc":"2.0","method":"rpc.discover","id":1}`)
+		require.NoError(t, json.Unmarshal([]byte(body), &resp))
+
+		var names []string
+		for _, m := range resp.Result.Methods {
+			names = append(names, m.Name)
+		}
+		return names
+	}
+
+	assert.Equal(t, []string{"public"}, names(""))
+	assert.Equal(t, []string{"public", "user"}, names("Bearer reader"))
+	assert.Equal(t, []string{"admin", "child.admin", "public", "root", "user"}, names("Bearer admin"))
+	assert.Len(t, h.Discover().Methods, 5)
+}
@@ -0,0 +1,140 @@
+package generpc
+
+import (
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dwlnetnl/generpc/coder"
+)
+
+// defaultCacheSize is the default value of Server.CacheSize.
+const defaultCacheSize = 1024
+
+// CacheStats contains the result cache statistics of a method.
+type CacheStats struct {
+	Hits   uint64
+	Misses uint64
+}
+
+type resultCache struct {
+	once  sync.Once
+	c     *lruCache
+	mu    sync.Mutex
+	stats map[string]*CacheStats
+}
+
+func (s *Server) resultCache() *lruCache {
+	s.cache.once.Do(func() {
+		size := s.CacheSize
+		if size <= 0 {
+			size = defaultCacheSize
+		}
+		s.cache.c = newLRUCache(size)
+	})
+
+	return s.cache.c
+}
+
+// cacheKey returns the cache key for the call, it's empty if the params
+// cannot be encoded.
+func cacheKey(method string, params []interface{}) string {
+	b, err := json.Marshal(canonical(params))
+	if err != nil {
+		return ""
+	}
+
+	return method + "\x00" + string(b)
+}
+
+// canonical returns v with numbers converted to int or float64, so equal
+// params have the same encoding regardless of the coder.
+func canonical(v interface{}) interface{} {
+	switch v := v.(type) {
+	case coder.Number:
+		if i, ok := v.CastInt(); ok {
+			return i
+		}
+		f, _ := v.CastFloat64()
+		return f
+
+	case []interface{}:
+		s := make([]interface{}, len(v))
+		for i, e := range v {
+			s[i] = canonical(e)
+		}
+		return s
+
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			m[k] = canonical(e)
+		}
+		return m
+	}
+
+	return v
+}
+
+func (s *Server) cachedResult(key, method string) (interface{}, bool) {
+	result, ok := s.resultCache().get(key)
+
+	s.cache.mu.Lock()
+	if s.cache.stats == nil {
+		s.cache.stats = make(map[string]*CacheStats)
+	}
+	st := s.cache.stats[method]
+	if st == nil {
+		st = new(CacheStats)
+		s.cache.stats[method] = st
+	}
+	if ok {
+		st.Hits++
+	} else {
+		st.Misses++
+	}
+	s.cache.mu.Unlock()
+
+	return result, ok
+}
+
+func (s *Server) cacheResult(key string, result interface{}, ttl time.Duration) {
+	s.resultCache().put(key, result, ttl)
+}
+
+// InvalidateCache removes the cached results of the method, see
+// Method.CacheTTL. Results are cached by the name of the method, so calls of
+// its aliases are invalidated as well. All cached results are removed if
+// method is empty. Results of mounted servers are removed by their prefixed
+// name.
+func (s *Server) InvalidateCache(method string) {
+	if method != "" {
+		t := s.methods()
+		if m, canonical, _ := t.lookup(method); m != nil {
+			method = canonical
+		} else if child, name := t.mount(method); child != nil {
+			child.InvalidateCache(name)
+			return
+		}
+	}
+
+	prefix := method + "\x00"
+	s.resultCache().removeFunc(func(key string) bool {
+		return method == "" || strings.HasPrefix(key, prefix)
+	})
+}
+
+// CacheStats returns the result cache statistics per cached method, calls of
+// aliases are counted for their target.
+func (s *Server) CacheStats() map[string]CacheStats {
+	s.cache.mu.Lock()
+	defer s.cache.mu.Unlock()
+
+	stats := make(map[string]CacheStats, len(s.cache.stats))
+	for name, st := range s.cache.stats {
+		stats[name] = *st
+	}
+
+	return stats
+}
@@ -0,0 +1,89 @@
+package generpc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResultCache(t *testing.T) {
+	var n int64
+	m := counterMethod(&n)
+	m.ParamNames = []string{"a", "b"}
+	m.CacheTTL = time.Hour
+
+	h := NewServer()
+	h.Register("lookup", m)
+
+	got := serveJSON(t, h, `[
+		{"jsonrpc":"2.0","method":"lookup","params":[1,2],"id":1},
+		{"jsonrpc":"2.0","method":"lookup","params":{"b":2.0,"a":1},"id":2},
+		{"jsonrpc":"2.0","method":"lookup","params":[1,3],"id":3}
+	]`).Body.String()
+	assert.JSONEq(t, `[
+		{"jsonrpc":"2.0","result":1,"id":1},
+		{"jsonrpc":"2.0","result":1,"id":2},
+		{"jsonrpc":"2.0","result":2,"id":3}
+	]`, got)
+
+	got = serveJSON(t, h, `{"jsonrpc":"2.0","method":"lookup","params":[1,2],"id":4}`).Body.String()
+	assert.Equal(t, `{"jsonrpc":"2.0","result":1,"id":4}`+"\n", got)
+	assert.Equal(t, map[string]CacheStats{"lookup": {Hits: 2, Misses: 2}}, h.CacheStats())
+
+	h.InvalidateCache("lookup")
+	got = serveJSON(t, h, `{"jsonrpc":"2.0","method":"lookup","params":[1,2],"id":5}`).Body.String()
+	assert.Equal(t, `{"jsonrpc":"2.0","result":3,"id":5}`+"\n", got)
+}
+
+func TestResultCache_errors(t *testing.T) {
+	h := NewServer()
+	m := errorMethod()
+	m.CacheTTL = time.Hour
+	h.Register("error", m)
+
+	serveJSON(t, h, `{"jsonrpc":"2.0","method":"error","params":[],"id":1}`)
+	serveJSON(t, h, `{"jsonrpc":"2.0","method":"error","params":[],"id":2}`)
+	assert.Equal(t, map[string]CacheStats{"error": {Misses: 2}}, h.CacheStats())
+}
+
+func TestResultCache_mount(t *testing.T) {
+	var n int64
+	m := counterMethod(&n)
+	m.CacheTTL = time.Hour
+
+	child := NewServer()
+	child.Register("lookup", m)
+
+	h := NewServer()
+	h.Mount("child", child)
+
+	const body = `{"jsonrpc":"2.0","method":"child.lookup","params":[],"id":1}`
+	assert.Equal(t, `{"jsonrpc":"2.0","result":1,"id":1}`+"\n", serveJSON(t, h, body).Body.String())
+	assert.Equal(t, `{"jsonrpc":"2.0","result":1,"id":1}`+"\n", serveJSON(t, h, body).Body.String())
+
+	h.InvalidateCache("child.lookup")
+	assert.Equal(t, `{"jsonrpc":"2.0","result":2,"id":1}`+"\n", serveJSON(t, h, body).Body.String())
+}
+
+func TestResultCache_alias(t *testing.T) {
+	var n int64
+	m := counterMethod(&n)
+	m.CacheTTL = time.Hour
+
+	h := NewServer()
+	h.Register("lookup", m)
+	h.Alias("find", "lookup")
+
+	const body = `{"jsonrpc":"2.0","method":"find","params":[],"id":1}`
+	assert.Equal(t, `{"jsonrpc":"2.0","result":1,"id":1}`+"\n", serveJSON(t, h, body).Body.String())
+	assert.Equal(t, `{"jsonrpc":"2.0","result":1,"id":1}`+"\n",
+		serveJSON(t, h, `{"jsonrpc":"2.0","method":"lookup","params":[],"id":1}`).Body.String())
+	assert.Equal(t, map[string]CacheStats{"lookup": {Hits: 1, Misses: 1}}, h.CacheStats())
+
+	h.InvalidateCache("lookup")
+	assert.Equal(t, `{"jsonrpc":"2.0","result":2,"id":1}`+"\n", serveJSON(t, h, body).Body.String())
+
+	h.InvalidateCache("find")
+	assert.Equal(t, `{"jsonrpc":"2.0","result":3,"id":1}`+"\n", serveJSON(t, h, body).Body.String())
+}
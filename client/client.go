@@ -0,0 +1,202 @@
+// Package client implements a GeneRPC HTTP client.
+//
+//	c := client.New("https://example.com/rpc")
+//
+//	var result int
+//	err := c.Call(ctx, "subtract", []interface{}{42, 23}, &result)
+//
+// RPC errors are returned as *coder.Error. The request is encoded with the
+// client codec of the coder for MediaType, see coder.ClientCodec. The span
+// context in ctx is propagated with the traceparent and tracestate headers,
+// see generpc.InjectTraceHeaders.
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync/atomic"
+
+	"github.com/dwlnetnl/generpc"
+	"github.com/dwlnetnl/generpc/coder"
+	"github.com/dwlnetnl/generpc/convert"
+)
+
+// DefaultMediaType is the default value of Client.MediaType.
+const DefaultMediaType = "application/json"
+
+// Client calls methods of a GeneRPC endpoint. It's safe for concurrent use,
+// but the fields shouldn't be changed after the first call.
+type Client struct {
+	// URL is the URL of the endpoint.
+	URL string
+
+	// MediaType selects the coder. The default is DefaultMediaType.
+	MediaType string
+
+	// HTTPClient is used to send requests. The default is
+	// http.DefaultClient.
+	HTTPClient *http.Client
+
+	// Header contains additional HTTP headers of each request, for example
+	// Authorization.
+	Header http.Header
+
+	id int64
+}
+
+// New returns a client for the endpoint at url.
+func New(url string) *Client {
+	return &Client{URL: url, Header: make(http.Header)}
+}
+
+// Call calls the method and decodes the result into the value pointed to by
+// result, if it's not nil (see convert.Value). Params should be by-position
+// ([]interface{}) or by-name (map[string]interface{}) params, nil means no
+// params.
+func (c *Client) Call(ctx context.Context, method string, params interface{}, result interface{}) error {
+	call := &Call{Method: method, Params: params, Result: result}
+	if err := c.do(ctx, []*Call{call}, false); err != nil {
+		return err
+	}
+
+	return call.Error
+}
+
+// Notify sends a notification.
+func (c *Client) Notify(ctx context.Context, method string, params interface{}) error {
+	return c.do(ctx, []*Call{{Method: method, Params: params, Notification: true}}, false)
+}
+
+// Call is a call in a batch, see Client.Batch.
+type Call struct {
+	Method       string
+	Params       interface{}
+	Notification bool
+
+	// Result optionally points to the value the result is decoded into.
+	Result interface{}
+
+	// Response and Error are set by Batch. Error is a *coder.Error if the
+	// call failed or an error if the result could not be decoded.
+	Response *coder.Response
+	Error    error
+}
+
+// Batch sends the calls as batch. The returned error is only set if the batch
+// failed as a whole, the error of each call is set in Call.Error.
+func (c *Client) Batch(ctx context.Context, calls ...*Call) error {
+	return c.do(ctx, calls, true)
+}
+
+func (c *Client) do(ctx context.Context, calls []*Call, batch bool) error {
+	mediaType := c.MediaType
+	if mediaType == "" {
+		mediaType = DefaultMediaType
+	}
+
+	codec := coder.NewClient(mediaType)
+	if codec == nil {
+		return fmt.Errorf("client: no client codec for media type %q", mediaType)
+	}
+
+	reqs := make([]*coder.Request, len(calls))
+	byID := make(map[string]*Call)
+	for i, call := range calls {
+		params := call.Params
+		if params == nil {
+			params = []interface{}{}
+		}
+
+		var id coder.RequestID
+		if !call.Notification {
+			id = codec.NewID(int(atomic.AddInt64(&c.id, 1)))
+			byID[string(id)] = call
+		}
+
+		reqs[i] = &coder.Request{Method: call.Method, Params: params, ID: &id}
+	}
+
+	body, err := codec.EncodeRequests(reqs, batch)
+	if err != nil {
+		return err
+	}
+
+	data, status, err := c.post(ctx, mediaType, body)
+	if err != nil {
+		return err
+	}
+
+	if len(byID) == 0 {
+		return nil
+	}
+
+	if len(bytes.TrimSpace(data)) == 0 {
+		return fmt.Errorf("client: empty response (HTTP status %d)", status)
+	}
+
+	resps, _, err := codec.DecodeResponses(data)
+	if err != nil {
+		return fmt.Errorf("client: invalid response (HTTP status %d): %v", status, err)
+	}
+
+	for _, resp := range resps {
+		if resp.ID == nil || *resp.ID == nil {
+			// The request as a whole failed.
+			if resp.Error != nil {
+				return resp.Error
+			}
+			continue
+		}
+
+		call, ok := byID[string(*resp.ID)]
+		if !ok {
+			return fmt.Errorf("client: response for unknown id %s", *resp.ID)
+		}
+		delete(byID, string(*resp.ID))
+
+		call.Response = resp
+		switch {
+		case resp.Error != nil:
+			call.Error = resp.Error
+		case call.Result != nil:
+			call.Error = convert.Value(resp.Result, call.Result)
+		}
+	}
+
+	for _, call := range byID {
+		call.Error = errors.New("client: no response")
+	}
+
+	return nil
+}
+
+func (c *Client) post(ctx context.Context, mediaType string, body []byte) ([]byte, int, error) {
+	r, err := http.NewRequestWithContext(ctx, "POST", c.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	for k, v := range c.Header {
+		r.Header[k] = v
+	}
+	r.Header.Set("Content-Type", mediaType)
+	generpc.InjectTraceHeaders(ctx, r.Header)
+
+	hc := c.HTTPClient
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+
+	resp, err := hc.Do(r)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	return data, resp.StatusCode, err
+}
@@ -0,0 +1,126 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/dwlnetnl/generpc"
+	"github.com/dwlnetnl/generpc/coder"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testServer(t *testing.T, notified *int64) *httptest.Server {
+	s := generpc.NewServer()
+	s.Register("subtract", generpc.Method{
+		ParamNames: []string{"minuend", "subtrahend"},
+		Func: func(params []interface{}) interface{} {
+			a, _ := params[0].(coder.Number).CastFloat64()
+			b, _ := params[1].(coder.Number).CastFloat64()
+			return a - b
+		},
+	})
+	s.Register("user", generpc.Method{
+		Func: func(params []interface{}) interface{} {
+			return map[string]interface{}{"name": "alice", "age": 42}
+		},
+	})
+	s.Register("notify", generpc.Method{
+		Func: func(params []interface{}) interface{} {
+			atomic.AddInt64(notified, 1)
+			return nil
+		},
+	})
+
+	ts := httptest.NewServer(s)
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func TestClient(t *testing.T) {
+	var notified int64
+	c := New(testServer(t, &notified).URL)
+	ctx := context.Background()
+
+	var n int
+	require.NoError(t, c.Call(ctx, "subtract", []interface{}{42, 23}, &n))
+	assert.Equal(t, 19, n)
+
+	require.NoError(t, c.Call(ctx, "subtract", map[string]interface{}{"subtrahend": 23, "minuend": 42}, &n))
+	assert.Equal(t, 19, n)
+
+	var u struct {
+		Name string
+		Age  int
+	}
+	require.NoError(t, c.Call(ctx, "user", nil, &u))
+	assert.Equal(t, "alice", u.Name)
+	assert.Equal(t, 42, u.Age)
+
+	err := c.Call(ctx, "missing", nil, nil)
+	var e *coder.Error
+	require.ErrorAs(t, err, &e)
+	assert.Equal(t, -32601, e.Code)
+
+	require.NoError(t, c.Notify(ctx, "notify", nil))
+	assert.EqualValues(t, 1, atomic.LoadInt64(&notified))
+}
+
+func TestClientBatch(t *testing.T) {
+	var notified int64
+	c := New(testServer(t, &notified).URL)
+
+	var n float64
+	calls := []*Call{
+		{Method: "subtract", Params: []interface{}{42.5, 0.5}, Result: &n},
+		{Method: "notify", Notification: true},
+		{Method: "missing"},
+	}
+	require.NoError(t, c.Batch(context.Background(), calls...))
+
+	assert.NoError(t, calls[0].Error)
+	assert.Equal(t, 42.0, n)
+	assert.Nil(t, calls[1].Response)
+	var e *coder.Error
+	require.ErrorAs(t, calls[2].Error, &e)
+	assert.Equal(t, -32601, e.Code)
+	assert.EqualValues(t, 1, atomic.LoadInt64(&notified))
+
+	require.NoError(t, c.Batch(context.Background(), &Call{Method: "notify", Notification: true}))
+	assert.EqualValues(t, 2, atomic.LoadInt64(&notified))
+}
+
+func TestClientHeaders(t *testing.T) {
+	var got http.Header
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header
+		w.Write([]byte(`{"jsonrpc":"2.0","result":true,"id":1}`))
+	}))
+	defer ts.Close()
+
+	c := New(ts.URL)
+	c.Header.Set("Authorization", "Bearer token")
+
+	sc := generpc.SpanContext{TraceID: [16]byte{1}, SpanID: [8]byte{2}, TraceFlags: 1}
+	ctx := generpc.ContextWithSpanContext(context.Background(), sc)
+
+	var ok bool
+	require.NoError(t, c.Call(ctx, "m", nil, &ok))
+	assert.True(t, ok)
+	assert.Equal(t, "Bearer token", got.Get("Authorization"))
+	assert.Equal(t, "application/json", got.Get("Content-Type"))
+	assert.Equal(t, sc.Traceparent(), got.Get(generpc.TraceparentHeader))
+}
+
+func TestClientInvalidResponse(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "bad gateway", http.StatusBadGateway)
+	}))
+	defer ts.Close()
+
+	err := New(ts.URL).Call(context.Background(), "m", nil, nil)
+	assert.EqualError(t, err, "client: invalid response (HTTP status 502): invalid character 'b' looking for beginning of value")
+}
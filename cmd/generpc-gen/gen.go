@@ -0,0 +1,530 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/dwlnetnl/generpc/openrpc"
+)
+
+// iface describes the interface to generate code for.
+type iface struct {
+	pkg     string
+	name    string
+	decls   []byte // declarations preceding the generated code
+	methods []*method
+	aliases []alias
+	imports map[string]string // path by name
+}
+
+type method struct {
+	name           string // Go method name
+	rpcName        string
+	context        bool
+	params         []param
+	optional       int    // number of trailing optional params
+	result         string // Go type or "" if the method only returns an error
+	resultSchema   string
+	paramStructure string
+	errors         []string // Go expressions of the errors
+	deprecated     string
+}
+
+type param struct {
+	name   string // RPC name
+	goName string
+	typ    string
+	schema string // JSON Schema or "" if not described
+}
+
+type alias struct {
+	name, target, deprecated string
+}
+
+// use adds the import of path.
+func (it *iface) use(path string) {
+	it.imports[path[strings.LastIndexByte(path, '/')+1:]] = path
+}
+
+// generate generates the code for the interface typ in src.
+func generate(filename string, src []byte, typ, prefix string) ([]byte, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, filename, src, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+
+	it, err := parseInterface(f, typ, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	return it.generate()
+}
+
+func (it *iface) generate() ([]byte, error) {
+	var buf bytes.Buffer
+	it.write(&buf)
+
+	out, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("format generated code: %v\n%s", err, buf.Bytes())
+	}
+
+	return out, nil
+}
+
+func parseInterface(f *ast.File, typ, prefix string) (*iface, error) {
+	var t *ast.InterfaceType
+	ast.Inspect(f, func(n ast.Node) bool {
+		if ts, ok := n.(*ast.TypeSpec); ok && ts.Name.Name == typ {
+			t, _ = ts.Type.(*ast.InterfaceType)
+		}
+		return t == nil
+	})
+
+	if t == nil {
+		return nil, fmt.Errorf("interface %s not found", typ)
+	}
+
+	imports := make(map[string]string)
+	for _, spec := range f.Imports {
+		path, _ := strconv.Unquote(spec.Path.Value)
+		name := path[strings.LastIndexByte(path, '/')+1:]
+		if spec.Name != nil {
+			name = spec.Name.Name
+		}
+		imports[name] = path
+	}
+
+	it := &iface{
+		pkg:     f.Name.Name,
+		name:    typ,
+		imports: make(map[string]string),
+	}
+
+	for _, field := range t.Methods.List {
+		ft, ok := field.Type.(*ast.FuncType)
+		if !ok || len(field.Names) == 0 {
+			return nil, fmt.Errorf("%s: embedded interfaces are not supported", typ)
+		}
+
+		m, err := parseMethod(field.Names[0].Name, ft, field.Doc, prefix)
+		if err != nil {
+			return nil, fmt.Errorf("%s.%s: %v", typ, field.Names[0].Name, err)
+		}
+		it.methods = append(it.methods, m)
+
+		ast.Inspect(ft, func(n ast.Node) bool {
+			if sel, ok := n.(*ast.SelectorExpr); ok {
+				if id, ok := sel.X.(*ast.Ident); ok && imports[id.Name] != "" {
+					it.imports[id.Name] = imports[id.Name]
+				}
+			}
+			return true
+		})
+	}
+
+	return it, nil
+}
+
+func parseMethod(name string, ft *ast.FuncType, doc *ast.CommentGroup, prefix string) (*method, error) {
+	m := &method{name: name, rpcName: prefix + lowerCamel(name)}
+
+	if doc != nil {
+		for _, c := range doc.List {
+			if s := strings.TrimPrefix(c.Text, "//generpc:name "); s != c.Text {
+				m.rpcName = strings.TrimSpace(s)
+			}
+		}
+	}
+
+	for _, field := range ft.Params.List {
+		if _, ok := field.Type.(*ast.Ellipsis); ok {
+			return nil, errors.New("variadic parameters are not supported")
+		}
+
+		typ := types.ExprString(field.Type)
+		if typ == "context.Context" && len(m.params) == 0 && !m.context {
+			m.context = true
+			continue
+		}
+
+		names := field.Names
+		if len(names) == 0 {
+			names = []*ast.Ident{{Name: "_"}}
+		}
+
+		for _, id := range names {
+			name := id.Name
+			if name == "_" {
+				name = "param" + strconv.Itoa(len(m.params))
+			}
+			m.params = append(m.params, param{name, name, typ, schemaOf(typ)})
+		}
+	}
+
+	var results []string
+	if ft.Results != nil {
+		for _, field := range ft.Results.List {
+			n := len(field.Names)
+			if n == 0 {
+				n = 1
+			}
+			for i := 0; i < n; i++ {
+				results = append(results, types.ExprString(field.Type))
+			}
+		}
+	}
+
+	switch {
+	case len(results) == 1 && results[0] == "error":
+	case len(results) == 2 && results[1] == "error":
+		m.result = results[0]
+		m.resultSchema = schemaOf(m.result)
+	default:
+		return nil, errors.New("method should return (T, error) or error")
+	}
+
+	return m, nil
+}
+
+// lowerCamel returns s with the leading upper case letters in lower case, an
+// initialism is kept together: "HTTPStatus" becomes "httpStatus".
+func lowerCamel(s string) string {
+	r := []rune(s)
+	n := 0
+	for n < len(r) && unicode.IsUpper(r[n]) {
+		n++
+	}
+
+	if n > 1 && n < len(r) {
+		n-- // the last upper case letter starts the next word
+	}
+
+	for i := 0; i < n; i++ {
+		r[i] = unicode.ToLower(r[i])
+	}
+
+	return string(r)
+}
+
+// conversion returns the convert function call for a Go type and the
+// conversion of its result. The call is "" for types that are converted with
+// convert.Value.
+func conversion(typ string) (call, conv string) {
+	switch typ {
+	case "interface{}", "any":
+		return "", ""
+	case "int64":
+		return "convert.Int(%s, 64)", ""
+	case "uint64":
+		return "convert.Uint(%s, 64)", ""
+	case "float64":
+		return "convert.Float(%s, 64)", ""
+	case "int", "int8", "int16", "int32", "rune":
+		return "convert.Int(%s, " + bitSize(typ) + ")", typ
+	case "uint", "uint8", "uint16", "uint32", "byte", "uintptr":
+		return "convert.Uint(%s, " + bitSize(typ) + ")", typ
+	case "float32":
+		return "convert.Float(%s, 32)", typ
+	case "string":
+		return "convert.String(%s)", ""
+	case "bool":
+		return "convert.Bool(%s)", ""
+	}
+
+	return "", "value"
+}
+
+func bitSize(typ string) string {
+	switch typ {
+	case "rune":
+		return "32"
+	case "byte":
+		return "8"
+	case "int", "uint", "uintptr":
+		return "0"
+	}
+
+	return strings.TrimLeft(typ, "uint")
+}
+
+// schemaOf returns a JSON Schema for basic types, slices of basic types and
+// maps of basic types, or "" if the type isn't described.
+func schemaOf(typ string) string {
+	switch typ {
+	case "int", "int8", "int16", "int32", "int64", "rune",
+		"uint", "uint8", "uint16", "uint32", "uint64", "byte", "uintptr":
+		return `{"type":"integer"}`
+	case "float32", "float64":
+		return `{"type":"number"}`
+	case "string":
+		return `{"type":"string"}`
+	case "bool":
+		return `{"type":"boolean"}`
+	}
+
+	if s := strings.TrimPrefix(typ, "[]"); s != typ {
+		if items := schemaOf(s); items != "" {
+			return `{"type":"array","items":` + items + `}`
+		}
+	}
+
+	if s := strings.TrimPrefix(typ, "map[string]"); s != typ {
+		if props := schemaOf(s); props != "" {
+			return `{"type":"object","additionalProperties":` + props + `}`
+		}
+	}
+
+	return ""
+}
+
+func (it *iface) write(buf *bytes.Buffer) {
+	var body bytes.Buffer
+	p := func(format string, args ...interface{}) {
+		fmt.Fprintf(&body, format+"\n", args...)
+	}
+
+	body.Write(it.decls)
+	it.writeServer(p)
+	it.writeClient(p)
+
+	fmt.Fprintf(buf, "// Code generated by generpc-gen. DO NOT EDIT.\n\n")
+	fmt.Fprintf(buf, "package %s\n\n", it.pkg)
+
+	it.use("context")
+	it.use("github.com/dwlnetnl/generpc")
+	it.use("github.com/dwlnetnl/generpc/client")
+	it.use("github.com/dwlnetnl/generpc/convert")
+
+	var std, other []string
+	for name, path := range it.imports {
+		spec := strconv.Quote(path)
+		if name != path[strings.LastIndexByte(path, '/')+1:] {
+			spec = name + " " + spec
+		}
+		if strings.Contains(strings.SplitN(path, "/", 2)[0], ".") {
+			other = append(other, spec)
+		} else {
+			std = append(std, spec)
+		}
+	}
+	sort.Strings(std)
+	sort.Strings(other)
+	fmt.Fprintf(buf, "import (\n%s\n\n%s\n)\n\n", strings.Join(std, "\n"), strings.Join(other, "\n"))
+
+	buf.Write(body.Bytes())
+}
+
+func (it *iface) writeServer(p func(string, ...interface{})) {
+	p("// Register%s registers the methods of %[1]s on s.", it.name)
+	p("func Register%s(s *generpc.Server, impl %[1]s) {", it.name)
+	for _, m := range it.methods {
+		p("s.Register(%q, generpc.Method{", m.rpcName)
+
+		if len(m.params) > 0 {
+			names := make([]string, len(m.params))
+			for i, param := range m.params {
+				names[i] = strconv.Quote(param.name)
+			}
+			p("ParamNames: []string{%s},", strings.Join(names, ", "))
+
+			var schemas []string
+			described := false
+			for _, param := range m.params {
+				if param.schema == "" {
+					schemas = append(schemas, "nil")
+					continue
+				}
+				schemas = append(schemas, it.schema(param.schema))
+				described = true
+			}
+			if described {
+				p("ParamSchemas: []*schema.Schema{%s},", strings.Join(schemas, ", "))
+			}
+		}
+
+		if m.optional > 0 {
+			p("OptionalParams: %d,", m.optional)
+		}
+
+		if m.resultSchema != "" {
+			p("ResultSchema: %s,", it.schema(m.resultSchema))
+		}
+
+		switch m.paramStructure {
+		case openrpc.ByName:
+			it.use("github.com/dwlnetnl/generpc/openrpc")
+			p("ParamStructure: openrpc.ByName,")
+		case openrpc.ByPosition:
+			it.use("github.com/dwlnetnl/generpc/openrpc")
+			p("ParamStructure: openrpc.ByPosition,")
+		}
+
+		if len(m.errors) > 0 {
+			it.use("github.com/dwlnetnl/generpc/coder")
+			p("Errors: []coder.Error{%s},", strings.Join(m.errors, ", "))
+		}
+
+		if m.deprecated != "" {
+			p("Deprecated: %q,", m.deprecated)
+		}
+
+		if m.context {
+			p("FuncContext: func(ctx context.Context, params []interface{}) interface{} {")
+		} else {
+			p("Func: func(params []interface{}) interface{} {")
+		}
+
+		if m.optional > 0 {
+			p("params, perr := convert.ParamRange(params, %d, %d)", len(m.params)-m.optional, len(m.params))
+			p("if perr != nil {")
+			p("return *perr")
+			p("}")
+		} else {
+			p("if err := convert.ParamCount(params, %d); err != nil {", len(m.params))
+			p("return *err")
+			p("}")
+		}
+
+		var args []string
+		if m.context {
+			args = append(args, "ctx")
+		}
+
+		for i, param := range m.params {
+			arg := "p" + strconv.Itoa(i)
+			call, conv := conversion(param.typ)
+			switch {
+			case call != "":
+				p("%s, err := "+call, arg, "params["+strconv.Itoa(i)+"]")
+				p("if err != nil {")
+				p("return *convert.ParamError(%q, err)", param.name)
+				p("}")
+				if conv != "" {
+					arg = conv + "(" + arg + ")"
+				}
+
+			case conv == "value":
+				p("var %s %s", arg, param.typ)
+				p("if err := convert.Value(params[%d], &%s); err != nil {", i, arg)
+				p("return *convert.ParamError(%q, err)", param.name)
+				p("}")
+
+			default:
+				arg = "params[" + strconv.Itoa(i) + "]"
+			}
+			args = append(args, arg)
+		}
+
+		call := "impl." + m.name + "(" + strings.Join(args, ", ") + ")"
+		if m.result == "" {
+			p("if err := %s; err != nil {", call)
+			p("return err")
+			p("}")
+			p("return nil")
+		} else {
+			p("r, err := %s", call)
+			p("if err != nil {")
+			p("return err")
+			p("}")
+			p("return r")
+		}
+
+		p("},")
+		p("})")
+	}
+
+	for _, a := range it.aliases {
+		p("s.Alias(%q, %q)", a.name, a.target)
+		if a.deprecated != "" {
+			p("s.Deprecate(%q, %q)", a.name, a.deprecated)
+		}
+	}
+	p("}")
+	p("")
+}
+
+// schema returns the expression of a JSON Schema.
+func (it *iface) schema(s string) string {
+	it.use("github.com/dwlnetnl/generpc/schema")
+	if strings.Contains(s, "`") {
+		return "schema.MustParse(" + strconv.Quote(s) + ")"
+	}
+
+	return "schema.MustParse(`" + s + "`)"
+}
+
+// reserved are the identifiers used in the generated client methods.
+var reserved = map[string]bool{"c": true, "ctx": true, "r": true, "err": true}
+
+func (it *iface) writeClient(p func(string, ...interface{})) {
+	client := it.name + "Client"
+	implements := true
+	for _, m := range it.methods {
+		implements = implements && m.context
+	}
+
+	p("// %s is a typed client for %s.", client, it.name)
+	p("type %s struct {", client)
+	p("Client *client.Client")
+	p("}")
+	p("")
+	if implements {
+		p("var _ %s = (*%s)(nil)", it.name, client)
+		p("")
+	}
+	p("// New%s returns a %s that calls methods via c.", client, client)
+	p("func New%s(c *client.Client) *%[1]s {", client)
+	p("return &%s{Client: c}", client)
+	p("}")
+
+	for _, m := range it.methods {
+		params := []string{"ctx context.Context"}
+		var args []string
+		for _, param := range m.params {
+			name := param.goName
+			if reserved[name] {
+				name += "_"
+			}
+			params = append(params, name+" "+param.typ)
+			if m.paramStructure == openrpc.ByName {
+				args = append(args, strconv.Quote(param.name)+": "+name)
+			} else {
+				args = append(args, name)
+			}
+		}
+
+		call := fmt.Sprintf("c.Client.Call(ctx, %q, []interface{}{%s}", m.rpcName, strings.Join(args, ", "))
+		if m.paramStructure == openrpc.ByName {
+			call = fmt.Sprintf("c.Client.Call(ctx, %q, map[string]interface{}{%s}", m.rpcName, strings.Join(args, ", "))
+		}
+		p("")
+		p("// %s calls %s.", m.name, m.rpcName)
+		if m.deprecated != "" {
+			p("//")
+			p("// Deprecated: %s", m.deprecated)
+		}
+		if m.result == "" {
+			p("func (c *%s) %s(%s) error {", client, m.name, strings.Join(params, ", "))
+			p("return %s, nil)", call)
+		} else {
+			p("func (c *%s) %s(%s) (%s, error) {", client, m.name, strings.Join(params, ", "), m.result)
+			p("var r %s", m.result)
+			p("err := %s, &r)", call)
+			p("return r, err")
+		}
+		p("}")
+	}
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerate(t *testing.T) {
+	src, err := os.ReadFile("internal/example/example.go")
+	require.NoError(t, err)
+
+	got, err := generate("example.go", src, "Calculator", "calc.")
+	require.NoError(t, err)
+
+	want, err := os.ReadFile("internal/example/calculator_generpc.go")
+	require.NoError(t, err)
+	assert.Equal(t, string(want), string(got), "run go generate ./cmd/generpc-gen/internal/example")
+}
+
+func TestGenerateUnnamed(t *testing.T) {
+	src := `package p
+
+type Service interface {
+	// Ping pings.
+	Ping(string, int) error
+	HTTPStatus() (int, error)
+}
+`
+	got, err := generate("p.go", []byte(src), "Service", "")
+	require.NoError(t, err)
+
+	out := string(got)
+	assert.Contains(t, out, `s.Register("ping", generpc.Method{`)
+	assert.Contains(t, out, `ParamNames:   []string{"param0", "param1"},`)
+	assert.Contains(t, out, `Func: func(params []interface{}) interface{} {`)
+	assert.Contains(t, out, `s.Register("httpStatus", generpc.Method{`)
+	assert.NotContains(t, out, "var _ Service")
+}
+
+func TestGenerateErrors(t *testing.T) {
+	tests := []struct {
+		src string
+		err string
+	}{
+		{"type Other interface{}", "interface Service not found"},
+		{"type Service interface{ Other }", "Service: embedded interfaces are not supported"},
+		{"type Service interface{ M(v ...int) error }", "Service.M: variadic parameters are not supported"},
+		{"type Service interface{ M() int }", "Service.M: method should return (T, error) or error"},
+		{"type Service interface{ M() }", "Service.M: method should return (T, error) or error"},
+	}
+
+	for _, tt := range tests {
+		_, err := generate("p.go", []byte("package p\n"+tt.src), "Service", "")
+		assert.EqualError(t, err, tt.err, tt.src)
+	}
+}
+
+func TestLowerCamel(t *testing.T) {
+	for in, want := range map[string]string{
+		"Add":        "add",
+		"GetUser":    "getUser",
+		"HTTPStatus": "httpStatus",
+		"ID":         "id",
+		"X":          "x",
+	} {
+		assert.Equal(t, want, lowerCamel(in), in)
+	}
+}
+
+func TestSchemaOf(t *testing.T) {
+	assert.Equal(t, `{"type":"integer"}`, schemaOf("uint16"))
+	assert.Equal(t, `{"type":"array","items":{"type":"string"}}`, schemaOf("[]string"))
+	assert.Equal(t, `{"type":"object","additionalProperties":{"type":"number"}}`, schemaOf("map[string]float64"))
+	assert.Empty(t, schemaOf("Person"))
+	assert.Empty(t, schemaOf("[]Person"))
+	assert.Empty(t, schemaOf("*int"))
+}
+
+func TestGenerateOpenRPC(t *testing.T) {
+	doc, err := os.ReadFile("internal/users/users.json")
+	require.NoError(t, err)
+
+	got, err := generateOpenRPC(doc, "users", "Service")
+	require.NoError(t, err)
+
+	want, err := os.ReadFile("internal/users/service_generpc.go")
+	require.NoError(t, err)
+	assert.Equal(t, string(want), string(got), "run go generate ./cmd/generpc-gen/internal/users")
+}
+
+func TestGenerateOpenRPCErrors(t *testing.T) {
+	tests := []struct {
+		doc string
+		err string
+	}{
+		{`[]`, "invalid OpenRPC document: json: cannot unmarshal array into Go value of type openrpc.Document"},
+		{
+			`{"methods":[{"name":"m","params":[{"$ref":"#/components/contentDescriptors/X"}]}]}`,
+			"method m: unknown reference #/components/contentDescriptors/X",
+		},
+		{
+			`{"methods":[{"name":"m","params":[],"errors":[{"$ref":"#/components/errors/X"}]}]}`,
+			"method m: unknown reference #/components/errors/X",
+		},
+		{
+			`{"methods":[],"components":{"errors":{"A":{"code":1,"message":"a"},"B":{"code":1,"message":"b"}}}}`,
+			"errors A and B have the same code 1",
+		},
+		{
+			`{"methods":[{"name":"m","params":[],"errors":[{"code":1,"message":"a"},{"code":1,"message":"b"}]}]}`,
+			"method m: errors A and B have the same code 1",
+		},
+	}
+
+	for _, tt := range tests {
+		_, err := generateOpenRPC([]byte(tt.doc), "p", "Service")
+		assert.EqualError(t, err, tt.err, tt.doc)
+	}
+}
+
+func TestGoName(t *testing.T) {
+	for in, want := range map[string]string{
+		"user.get":   "UserGet",
+		"user_id":    "UserID",
+		"first-name": "FirstName",
+		"getURL":     "GetURL",
+		"2fa":        "X2fa",
+		"":           "X",
+	} {
+		assert.Equal(t, want, goName(in), in)
+	}
+}
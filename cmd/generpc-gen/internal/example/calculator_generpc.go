@@ -0,0 +1,183 @@
+// Code generated by generpc-gen. DO NOT EDIT.
+
+package example
+
+import (
+	"context"
+	"time"
+
+	"github.com/dwlnetnl/generpc"
+	"github.com/dwlnetnl/generpc/client"
+	"github.com/dwlnetnl/generpc/convert"
+	"github.com/dwlnetnl/generpc/schema"
+)
+
+// RegisterCalculator registers the methods of Calculator on s.
+func RegisterCalculator(s *generpc.Server, impl Calculator) {
+	s.Register("calc.add", generpc.Method{
+		ParamNames:   []string{"a", "b"},
+		ParamSchemas: []*schema.Schema{schema.MustParse(`{"type":"integer"}`), schema.MustParse(`{"type":"integer"}`)},
+		ResultSchema: schema.MustParse(`{"type":"integer"}`),
+		FuncContext: func(ctx context.Context, params []interface{}) interface{} {
+			if err := convert.ParamCount(params, 2); err != nil {
+				return *err
+			}
+			p0, err := convert.Int(params[0], 0)
+			if err != nil {
+				return *convert.ParamError("a", err)
+			}
+			p1, err := convert.Int(params[1], 0)
+			if err != nil {
+				return *convert.ParamError("b", err)
+			}
+			r, err := impl.Add(ctx, int(p0), int(p1))
+			if err != nil {
+				return err
+			}
+			return r
+		},
+	})
+	s.Register("calc.divide", generpc.Method{
+		ParamNames:   []string{"dividend", "divisor"},
+		ParamSchemas: []*schema.Schema{schema.MustParse(`{"type":"number"}`), schema.MustParse(`{"type":"number"}`)},
+		ResultSchema: schema.MustParse(`{"type":"number"}`),
+		FuncContext: func(ctx context.Context, params []interface{}) interface{} {
+			if err := convert.ParamCount(params, 2); err != nil {
+				return *err
+			}
+			p0, err := convert.Float(params[0], 64)
+			if err != nil {
+				return *convert.ParamError("dividend", err)
+			}
+			p1, err := convert.Float(params[1], 64)
+			if err != nil {
+				return *convert.ParamError("divisor", err)
+			}
+			r, err := impl.Divide(ctx, p0, p1)
+			if err != nil {
+				return err
+			}
+			return r
+		},
+	})
+	s.Register("calc.sum", generpc.Method{
+		ParamNames:   []string{"values"},
+		ParamSchemas: []*schema.Schema{schema.MustParse(`{"type":"array","items":{"type":"integer"}}`)},
+		ResultSchema: schema.MustParse(`{"type":"integer"}`),
+		FuncContext: func(ctx context.Context, params []interface{}) interface{} {
+			if err := convert.ParamCount(params, 1); err != nil {
+				return *err
+			}
+			var p0 []int
+			if err := convert.Value(params[0], &p0); err != nil {
+				return *convert.ParamError("values", err)
+			}
+			r, err := impl.Sum(ctx, p0)
+			if err != nil {
+				return err
+			}
+			return r
+		},
+	})
+	s.Register("calc.hello", generpc.Method{
+		ParamNames: []string{"p"},
+		FuncContext: func(ctx context.Context, params []interface{}) interface{} {
+			if err := convert.ParamCount(params, 1); err != nil {
+				return *err
+			}
+			var p0 Person
+			if err := convert.Value(params[0], &p0); err != nil {
+				return *convert.ParamError("p", err)
+			}
+			r, err := impl.Greet(ctx, p0)
+			if err != nil {
+				return err
+			}
+			return r
+		},
+	})
+	s.Register("calc.after", generpc.Method{
+		ParamNames: []string{"t", "d"},
+		FuncContext: func(ctx context.Context, params []interface{}) interface{} {
+			if err := convert.ParamCount(params, 2); err != nil {
+				return *err
+			}
+			var p0 time.Time
+			if err := convert.Value(params[0], &p0); err != nil {
+				return *convert.ParamError("t", err)
+			}
+			var p1 time.Duration
+			if err := convert.Value(params[1], &p1); err != nil {
+				return *convert.ParamError("d", err)
+			}
+			r, err := impl.After(ctx, p0, p1)
+			if err != nil {
+				return err
+			}
+			return r
+		},
+	})
+	s.Register("calc.reset", generpc.Method{
+		FuncContext: func(ctx context.Context, params []interface{}) interface{} {
+			if err := convert.ParamCount(params, 0); err != nil {
+				return *err
+			}
+			if err := impl.Reset(ctx); err != nil {
+				return err
+			}
+			return nil
+		},
+	})
+}
+
+// CalculatorClient is a typed client for Calculator.
+type CalculatorClient struct {
+	Client *client.Client
+}
+
+var _ Calculator = (*CalculatorClient)(nil)
+
+// NewCalculatorClient returns a CalculatorClient that calls methods via c.
+func NewCalculatorClient(c *client.Client) *CalculatorClient {
+	return &CalculatorClient{Client: c}
+}
+
+// Add calls calc.add.
+func (c *CalculatorClient) Add(ctx context.Context, a int, b int) (int, error) {
+	var r int
+	err := c.Client.Call(ctx, "calc.add", []interface{}{a, b}, &r)
+	return r, err
+}
+
+// Divide calls calc.divide.
+func (c *CalculatorClient) Divide(ctx context.Context, dividend float64, divisor float64) (float64, error) {
+	var r float64
+	err := c.Client.Call(ctx, "calc.divide", []interface{}{dividend, divisor}, &r)
+	return r, err
+}
+
+// Sum calls calc.sum.
+func (c *CalculatorClient) Sum(ctx context.Context, values []int) (int, error) {
+	var r int
+	err := c.Client.Call(ctx, "calc.sum", []interface{}{values}, &r)
+	return r, err
+}
+
+// Greet calls calc.hello.
+func (c *CalculatorClient) Greet(ctx context.Context, p Person) (*Greeting, error) {
+	var r *Greeting
+	err := c.Client.Call(ctx, "calc.hello", []interface{}{p}, &r)
+	return r, err
+}
+
+// After calls calc.after.
+func (c *CalculatorClient) After(ctx context.Context, t time.Time, d time.Duration) (time.Time, error) {
+	var r time.Time
+	err := c.Client.Call(ctx, "calc.after", []interface{}{t, d}, &r)
+	return r, err
+}
+
+// Reset calls calc.reset.
+func (c *CalculatorClient) Reset(ctx context.Context) error {
+	return c.Client.Call(ctx, "calc.reset", []interface{}{}, nil)
+}
@@ -0,0 +1,90 @@
+// Package example contains an interface with generated code, see
+// calculator_generpc.go. It's used to test generpc-gen.
+package example
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/dwlnetnl/generpc/coder"
+)
+
+//go:generate go run github.com/dwlnetnl/generpc/cmd/generpc-gen -type Calculator -prefix calc.
+
+// Calculator is an example service.
+type Calculator interface {
+	Add(ctx context.Context, a, b int) (int, error)
+	Divide(ctx context.Context, dividend, divisor float64) (float64, error)
+	Sum(ctx context.Context, values []int) (int, error)
+
+	//generpc:name calc.hello
+	Greet(ctx context.Context, p Person) (*Greeting, error)
+
+	After(ctx context.Context, t time.Time, d time.Duration) (time.Time, error)
+	Reset(ctx context.Context) error
+}
+
+// Person is a param of Calculator.Greet.
+type Person struct {
+	Name string `json:"name"`
+	Age  uint8  `json:"age"`
+}
+
+// Greeting is the result of Calculator.Greet.
+type Greeting struct {
+	Message string `json:"message"`
+}
+
+// ErrDivisionByZero is returned by Calc.Divide.
+var ErrDivisionByZero = &coder.Error{Code: 1, Message: "division by zero"}
+
+// Calc implements Calculator.
+type Calc struct {
+	Resets int
+}
+
+// Add implements Calculator.
+func (c *Calc) Add(ctx context.Context, a, b int) (int, error) {
+	return a + b, nil
+}
+
+// Divide implements Calculator.
+func (c *Calc) Divide(ctx context.Context, dividend, divisor float64) (float64, error) {
+	if divisor == 0 {
+		return 0, ErrDivisionByZero
+	}
+
+	return dividend / divisor, nil
+}
+
+// Sum implements Calculator.
+func (c *Calc) Sum(ctx context.Context, values []int) (int, error) {
+	sum := 0
+	for _, v := range values {
+		sum += v
+	}
+
+	return sum, nil
+}
+
+// Greet implements Calculator.
+func (c *Calc) Greet(ctx context.Context, p Person) (*Greeting, error) {
+	if p.Name == "" {
+		return nil, errors.New("example: no name")
+	}
+
+	return &Greeting{Message: fmt.Sprintf("Hello %s (%d)", p.Name, p.Age)}, nil
+}
+
+// After implements Calculator.
+func (c *Calc) After(ctx context.Context, t time.Time, d time.Duration) (time.Time, error) {
+	return t.Add(d), nil
+}
+
+// Reset implements Calculator.
+func (c *Calc) Reset(ctx context.Context) error {
+	c.Resets++
+	return nil
+}
@@ -0,0 +1,106 @@
+package example
+
+import (
+	"context"
+	"io"
+	"log"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dwlnetnl/generpc"
+	"github.com/dwlnetnl/generpc/client"
+	"github.com/dwlnetnl/generpc/coder"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newClient(t *testing.T, impl Calculator) (*CalculatorClient, *client.Client) {
+	s := generpc.NewServer()
+	s.ErrorLog = log.New(io.Discard, "", 0)
+	RegisterCalculator(s, impl)
+
+	ts := httptest.NewServer(s)
+	t.Cleanup(ts.Close)
+
+	c := client.New(ts.URL)
+	return NewCalculatorClient(c), c
+}
+
+func TestCalculator(t *testing.T) {
+	calc := &Calc{}
+	c, _ := newClient(t, calc)
+	ctx := context.Background()
+
+	n, err := c.Add(ctx, 2, 3)
+	require.NoError(t, err)
+	assert.Equal(t, 5, n)
+
+	f, err := c.Divide(ctx, 1, 4)
+	require.NoError(t, err)
+	assert.Equal(t, 0.25, f)
+
+	n, err = c.Sum(ctx, []int{1, 2, 3})
+	require.NoError(t, err)
+	assert.Equal(t, 6, n)
+
+	g, err := c.Greet(ctx, Person{Name: "Alice", Age: 42})
+	require.NoError(t, err)
+	assert.Equal(t, "Hello Alice (42)", g.Message)
+
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	after, err := c.After(ctx, now, time.Hour)
+	require.NoError(t, err)
+	assert.True(t, now.Add(time.Hour).Equal(after))
+
+	require.NoError(t, c.Reset(ctx))
+	assert.Equal(t, 1, calc.Resets)
+}
+
+func TestCalculatorErrors(t *testing.T) {
+	c, rc := newClient(t, &Calc{})
+	ctx := context.Background()
+
+	_, err := c.Divide(ctx, 1, 0)
+	assert.Equal(t, ErrDivisionByZero, err)
+
+	_, err = c.Greet(ctx, Person{})
+	var e *coder.Error
+	require.ErrorAs(t, err, &e)
+	assert.Equal(t, -32603, e.Code)
+
+	tests := []struct {
+		method string
+		params interface{}
+		data   interface{}
+	}{
+		{"calc.add", []interface{}{1, 1.5}, []interface{}{
+			map[string]interface{}{"pointer": "/1", "message": "expected integer, got number"},
+		}},
+		{"calc.after", []interface{}{"now", 1}, map[string]interface{}{
+			"param":   "t",
+			"message": `parsing time "now" as "2006-01-02T15:04:05Z07:00": cannot parse "now" as "2006"`,
+		}},
+		{"calc.reset", []interface{}{1}, "expected 0 params, got 1"},
+		{"calc.hello", map[string]interface{}{"p": map[string]interface{}{"age": 256}}, map[string]interface{}{
+			"param":   "p",
+			"message": "json: cannot unmarshal number 256 into Go struct field Person.age of type uint8",
+		}},
+	}
+
+	for _, tt := range tests {
+		err := rc.Call(ctx, tt.method, tt.params, nil)
+		require.ErrorAs(t, err, &e, tt.method)
+		assert.Equal(t, -32602, e.Code, tt.method)
+		assert.Equal(t, tt.data, e.Data, tt.method)
+	}
+}
+
+func TestCalculatorNamedParams(t *testing.T) {
+	_, c := newClient(t, &Calc{})
+
+	var n int
+	err := c.Call(context.Background(), "calc.add", map[string]interface{}{"b": 3, "a": 2}, &n)
+	require.NoError(t, err)
+	assert.Equal(t, 5, n)
+}
@@ -0,0 +1,202 @@
+// Code generated by generpc-gen. DO NOT EDIT.
+
+package users
+
+import (
+	"context"
+
+	"github.com/dwlnetnl/generpc"
+	"github.com/dwlnetnl/generpc/client"
+	"github.com/dwlnetnl/generpc/coder"
+	"github.com/dwlnetnl/generpc/convert"
+	"github.com/dwlnetnl/generpc/openrpc"
+	"github.com/dwlnetnl/generpc/schema"
+)
+
+// Role is the Role schema.
+type Role string
+
+// Values of Role.
+const (
+	RoleAdmin  Role = "admin"
+	RoleMember Role = "member"
+)
+
+// User is the User schema.
+//
+// A registered user.
+type User struct {
+	Email *string  `json:"email,omitempty"`
+	ID    int      `json:"id"`
+	Name  string   `json:"name"`
+	Role  Role     `json:"role"`
+	Tags  []string `json:"tags,omitempty"`
+}
+
+// ErrNotFound is the users.NotFound error.
+var ErrNotFound = generpc.DefineError("users", "NotFound", 1, "User not found", schema.MustParse(`{"type":"integer"}`))
+
+// UserCreateUser is the user param of user.create.
+type UserCreateUser struct {
+	Email *string `json:"email,omitempty"`
+	// Full name.
+	Name string `json:"name"`
+	Role Role   `json:"role"`
+}
+
+// ErrEmailTaken is the EmailTaken error.
+var ErrEmailTaken = generpc.DefineError("users", "EmailTaken", 2, "Email taken", nil)
+
+// Service is implemented by the server of Users.
+type Service interface {
+	// UserGet is the user.get method.
+	//
+	// Returns a user.
+	UserGet(ctx context.Context, id int) (User, error)
+
+	// UserCreate is the user.create method.
+	//
+	// Creates a user.
+	//
+	// The ID of the new user is returned.
+	UserCreate(ctx context.Context, user UserCreateUser, notify *bool) (int, error)
+
+	// UserList is the user.list method.
+	UserList(ctx context.Context, role Role) ([]User, error)
+
+	// UserDelete is the user.delete method.
+	//
+	// Deprecated: use user.remove instead
+	UserDelete(ctx context.Context, id int) error
+}
+
+// RegisterService registers the methods of Service on s.
+func RegisterService(s *generpc.Server, impl Service) {
+	s.Register("user.get", generpc.Method{
+		ParamNames:     []string{"id"},
+		ParamSchemas:   []*schema.Schema{schema.MustParse(`{"type":"integer","minimum":1}`)},
+		ResultSchema:   schema.MustParse(`{"$ref":"#/$defs/User","$defs":{"Role":{"type":"string","enum":["admin","member"]},"User":{"description":"A registered user.","type":"object","properties":{"email":{"type":"string"},"id":{"type":"integer"},"name":{"type":"string"},"role":{"$ref":"#/$defs/Role"},"tags":{"type":"array","items":{"type":"string"}}},"required":["id","name","role"]}}}`),
+		ParamStructure: openrpc.ByName,
+		Errors:         []coder.Error{ErrNotFound},
+		FuncContext: func(ctx context.Context, params []interface{}) interface{} {
+			if err := convert.ParamCount(params, 1); err != nil {
+				return *err
+			}
+			p0, err := convert.Int(params[0], 0)
+			if err != nil {
+				return *convert.ParamError("id", err)
+			}
+			r, err := impl.UserGet(ctx, int(p0))
+			if err != nil {
+				return err
+			}
+			return r
+		},
+	})
+	s.Register("user.create", generpc.Method{
+		ParamNames:     []string{"user", "notify"},
+		ParamSchemas:   []*schema.Schema{schema.MustParse(`{"$defs":{"Role":{"type":"string","enum":["admin","member"]}},"type":"object","properties":{"email":{"type":"string"},"name":{"description":"Full name.","type":"string"},"role":{"$ref":"#/$defs/Role"}},"required":["name","role"]}`), schema.MustParse(`{"anyOf":[{"type":"null"},{"type":"boolean"}]}`)},
+		OptionalParams: 1,
+		ResultSchema:   schema.MustParse(`{"type":"integer"}`),
+		ParamStructure: openrpc.ByPosition,
+		Errors:         []coder.Error{ErrEmailTaken},
+		FuncContext: func(ctx context.Context, params []interface{}) interface{} {
+			params, perr := convert.ParamRange(params, 1, 2)
+			if perr != nil {
+				return *perr
+			}
+			var p0 UserCreateUser
+			if err := convert.Value(params[0], &p0); err != nil {
+				return *convert.ParamError("user", err)
+			}
+			var p1 *bool
+			if err := convert.Value(params[1], &p1); err != nil {
+				return *convert.ParamError("notify", err)
+			}
+			r, err := impl.UserCreate(ctx, p0, p1)
+			if err != nil {
+				return err
+			}
+			return r
+		},
+	})
+	s.Register("user.list", generpc.Method{
+		ParamNames:   []string{"role"},
+		ParamSchemas: []*schema.Schema{schema.MustParse(`{"$ref":"#/$defs/Role","$defs":{"Role":{"type":"string","enum":["admin","member"]}}}`)},
+		ResultSchema: schema.MustParse(`{"$defs":{"Role":{"type":"string","enum":["admin","member"]},"User":{"description":"A registered user.","type":"object","properties":{"email":{"type":"string"},"id":{"type":"integer"},"name":{"type":"string"},"role":{"$ref":"#/$defs/Role"},"tags":{"type":"array","items":{"type":"string"}}},"required":["id","name","role"]}},"type":"array","items":{"$ref":"#/$defs/User"}}`),
+		FuncContext: func(ctx context.Context, params []interface{}) interface{} {
+			if err := convert.ParamCount(params, 1); err != nil {
+				return *err
+			}
+			var p0 Role
+			if err := convert.Value(params[0], &p0); err != nil {
+				return *convert.ParamError("role", err)
+			}
+			r, err := impl.UserList(ctx, p0)
+			if err != nil {
+				return err
+			}
+			return r
+		},
+	})
+	s.Register("user.delete", generpc.Method{
+		ParamNames:   []string{"id"},
+		ParamSchemas: []*schema.Schema{schema.MustParse(`{"type":"integer","minimum":1}`)},
+		Errors:       []coder.Error{ErrNotFound},
+		Deprecated:   "use user.remove instead",
+		FuncContext: func(ctx context.Context, params []interface{}) interface{} {
+			if err := convert.ParamCount(params, 1); err != nil {
+				return *err
+			}
+			p0, err := convert.Int(params[0], 0)
+			if err != nil {
+				return *convert.ParamError("id", err)
+			}
+			if err := impl.UserDelete(ctx, int(p0)); err != nil {
+				return err
+			}
+			return nil
+		},
+	})
+	s.Alias("user.fetch", "user.get")
+}
+
+// ServiceClient is a typed client for Service.
+type ServiceClient struct {
+	Client *client.Client
+}
+
+var _ Service = (*ServiceClient)(nil)
+
+// NewServiceClient returns a ServiceClient that calls methods via c.
+func NewServiceClient(c *client.Client) *ServiceClient {
+	return &ServiceClient{Client: c}
+}
+
+// UserGet calls user.get.
+func (c *ServiceClient) UserGet(ctx context.Context, id int) (User, error) {
+	var r User
+	err := c.Client.Call(ctx, "user.get", map[string]interface{}{"id": id}, &r)
+	return r, err
+}
+
+// UserCreate calls user.create.
+func (c *ServiceClient) UserCreate(ctx context.Context, user UserCreateUser, notify *bool) (int, error) {
+	var r int
+	err := c.Client.Call(ctx, "user.create", []interface{}{user, notify}, &r)
+	return r, err
+}
+
+// UserList calls user.list.
+func (c *ServiceClient) UserList(ctx context.Context, role Role) ([]User, error) {
+	var r []User
+	err := c.Client.Call(ctx, "user.list", []interface{}{role}, &r)
+	return r, err
+}
+
+// UserDelete calls user.delete.
+//
+// Deprecated: use user.remove instead
+func (c *ServiceClient) UserDelete(ctx context.Context, id int) error {
+	return c.Client.Call(ctx, "user.delete", []interface{}{id}, nil)
+}
@@ -0,0 +1,5 @@
+// Package users contains code generated from the OpenRPC document users.json,
+// see service_generpc.go. It's used to test generpc-gen.
+package users
+
+//go:generate go run github.com/dwlnetnl/generpc/cmd/generpc-gen -type Service -openrpc users.json
@@ -0,0 +1,207 @@
+package users
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http/httptest"
+	"os"
+	"sort"
+	"testing"
+
+	"github.com/dwlnetnl/generpc"
+	"github.com/dwlnetnl/generpc/client"
+	"github.com/dwlnetnl/generpc/coder"
+	"github.com/dwlnetnl/generpc/openrpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type service struct {
+	users []User
+}
+
+func (s *service) UserGet(ctx context.Context, id int) (User, error) {
+	for _, u := range s.users {
+		if u.ID == id {
+			return u, nil
+		}
+	}
+
+	e := ErrNotFound
+	e.Data = id
+	return User{}, &e
+}
+
+func (s *service) UserCreate(ctx context.Context, user UserCreateUser, notify *bool) (int, error) {
+	for _, u := range s.users {
+		if u.Email != nil && user.Email != nil && *u.Email == *user.Email {
+			return 0, &ErrEmailTaken
+		}
+	}
+
+	u := User{ID: len(s.users) + 1, Name: user.Name, Email: user.Email, Role: user.Role}
+	s.users = append(s.users, u)
+	return u.ID, nil
+}
+
+func (s *service) UserList(ctx context.Context, role Role) ([]User, error) {
+	users := []User{}
+	for _, u := range s.users {
+		if u.Role == role {
+			users = append(users, u)
+		}
+	}
+
+	return users, nil
+}
+
+func (s *service) UserDelete(ctx context.Context, id int) error {
+	return &ErrNotFound
+}
+
+func newServer(t *testing.T) (*generpc.Server, *client.Client) {
+	s := generpc.NewServer()
+	s.ErrorLog = log.New(io.Discard, "", 0)
+	s.Development = true
+	RegisterService(s, &service{})
+
+	ts := httptest.NewServer(s)
+	t.Cleanup(ts.Close)
+	return s, client.New(ts.URL)
+}
+
+func TestService(t *testing.T) {
+	_, rc := newServer(t)
+	c := NewServiceClient(rc)
+	ctx := context.Background()
+
+	email := "alice@example.com"
+	id, err := c.UserCreate(ctx, UserCreateUser{Name: "Alice", Email: &email, Role: RoleAdmin}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 1, id)
+
+	notify := true
+	_, err = c.UserCreate(ctx, UserCreateUser{Name: "Bob", Email: &email, Role: RoleMember}, &notify)
+	var e *coder.Error
+	require.ErrorAs(t, err, &e)
+	assert.Equal(t, ErrEmailTaken.Code, e.Code)
+
+	u, err := c.UserGet(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, User{ID: 1, Name: "Alice", Email: &email, Role: RoleAdmin}, u)
+
+	_, err = c.UserGet(ctx, 2)
+	require.ErrorAs(t, err, &e)
+	assert.Equal(t, ErrNotFound.Code, e.Code)
+	n, _ := e.Data.(coder.Number).CastInt()
+	assert.Equal(t, 2, n)
+
+	users, err := c.UserList(ctx, RoleAdmin)
+	require.NoError(t, err)
+	assert.Len(t, users, 1)
+
+	// The alias and deprecated method are registered.
+	err = rc.Call(ctx, "user.fetch", map[string]interface{}{"id": 1}, &u)
+	require.NoError(t, err)
+	assert.Equal(t, "Alice", u.Name)
+
+	err = c.UserDelete(ctx, 1)
+	require.ErrorAs(t, err, &e)
+	assert.Equal(t, ErrNotFound.Code, e.Code)
+}
+
+func TestServiceParams(t *testing.T) {
+	_, rc := newServer(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		method string
+		params interface{}
+	}{
+		{"user.get", []interface{}{1}},                                               // by-name only
+		{"user.get", map[string]interface{}{"id": 0}},                                // minimum
+		{"user.create", map[string]interface{}{"user": nil}},                         // by-position only
+		{"user.create", []interface{}{map[string]interface{}{"name": "Alice"}, nil}}, // required
+		{"user.list", []interface{}{"guest"}},                                        // enum
+	}
+
+	for _, tt := range tests {
+		err := rc.Call(ctx, tt.method, tt.params, nil)
+		var e *coder.Error
+		require.ErrorAs(t, err, &e, tt.method)
+		assert.Equal(t, -32602, e.Code, "%s %v", tt.method, tt.params)
+	}
+}
+
+func TestServiceOptionalParams(t *testing.T) {
+	_, rc := newServer(t)
+	ctx := context.Background()
+
+	// notify is optional.
+	var id int
+	err := rc.Call(ctx, "user.create", []interface{}{map[string]interface{}{"name": "Alice", "role": "admin"}}, &id)
+	require.NoError(t, err)
+	assert.Equal(t, 1, id)
+
+	for _, params := range [][]interface{}{
+		{},
+		{map[string]interface{}{"name": "Bob", "role": "admin"}, true, 1},
+	} {
+		err := rc.Call(ctx, "user.create", params, nil)
+		var e *coder.Error
+		require.ErrorAs(t, err, &e, params)
+		assert.Equal(t, -32602, e.Code, params)
+	}
+}
+
+func TestServiceDiscover(t *testing.T) {
+	data, err := os.ReadFile("users.json")
+	require.NoError(t, err)
+
+	var want openrpc.Document
+	require.NoError(t, json.Unmarshal(data, &want))
+
+	s, _ := newServer(t)
+	got := s.Discover()
+
+	type method struct {
+		Name, ParamStructure, AliasOf string
+		Params                        []string
+		Errors                        []int
+		Deprecated                    bool
+	}
+	describe := func(doc *openrpc.Document) []method {
+		var ms []method
+		for _, m := range doc.Methods {
+			d := method{Name: m.Name, AliasOf: m.AliasOf, Deprecated: m.Deprecated}
+			d.ParamStructure = m.ParamStructure
+			if d.ParamStructure == "" {
+				d.ParamStructure = openrpc.Either
+			}
+			for _, p := range m.Params {
+				if p.Ref != "" {
+					p = doc.Components.ContentDescriptors[p.Ref[len("#/components/contentDescriptors/"):]]
+				}
+				if !p.Required {
+					p.Name += "?"
+				}
+				d.Params = append(d.Params, p.Name)
+			}
+			for _, e := range m.Errors {
+				if e.Ref != "" {
+					e = doc.Components.Errors[e.Ref[len("#/components/errors/"):]]
+				}
+				d.Errors = append(d.Errors, e.Code)
+			}
+			ms = append(ms, d)
+		}
+		sort.Slice(ms, func(i, j int) bool { return ms[i].Name < ms[j].Name })
+		return ms
+	}
+
+	assert.Equal(t, describe(&want), describe(got))
+	assert.Contains(t, got.Components.Errors, "users.NotFound")
+	assert.Contains(t, got.Components.Errors, "users.EmailTaken")
+}
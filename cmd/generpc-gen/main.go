@@ -0,0 +1,134 @@
+// Command generpc-gen generates GeneRPC server registration code and a typed
+// client from a Go interface or an OpenRPC document.
+//
+// Usage:
+//
+//	generpc-gen -type Calculator [-prefix calc.] [-o file] [file.go]
+//	generpc-gen -type Service -openrpc api.json [-package name] [-o file]
+//
+// The file defaults to $GOFILE, so it can be used with go generate:
+//
+//	//go:generate generpc-gen -type Calculator
+//
+// Each method of the interface should have the form
+//
+//	Name([ctx context.Context,] params...) (Result, error)
+//	Name([ctx context.Context,] params...) error
+//
+// and is registered as the RPC method name, the method name starting with a
+// lower case letter ("GetUser" becomes "getUser") with the prefix prepended.
+// The name can be overridden with a directive in the doc comment of the
+// method:
+//
+//	//generpc:name user.get
+//
+// The generated file contains:
+//
+//   - RegisterCalculator(s *generpc.Server, impl Calculator), which registers
+//     the methods with their parameter names. Params are converted to the Go
+//     types of the parameters, a param that cannot be converted results in an
+//     "Invalid params" error (see package convert). Params and results of
+//     basic types, slices of basic types and maps of basic types are
+//     described by a schema. Errors returned by impl are mapped by the server,
+//     see Server.ErrorFallback.
+//   - CalculatorClient, a typed client that calls the methods via a
+//     client.Client. RPC errors are returned as *coder.Error. If every method
+//     takes a context, CalculatorClient implements Calculator.
+//
+// # OpenRPC
+//
+// With -openrpc the code is generated from an OpenRPC document, like the
+// result of rpc.discover. The package defaults to $GOPACKAGE and the output
+// file to <type>_generpc.go in the current directory. Besides the registration
+// code and client, the generated file contains:
+//
+//   - A Go type for each component schema and for object schemas of params
+//     and results. Optional params and properties are pointers, unless the
+//     type is a slice, map or interface.
+//   - A variable for each error, defined in the error catalogue with
+//     generpc.DefineError. The component key of an error ("package.Name") is
+//     used as package and name.
+//   - The interface named by -type, with a method for each RPC method that
+//     takes a context and the params.
+//
+// The methods are registered with the param schemas, result schema, param
+// structure and errors of the document, aliases are registered with
+// Server.Alias.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("generpc-gen: ")
+
+	typ := flag.String("type", "", "interface `name` (required)")
+	prefix := flag.String("prefix", "", "RPC method name `prefix`")
+	output := flag.String("o", "", "output `file` (default <type>_generpc.go)")
+	doc := flag.String("openrpc", "", "generate from OpenRPC document `file`")
+	pkg := flag.String("package", os.Getenv("GOPACKAGE"), "package `name` of the code generated from an OpenRPC document")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: generpc-gen -type name [flags] [file.go]\n")
+		fmt.Fprintf(os.Stderr, "       generpc-gen -type name -openrpc file [flags]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	var (
+		out []byte
+		err error
+		dir string
+	)
+	switch {
+	case *typ == "":
+		flag.Usage()
+		os.Exit(2)
+
+	case *doc != "":
+		if *pkg == "" || flag.NArg() > 0 {
+			flag.Usage()
+			os.Exit(2)
+		}
+
+		var data []byte
+		if data, err = os.ReadFile(*doc); err == nil {
+			out, err = generateOpenRPC(data, *pkg, *typ)
+		}
+
+	default:
+		file := os.Getenv("GOFILE")
+		if flag.NArg() > 0 {
+			file = flag.Arg(0)
+		}
+
+		if file == "" || flag.NArg() > 1 {
+			flag.Usage()
+			os.Exit(2)
+		}
+
+		var src []byte
+		if src, err = os.ReadFile(file); err == nil {
+			out, err = generate(file, src, *typ, *prefix)
+		}
+		dir = filepath.Dir(file)
+	}
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if *output == "" {
+		*output = filepath.Join(dir, strings.ToLower(*typ)+"_generpc.go")
+	}
+
+	if err := os.WriteFile(*output, out, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
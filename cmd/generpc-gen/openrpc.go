@@ -0,0 +1,565 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/token"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/dwlnetnl/generpc/openrpc"
+	"github.com/dwlnetnl/generpc/schema"
+)
+
+// docGen generates Go declarations from an OpenRPC document.
+type docGen struct {
+	doc   *openrpc.Document
+	it    *iface
+	decls bytes.Buffer
+	names map[string]bool   // declared Go names
+	errs  map[string]string // Go expression by component error key
+	codes map[int]docError  // defined error by code
+}
+
+// docError is an error defined by the generated code.
+type docError struct {
+	key     string // qualified name, see defineError
+	v       string // Go expression
+	message string
+}
+
+// generateOpenRPC generates the code for the OpenRPC document in data. The
+// server interface is named typ.
+func generateOpenRPC(data []byte, pkg, typ string) ([]byte, error) {
+	var doc openrpc.Document
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid OpenRPC document: %v", err)
+	}
+
+	if doc.Components == nil {
+		doc.Components = &openrpc.Components{}
+	}
+
+	g := &docGen{
+		doc:   &doc,
+		it:    &iface{pkg: pkg, name: typ, imports: make(map[string]string)},
+		names: map[string]bool{typ: true, "Register" + typ: true, typ + "Client": true},
+		errs:  make(map[string]string),
+		codes: make(map[int]docError),
+	}
+
+	if err := g.generate(); err != nil {
+		return nil, err
+	}
+
+	g.it.decls = g.decls.Bytes()
+	return g.it.generate()
+}
+
+func (g *docGen) p(format string, args ...interface{}) {
+	fmt.Fprintf(&g.decls, format+"\n", args...)
+}
+
+func (g *docGen) generate() error {
+	keys := sortedKeys(g.doc.Components.Schemas)
+	for _, key := range keys {
+		g.names[goName(key)] = true
+	}
+
+	for _, key := range keys {
+		g.declare(goName(key), key, g.doc.Components.Schemas[key])
+	}
+
+	errKeys := make([]string, 0, len(g.doc.Components.Errors))
+	for key := range g.doc.Components.Errors {
+		errKeys = append(errKeys, key)
+	}
+	sort.Strings(errKeys)
+	for _, key := range errKeys {
+		v, err := g.defineError(key, g.doc.Components.Errors[key])
+		if err != nil {
+			return err
+		}
+		g.errs[key] = v
+	}
+
+	var decl bytes.Buffer
+	methods := make(map[string]bool)
+	for _, d := range g.doc.Methods {
+		if strings.HasPrefix(d.Name, "rpc.") {
+			// Reserved for rpc-internal methods and extensions.
+			continue
+		}
+
+		notice := d.DeprecationNotice
+		if d.Deprecated && notice == "" {
+			notice = "deprecated"
+		}
+
+		if d.AliasOf != "" {
+			g.it.aliases = append(g.it.aliases, alias{d.Name, d.AliasOf, notice})
+			continue
+		}
+
+		name := goName(d.Name)
+		for i := 2; methods[name]; i++ {
+			name = fmt.Sprintf("%s%d", goName(d.Name), i)
+		}
+		methods[name] = true
+
+		m, err := g.method(name, d)
+		if err != nil {
+			return fmt.Errorf("method %s: %v", d.Name, err)
+		}
+		m.deprecated = notice
+		g.it.methods = append(g.it.methods, m)
+
+		params := []string{"ctx context.Context"}
+		for _, param := range m.params {
+			params = append(params, param.goName+" "+param.typ)
+		}
+		results := "error"
+		if m.result != "" {
+			results = "(" + m.result + ", error)"
+		}
+
+		if decl.Len() > 0 {
+			decl.WriteString("\n")
+		}
+		deprecated := ""
+		if notice != "" {
+			deprecated = "Deprecated: " + notice
+		}
+		summary := fmt.Sprintf("%s is the %s method.", name, d.Name)
+		writeDoc(&decl, summary, d.Summary, d.Description, deprecated)
+		fmt.Fprintf(&decl, "%s(%s) %s\n", name, strings.Join(params, ", "), results)
+	}
+
+	title := g.doc.Info.Title
+	if title == "" {
+		title = "the API"
+	}
+	g.p("// %s is implemented by the server of %s.", g.it.name, title)
+	g.p("type %s interface {", g.it.name)
+	g.decls.Write(decl.Bytes())
+	g.p("}")
+	g.p("")
+	return nil
+}
+
+func (g *docGen) method(name string, d openrpc.Method) (*method, error) {
+	m := &method{name: name, rpcName: d.Name, context: true}
+	if d.ParamStructure != openrpc.Either {
+		m.paramStructure = d.ParamStructure
+	}
+
+	ids := make(map[string]bool)
+	for i, cd := range d.Params {
+		cd, err := g.contentDescriptor(cd)
+		if err != nil {
+			return nil, err
+		}
+
+		if cd.Name == "" {
+			cd.Name = fmt.Sprintf("param%d", i)
+		}
+
+		typ := g.goType(cd.Schema, name+goName(cd.Name), cd.Name+" param of "+d.Name)
+		s := cd.Schema
+		if cd.Required {
+			// Only trailing params can be omitted.
+			m.optional = 0
+		} else {
+			m.optional++
+			if pointable(typ) {
+				typ = "*" + typ
+			}
+			if s != nil {
+				s = &schema.Schema{AnyOf: []*schema.Schema{{Type: schema.Types{"null"}}, s}}
+			}
+		}
+
+		id := lowerCamel(goName(cd.Name))
+		if token.IsKeyword(id) || reserved[id] || ids[id] {
+			id += "_"
+		}
+		ids[id] = true
+
+		m.params = append(m.params, param{cd.Name, id, typ, g.schemaJSON(s)})
+	}
+
+	if d.Result != nil {
+		cd, err := g.contentDescriptor(*d.Result)
+		if err != nil {
+			return nil, err
+		}
+
+		m.result = g.goType(cd.Schema, name+"Result", "result of "+d.Name)
+		m.resultSchema = g.schemaJSON(cd.Schema)
+	}
+
+	for _, e := range d.Errors {
+		if e.Ref != "" {
+			v, ok := g.errs[strings.TrimPrefix(e.Ref, "#/components/errors/")]
+			if !ok {
+				return nil, fmt.Errorf("unknown reference %s", e.Ref)
+			}
+			m.errors = append(m.errors, v)
+			continue
+		}
+
+		key := goName(e.Message)
+		if e.Message == "" {
+			key = fmt.Sprintf("Error%d", e.Code)
+			e.Message = key
+		}
+
+		// Inline errors with the same code and message are the same error.
+		if d, ok := g.codes[e.Code]; ok && d.message == e.Message {
+			m.errors = append(m.errors, d.v)
+			continue
+		}
+
+		v, err := g.defineError(key, e)
+		if err != nil {
+			return nil, err
+		}
+		m.errors = append(m.errors, v)
+	}
+
+	return m, nil
+}
+
+func (g *docGen) contentDescriptor(cd openrpc.ContentDescriptor) (openrpc.ContentDescriptor, error) {
+	if cd.Ref == "" {
+		return cd, nil
+	}
+
+	key := strings.TrimPrefix(cd.Ref, "#/components/contentDescriptors/")
+	if c, ok := g.doc.Components.ContentDescriptors[key]; ok && c.Ref == "" {
+		return c, nil
+	}
+
+	return cd, fmt.Errorf("unknown reference %s", cd.Ref)
+}
+
+// defineError declares a variable for the error and returns its name. The key
+// is the qualified name of the error ("package.Name"), the package defaults
+// to the package of the generated code. Codes must be unique, an error is
+// returned if the code is already defined.
+func (g *docGen) defineError(key string, e openrpc.Error) (string, error) {
+	if d, ok := g.codes[e.Code]; ok {
+		return "", fmt.Errorf("errors %s and %s have the same code %d", d.key, key, e.Code)
+	}
+
+	pkg, name := g.it.pkg, key
+	if i := strings.LastIndexByte(key, '.'); i >= 0 {
+		pkg, name = key[:i], key[i+1:]
+	}
+
+	v := g.unique("Err" + goName(name))
+	g.codes[e.Code] = docError{key, v, e.Message}
+
+	g.p("// %s is the %s error.", v, key)
+	if e.Code >= -32768 && e.Code <= -32000 {
+		// Reserved codes cannot be defined in the error catalogue.
+		g.it.use("github.com/dwlnetnl/generpc/coder")
+		g.p("var %s = coder.Error{Code: %d, Message: %q}", v, e.Code, e.Message)
+	} else {
+		data := "nil"
+		if s := g.schemaJSON(e.DataSchema); s != "" {
+			data = g.it.schema(s)
+		}
+		g.p("var %s = generpc.DefineError(%q, %q, %d, %q, %s)", v, pkg, name, e.Code, e.Message, data)
+	}
+	g.p("")
+
+	return v, nil
+}
+
+// unique returns name or name with a number appended if name is declared and
+// declares it.
+func (g *docGen) unique(name string) string {
+	v := name
+	for i := 2; g.names[v]; i++ {
+		v = fmt.Sprintf("%s%d", name, i)
+	}
+
+	g.names[v] = true
+	return v
+}
+
+// declare declares the component schema key as type name.
+func (g *docGen) declare(name, key string, s *schema.Schema) {
+	if s == nil {
+		s = &schema.Schema{}
+	}
+
+	if isStruct(s) {
+		g.structType(name, s, key+" schema")
+		return
+	}
+
+	typ := g.goType(s, name+"Item", "items of "+name)
+	writeDoc(&g.decls, fmt.Sprintf("%s is the %s schema.", name, key), s.Description)
+	g.p("type %s %s", name, typ)
+	g.p("")
+
+	if typ != "string" || len(s.Enum) == 0 {
+		return
+	}
+
+	g.p("// Values of %s.", name)
+	g.p("const (")
+	for _, v := range s.Enum {
+		if str, ok := v.(string); ok {
+			g.p("%s %s = %q", g.unique(name+goName(str)), name, str)
+		}
+	}
+	g.p(")")
+	g.p("")
+}
+
+// structType declares a struct type for an object schema.
+func (g *docGen) structType(name string, s *schema.Schema, what string) string {
+	g.names[name] = true
+
+	required := make(map[string]bool)
+	for _, key := range s.Required {
+		required[key] = true
+	}
+
+	var fields bytes.Buffer
+	for _, key := range sortedKeys(s.Properties) {
+		prop := s.Properties[key]
+		typ := g.goType(prop, name+goName(key), key+" property of "+name)
+		tag := key
+		if !required[key] {
+			tag += ",omitempty"
+			if pointable(typ) {
+				typ = "*" + typ
+			}
+		}
+
+		if prop != nil {
+			writeDoc(&fields, "", prop.Description)
+		}
+		fmt.Fprintf(&fields, "%s %s `json:%q`\n", goName(key), typ, tag)
+	}
+
+	writeDoc(&g.decls, fmt.Sprintf("%s is the %s.", name, what), s.Description)
+	g.p("type %s struct {", name)
+	g.decls.Write(fields.Bytes())
+	g.p("}")
+	g.p("")
+	return name
+}
+
+// goType returns the Go type for a schema. Object schemas with properties are
+// declared as struct type, named after hint and documented as what.
+func (g *docGen) goType(s *schema.Schema, hint, what string) string {
+	if s == nil {
+		return "interface{}"
+	}
+
+	if s.Ref != "" {
+		if key := strings.TrimPrefix(s.Ref, "#/components/schemas/"); key != s.Ref {
+			return goName(key)
+		}
+		return "interface{}"
+	}
+
+	var types []string
+	nullable := false
+	for _, t := range s.Type {
+		if t == "null" {
+			nullable = true
+		} else {
+			types = append(types, t)
+		}
+	}
+
+	var typ string
+	switch {
+	case len(types) == 1:
+		typ = types[0]
+	case len(types) == 0 && len(s.Properties) > 0:
+		typ = "object"
+	case len(types) == 0 && len(s.Enum) > 0 && allStrings(s.Enum):
+		typ = "string"
+	default:
+		return "interface{}"
+	}
+
+	var t string
+	switch typ {
+	case "string":
+		t = "string"
+	case "integer":
+		t = "int"
+	case "number":
+		t = "float64"
+	case "boolean":
+		t = "bool"
+	case "array":
+		return "[]" + g.goType(s.Items, hint+"Item", "items of "+what)
+	case "object":
+		if len(s.Properties) == 0 {
+			if ap := s.AdditionalProperties; ap != nil {
+				if _, ok := ap.IsBool(); !ok {
+					return "map[string]" + g.goType(ap, hint+"Value", "values of "+what)
+				}
+			}
+			return "map[string]interface{}"
+		}
+		t = g.structType(g.unique(hint), s, what)
+	default:
+		return "interface{}"
+	}
+
+	if nullable {
+		return "*" + t
+	}
+
+	return t
+}
+
+var componentRef = regexp.MustCompile(`"\$ref":"#/components/schemas/([^"]+)"`)
+
+// schemaJSON returns s as JSON or "" if s accepts any value. References to
+// component schemas are rewritten to $defs, so the schema can be validated on
+// its own.
+func (g *docGen) schemaJSON(s *schema.Schema) string {
+	if s == nil {
+		return ""
+	}
+
+	data, err := json.Marshal(s)
+	if err != nil || string(data) == "{}" || string(data) == "true" {
+		return ""
+	}
+
+	defs := make(map[string][]byte)
+	g.collectRefs(data, defs)
+	if len(defs) == 0 {
+		return string(data)
+	}
+
+	root, err := schema.Parse(rewriteRefs(data))
+	if err != nil {
+		return string(data)
+	}
+
+	if root.Defs == nil {
+		root.Defs = make(map[string]*schema.Schema)
+	}
+	for key, data := range defs {
+		root.Defs[key], _ = schema.Parse(rewriteRefs(data))
+	}
+
+	data, _ = json.Marshal(root)
+	return string(data)
+}
+
+func (g *docGen) collectRefs(data []byte, defs map[string][]byte) {
+	for _, m := range componentRef.FindAllSubmatch(data, -1) {
+		key := string(m[1])
+		s, ok := g.doc.Components.Schemas[key]
+		if _, seen := defs[key]; seen || !ok {
+			continue
+		}
+
+		data, _ := json.Marshal(s)
+		defs[key] = data
+		g.collectRefs(data, defs)
+	}
+}
+
+func rewriteRefs(data []byte) []byte {
+	return bytes.ReplaceAll(data, []byte(`"#/components/schemas/`), []byte(`"#/$defs/`))
+}
+
+func isStruct(s *schema.Schema) bool {
+	return s != nil && s.Ref == "" && len(s.Properties) > 0 &&
+		(len(s.Type) == 0 || (len(s.Type) == 1 && s.Type[0] == "object"))
+}
+
+// pointable reports if the Go type needs a pointer to represent null.
+func pointable(typ string) bool {
+	return typ != "interface{}" && !strings.HasPrefix(typ, "*") &&
+		!strings.HasPrefix(typ, "[]") && !strings.HasPrefix(typ, "map[")
+}
+
+func allStrings(s []interface{}) bool {
+	for _, v := range s {
+		if _, ok := v.(string); !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+func sortedKeys(m map[string]*schema.Schema) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+	return keys
+}
+
+// initialisms are written in upper case in Go names.
+var initialisms = map[string]bool{
+	"api": true, "http": true, "id": true, "ip": true, "json": true,
+	"rpc": true, "uri": true, "url": true, "uuid": true,
+}
+
+// goName returns an exported Go name for s, like "UserGet" for "user.get".
+func goName(s string) string {
+	var b strings.Builder
+	words := strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, w := range words {
+		if initialisms[strings.ToLower(w)] {
+			b.WriteString(strings.ToUpper(w))
+			continue
+		}
+		r := []rune(w)
+		r[0] = unicode.ToUpper(r[0])
+		b.WriteString(string(r))
+	}
+
+	name := b.String()
+	if name == "" || unicode.IsDigit([]rune(name)[0]) {
+		name = "X" + name
+	}
+
+	return name
+}
+
+// writeDoc writes the text as comment, paragraphs are separated by an empty
+// comment line.
+func writeDoc(buf *bytes.Buffer, paragraphs ...string) {
+	first := true
+	for _, text := range paragraphs {
+		text = strings.TrimSpace(text)
+		if text == "" {
+			continue
+		}
+
+		if !first {
+			buf.WriteString("//\n")
+		}
+		first = false
+
+		for _, line := range strings.Split(text, "\n") {
+			buf.WriteString(strings.TrimRight("// "+line, " ") + "\n")
+		}
+	}
+}
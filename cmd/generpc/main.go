@@ -0,0 +1,419 @@
+// Command generpc calls methods of a GeneRPC endpoint.
+//
+// Usage:
+//
+//	generpc [flags] endpoint method [arg ...]
+//	generpc [flags] -param name=value [-param name=value ...] endpoint method
+//	generpc [flags] -batch file endpoint
+//	generpc [flags] -list endpoint
+//
+// The endpoint is the URL of the endpoint, or with -local a recorded session
+// (see package record).
+//
+// Positional args are sent as by-position params and -param flags as by-name
+// params, they cannot be combined. Each value is parsed as JSON, a value that
+// isn't valid JSON is sent as string. For example:
+//
+//	generpc http://localhost:8080/rpc subtract 42 23
+//	generpc -param name=alice -param roles='["admin"]' http://localhost:8080/rpc user.create
+//	generpc http://localhost:8080/rpc echo '"42"'
+//
+// The result is printed as indented JSON, numbers are printed as received
+// without losing precision. An RPC error is printed with its code, message
+// and data, and the exit status is 1. With -notify the call is sent as
+// notification and nothing is printed.
+//
+// With -batch the calls in the file ("-" for stdin) are sent as batch. The
+// file contains JSON-RPC 2.0 requests, as array or a single object. Requests
+// without id are notifications, the ids of other requests are replaced:
+//
+//	[
+//	  {"jsonrpc": "2.0", "method": "subtract", "params": [42, 23], "id": 1},
+//	  {"jsonrpc": "2.0", "method": "update", "params": [1, 2]}
+//	]
+//
+// The result or error of each call is printed after the method name, in the
+// order of the file.
+//
+// With -list the methods are listed as described by rpc.discover.
+//
+// # Local mode
+//
+// With -local the endpoint is a recording and calls are answered with the
+// recorded responses, without a server. A call is answered with the response
+// of the first recorded call of the method with equal params. Notifications
+// are ignored. If the session doesn't contain a rpc.discover call, -list
+// lists the recorded methods.
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/dwlnetnl/generpc/client"
+	"github.com/dwlnetnl/generpc/coder"
+	"github.com/dwlnetnl/generpc/convert"
+	"github.com/dwlnetnl/generpc/openrpc"
+)
+
+// endpoint is implemented by *client.Client and *session.
+type endpoint interface {
+	Call(ctx context.Context, method string, params, result interface{}) error
+	Notify(ctx context.Context, method string, params interface{}) error
+	Batch(ctx context.Context, calls ...*client.Call) error
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run runs the command and returns the exit status.
+func run(args []string, stdout, stderr io.Writer) int {
+	var named namedParams
+	headers := make(http.Header)
+
+	fs := flag.NewFlagSet("generpc", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Var(&named, "param", "by-name param `name=value` (repeatable)")
+	fs.Var(headerFlag(headers), "header", "HTTP header `name: value` of each request (repeatable)")
+	mediaType := fs.String("type", client.DefaultMediaType, "media `type` of the coder")
+	notify := fs.Bool("notify", false, "send the call as notification")
+	batch := fs.String("batch", "", "send the requests in `file` as batch")
+	list := fs.Bool("list", false, "list the methods")
+	local := fs.Bool("local", false, "the endpoint is a recorded session")
+	timeout := fs.Duration("timeout", 30*time.Second, "timeout of the request")
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "usage: generpc [flags] endpoint method [arg ...]\n")
+		fmt.Fprintf(stderr, "       generpc [flags] -batch file endpoint\n")
+		fmt.Fprintf(stderr, "       generpc [flags] -list endpoint\n")
+		fs.PrintDefaults()
+		fmt.Fprintf(stderr, "media types: %s\n", strings.Join(coder.MediaTypes(), ", "))
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	usage := func() int {
+		fs.Usage()
+		return 2
+	}
+
+	if fs.NArg() == 0 {
+		return usage()
+	}
+
+	var e endpoint
+	if *local {
+		s, err := openSession(fs.Arg(0))
+		if err != nil {
+			fmt.Fprintf(stderr, "generpc: %v\n", err)
+			return 1
+		}
+		e = s
+	} else {
+		c := client.New(fs.Arg(0))
+		c.MediaType = *mediaType
+		c.Header = headers
+		e = c
+	}
+
+	ctx := context.Background()
+	if *timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *timeout)
+		defer cancel()
+	}
+
+	var err error
+	switch {
+	case *list:
+		if fs.NArg() != 1 || *batch != "" || *notify || len(named) > 0 {
+			return usage()
+		}
+		err = listMethods(ctx, e, stdout)
+
+	case *batch != "":
+		if fs.NArg() != 1 || *notify || len(named) > 0 {
+			return usage()
+		}
+		err = sendBatch(ctx, e, *batch, stdout)
+
+	default:
+		if fs.NArg() < 2 || (len(named) > 0 && fs.NArg() > 2) {
+			return usage()
+		}
+
+		var params interface{}
+		if len(named) > 0 {
+			params = map[string]interface{}(named)
+		} else if fs.NArg() > 2 {
+			var s []interface{}
+			for _, arg := range fs.Args()[2:] {
+				s = append(s, value(arg))
+			}
+			params = s
+		}
+
+		method := fs.Arg(1)
+		if *notify {
+			err = e.Notify(ctx, method, params)
+		} else {
+			var result json.RawMessage
+			if err = e.Call(ctx, method, params, &result); err == nil {
+				err = printJSON(stdout, "", result)
+			}
+		}
+	}
+
+	var rpcErr *coder.Error
+	switch {
+	case errors.As(err, &rpcErr):
+		printError(stderr, "", rpcErr)
+		return 1
+	case err != nil:
+		fmt.Fprintf(stderr, "generpc: %v\n", err)
+		return 1
+	}
+
+	return 0
+}
+
+// namedParams is the -param flag.
+type namedParams map[string]interface{}
+
+func (p *namedParams) String() string { return "" }
+
+func (p *namedParams) Set(s string) error {
+	name, v, ok := strings.Cut(s, "=")
+	if !ok || name == "" {
+		return errors.New("should be name=value")
+	}
+
+	if *p == nil {
+		*p = make(namedParams)
+	}
+	(*p)[name] = value(v)
+	return nil
+}
+
+// headerFlag is the -header flag.
+type headerFlag http.Header
+
+func (h headerFlag) String() string { return "" }
+
+func (h headerFlag) Set(s string) error {
+	name, v, ok := strings.Cut(s, ":")
+	if !ok || strings.TrimSpace(name) == "" {
+		return errors.New("should be name: value")
+	}
+
+	http.Header(h).Add(strings.TrimSpace(name), strings.TrimSpace(v))
+	return nil
+}
+
+// value returns s decoded as JSON, or s if it isn't valid JSON.
+func value(s string) interface{} {
+	v, err := decode([]byte(s))
+	if err != nil {
+		return s
+	}
+
+	return v
+}
+
+// decode decodes a single JSON value with numbers as json.Number.
+func decode(data []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, errors.New("invalid JSON: data after value")
+	}
+
+	return v, nil
+}
+
+// request is a JSON-RPC 2.0 request in a batch file.
+type request struct {
+	Method string           `json:"method"`
+	Params json.RawMessage  `json:"params"`
+	ID     *json.RawMessage `json:"id"`
+}
+
+// readBatch reads the calls in a batch file.
+func readBatch(data []byte) ([]*client.Call, error) {
+	data = bytes.TrimSpace(data)
+
+	var reqs []request
+	if len(data) > 0 && data[0] == '{' {
+		reqs = make([]request, 1)
+		if err := json.Unmarshal(data, &reqs[0]); err != nil {
+			return nil, err
+		}
+	} else if err := json.Unmarshal(data, &reqs); err != nil {
+		return nil, err
+	}
+
+	if len(reqs) == 0 {
+		return nil, errors.New("empty batch")
+	}
+
+	calls := make([]*client.Call, len(reqs))
+	for i, req := range reqs {
+		if req.Method == "" {
+			return nil, fmt.Errorf("request %d: no method", i+1)
+		}
+
+		var params interface{}
+		if len(req.Params) > 0 {
+			var err error
+			if params, err = decode(req.Params); err != nil {
+				return nil, fmt.Errorf("request %d: %v", i+1, err)
+			}
+		}
+
+		calls[i] = &client.Call{
+			Method:       req.Method,
+			Params:       params,
+			Notification: req.ID == nil,
+			Result:       new(json.RawMessage),
+		}
+	}
+
+	return calls, nil
+}
+
+func sendBatch(ctx context.Context, e endpoint, file string, w io.Writer) error {
+	var (
+		data []byte
+		err  error
+	)
+	if file == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(file)
+	}
+	if err != nil {
+		return err
+	}
+
+	calls, err := readBatch(data)
+	if err != nil {
+		return fmt.Errorf("%s: %v", file, err)
+	}
+
+	if err := e.Batch(ctx, calls...); err != nil {
+		return err
+	}
+
+	var failed int
+	for _, call := range calls {
+		if call.Notification {
+			continue
+		}
+
+		var rpcErr *coder.Error
+		switch {
+		case errors.As(call.Error, &rpcErr):
+			printError(w, call.Method+": ", rpcErr)
+			failed++
+		case call.Error != nil:
+			fmt.Fprintf(w, "%s: %v\n", call.Method, call.Error)
+			failed++
+		default:
+			if err := printJSON(w, call.Method+": ", *call.Result.(*json.RawMessage)); err != nil {
+				return err
+			}
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d calls failed", failed, len(calls))
+	}
+
+	return nil
+}
+
+func listMethods(ctx context.Context, e endpoint, w io.Writer) error {
+	var doc openrpc.Document
+	if err := e.Call(ctx, "rpc.discover", nil, &doc); err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	for _, m := range doc.Methods {
+		params := make([]string, len(m.Params))
+		for i, p := range m.Params {
+			params[i] = p.Name
+			if !p.Required {
+				params[i] += "?"
+			}
+		}
+
+		var desc []string
+		if m.AliasOf != "" {
+			desc = append(desc, "alias of "+m.AliasOf)
+		}
+		if m.Deprecated {
+			desc = append(desc, strings.TrimSuffix("deprecated: "+m.DeprecationNotice, ": "))
+		}
+		if m.Summary != "" {
+			desc = append(desc, m.Summary)
+		}
+
+		fmt.Fprintf(tw, "%s(%s)", m.Name, strings.Join(params, ", "))
+		if len(desc) > 0 {
+			fmt.Fprintf(tw, "\t%s", strings.Join(desc, "; "))
+		}
+		fmt.Fprintln(tw)
+	}
+
+	return tw.Flush()
+}
+
+// printJSON prints data indented after the prefix.
+func printJSON(w io.Writer, prefix string, data json.RawMessage) error {
+	if len(data) == 0 {
+		data = json.RawMessage("null")
+	}
+
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, data, "", "  "); err != nil {
+		return err
+	}
+
+	_, err := fmt.Fprintf(w, "%s%s\n", prefix, buf.Bytes())
+	return err
+}
+
+// printError prints an RPC error after the prefix, with its data indented on
+// the following lines.
+func printError(w io.Writer, prefix string, e *coder.Error) {
+	fmt.Fprintf(w, "%serror %d: %s\n", prefix, e.Code, e.Message)
+	if e.Data == nil {
+		return
+	}
+
+	data, err := json.MarshalIndent(convert.Numbers(e.Data), "  ", "  ")
+	if err != nil {
+		fmt.Fprintf(w, "  %v\n", e.Data)
+		return
+	}
+
+	fmt.Fprintf(w, "  %s\n", data)
+}
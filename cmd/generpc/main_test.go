@@ -0,0 +1,206 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dwlnetnl/generpc"
+	"github.com/dwlnetnl/generpc/coder"
+	"github.com/dwlnetnl/generpc/record"
+)
+
+func testServer() *generpc.Server {
+	s := generpc.NewServer()
+	s.Register("subtract", generpc.Method{
+		ParamNames: []string{"minuend", "subtrahend"},
+		Func: func(params []interface{}) interface{} {
+			a, _ := params[0].(coder.Number).CastInt()
+			b, _ := params[1].(coder.Number).CastInt()
+			return a - b
+		},
+	})
+	s.Register("echo", generpc.Method{
+		Func: func(params []interface{}) interface{} {
+			return params
+		},
+	})
+	s.Register("fail", generpc.Method{
+		Func: func(params []interface{}) interface{} {
+			return &coder.Error{Code: 1, Message: "Failed", Data: map[string]interface{}{"reason": "test"}}
+		},
+	})
+	s.Deprecate("echo", "use print instead")
+	return s
+}
+
+func runCmd(args ...string) (code int, stdout, stderr string) {
+	var out, errOut bytes.Buffer
+	code = run(args, &out, &errOut)
+	return code, out.String(), errOut.String()
+}
+
+func TestCall(t *testing.T) {
+	ts := httptest.NewServer(testServer())
+	defer ts.Close()
+
+	code, out, _ := runCmd(ts.URL, "subtract", "42", "23")
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "19\n", out)
+
+	code, out, _ = runCmd("-param", "minuend=42", "-param", "subtrahend=23", ts.URL, "subtract")
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "19\n", out)
+
+	code, out, _ = runCmd(ts.URL, "echo", "alice", `"42"`, `{"a":[1.5,true,null]}`)
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "[\n  \"alice\",\n  \"42\",\n  {\n    \"a\": [\n      1.5,\n      true,\n      null\n    ]\n  }\n]\n", out)
+
+	code, out, errOut := runCmd(ts.URL, "fail")
+	assert.Equal(t, 1, code)
+	assert.Empty(t, out)
+	assert.Equal(t, "error 1: Failed\n  {\n    \"reason\": \"test\"\n  }\n", errOut)
+
+	code, out, errOut = runCmd("-notify", ts.URL, "fail")
+	assert.Equal(t, 0, code)
+	assert.Empty(t, out)
+	assert.Empty(t, errOut)
+
+	code, _, errOut = runCmd("-type", "text/plain", ts.URL, "fail")
+	assert.Equal(t, 1, code)
+	assert.Equal(t, "generpc: client: no client codec for media type \"text/plain\"\n", errOut)
+}
+
+func TestCall_precision(t *testing.T) {
+	ts := httptest.NewServer(testServer())
+	defer ts.Close()
+
+	const params = `[18446744073709551615,0.1000000000000000055511151231257827,-9223372036854775809,1e400]`
+	code, out, _ := runCmd(ts.URL, "echo", params)
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "[\n  [\n    18446744073709551615,\n    0.1000000000000000055511151231257827,\n    -9223372036854775809,\n    1e400\n  ]\n]\n", out)
+
+	file := filepath.Join(t.TempDir(), "batch.json")
+	require.NoError(t, os.WriteFile(file, []byte(`[{"jsonrpc":"2.0","method":"echo","params":`+params+`,"id":1}]`), 0o644))
+	code, out, _ = runCmd("-batch", file, ts.URL)
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "echo: [\n  18446744073709551615,\n  0.1000000000000000055511151231257827,\n  -9223372036854775809,\n  1e400\n]\n", out)
+}
+
+func TestUsage(t *testing.T) {
+	for _, args := range [][]string{
+		{},
+		{"http://localhost"},
+		{"-param", "a=1", "http://localhost", "m", "2"},
+		{"-param", "a"},
+		{"-list", "http://localhost", "m"},
+		{"-batch", "file", "-notify", "http://localhost"},
+	} {
+		code, _, errOut := runCmd(args...)
+		assert.Equal(t, 2, code, args)
+		assert.Contains(t, errOut, "usage: generpc", args)
+	}
+}
+
+func TestBatch(t *testing.T) {
+	ts := httptest.NewServer(testServer())
+	defer ts.Close()
+
+	file := filepath.Join(t.TempDir(), "batch.json")
+	require.NoError(t, os.WriteFile(file, []byte(`[
+		{"jsonrpc":"2.0","method":"subtract","params":[42,23],"id":1},
+		{"jsonrpc":"2.0","method":"fail","params":[]},
+		{"jsonrpc":"2.0","method":"fail","id":"a"},
+		{"jsonrpc":"2.0","method":"subtract","params":{"minuend":1,"subtrahend":2},"id":2}
+	]`), 0o644))
+
+	code, out, errOut := runCmd("-batch", file, ts.URL)
+	assert.Equal(t, 1, code)
+	assert.Equal(t, "subtract: 19\nfail: error 1: Failed\n  {\n    \"reason\": \"test\"\n  }\nsubtract: -1\n", out)
+	assert.Equal(t, "generpc: 1 of 4 calls failed\n", errOut)
+}
+
+func TestReadBatch(t *testing.T) {
+	calls, err := readBatch([]byte(` {"jsonrpc":"2.0","method":"a","params":{"n":1},"id":null} `))
+	require.NoError(t, err)
+	require.Len(t, calls, 1)
+	assert.Equal(t, "a", calls[0].Method)
+	assert.Equal(t, map[string]interface{}{"n": json.Number("1")}, calls[0].Params)
+	assert.True(t, calls[0].Notification)
+
+	for data, msg := range map[string]string{
+		`[]`:                 "empty batch",
+		`[{"params":[]}]`:    "request 1: no method",
+		`[{"method":"a"} x]`: "invalid character 'x' after array element",
+		`{`:                  "unexpected end of JSON input",
+	} {
+		_, err := readBatch([]byte(data))
+		assert.EqualError(t, err, msg, data)
+	}
+}
+
+func TestList(t *testing.T) {
+	ts := httptest.NewServer(testServer())
+	defer ts.Close()
+
+	code, out, _ := runCmd("-list", ts.URL)
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "echo()  deprecated: use print instead\n"+
+		"fail()\n"+
+		"subtract(minuend, subtrahend)\n", out)
+}
+
+func TestLocal(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "session.jsonl")
+	f, err := os.Create(file)
+	require.NoError(t, err)
+
+	s := testServer()
+	s.Use(record.Interceptor(record.NewWriter(f), func(err error) { t.Error(err) }))
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+
+	runCmd(ts.URL, "subtract", "42", "23")
+	runCmd("-param", "minuend=1", "-param", "subtrahend=2", ts.URL, "subtract")
+	runCmd(ts.URL, "fail", "1")
+	runCmd(ts.URL, "echo", "18446744073709551615", "0.1000000000000000055511151231257827")
+	require.NoError(t, f.Close())
+
+	code, out, _ := runCmd("-local", file, "subtract", "42", "23")
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "19\n", out)
+
+	code, out, _ = runCmd("-local", "-param", "subtrahend=2", "-param", "minuend=1", file, "subtract")
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "-1\n", out)
+
+	code, out, _ = runCmd("-local", file, "echo", "18446744073709551615", "0.1000000000000000055511151231257827")
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "[\n  18446744073709551615,\n  0.1000000000000000055511151231257827\n]\n", out)
+
+	code, _, errOut := runCmd("-local", file, "fail", "1")
+	assert.Equal(t, 1, code)
+	assert.Equal(t, "error 1: Failed\n  {\n    \"reason\": \"test\"\n  }\n", errOut)
+
+	code, _, errOut = runCmd("-local", file, "subtract", "1", "1")
+	assert.Equal(t, 1, code)
+	assert.Equal(t, "generpc: subtract: no recorded call with these params\n", errOut)
+
+	code, _, errOut = runCmd("-local", file, "print")
+	assert.Equal(t, 1, code)
+	assert.Equal(t, "generpc: print: method not recorded\n", errOut)
+
+	code, out, _ = runCmd("-local", "-list", file)
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "echo()\nfail()\nsubtract()\n", out)
+
+	code, _, errOut = runCmd("-local", "missing.jsonl", "subtract")
+	assert.Equal(t, 1, code)
+	assert.Contains(t, errOut, "generpc: open missing.jsonl: ")
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+
+	"github.com/dwlnetnl/generpc/client"
+	"github.com/dwlnetnl/generpc/coder"
+	"github.com/dwlnetnl/generpc/convert"
+	"github.com/dwlnetnl/generpc/openrpc"
+	"github.com/dwlnetnl/generpc/record"
+)
+
+// session answers calls with the responses of a recorded session.
+type session struct {
+	title   string
+	entries []*record.Entry
+}
+
+// openSession reads the recorded session at path.
+func openSession(path string) (*session, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	entries, err := record.ReadAll(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+
+	return &session{title: filepath.Base(path), entries: entries}, nil
+}
+
+// Call answers a call like client.Client.Call.
+func (s *session) Call(ctx context.Context, method string, params, result interface{}) error {
+	call := &client.Call{Method: method, Params: params, Result: result}
+	if err := s.Batch(ctx, call); err != nil {
+		return err
+	}
+
+	return call.Error
+}
+
+// Notify ignores the notification.
+func (s *session) Notify(ctx context.Context, method string, params interface{}) error {
+	return nil
+}
+
+// Batch answers the calls like client.Client.Batch.
+func (s *session) Batch(ctx context.Context, calls ...*client.Call) error {
+	for _, call := range calls {
+		if call.Notification {
+			continue
+		}
+
+		resp, err := s.lookup(call.Method, call.Params)
+		if err != nil {
+			call.Error = err
+			continue
+		}
+
+		call.Response = resp
+		switch {
+		case resp.Error != nil:
+			call.Error = resp.Error
+		case call.Result != nil:
+			call.Error = convert.Value(resp.Result, call.Result)
+		}
+	}
+
+	return nil
+}
+
+// lookup returns the response of the first recorded call of the method with
+// equal params.
+func (s *session) lookup(method string, params interface{}) (*coder.Response, error) {
+	want := normalize(params)
+
+	var recorded bool
+	for _, e := range s.entries {
+		if e.Request.Method != method || e.Request.ID == nil {
+			continue
+		}
+		recorded = true
+
+		if !reflect.DeepEqual(normalize(e.Request.Params), want) {
+			continue
+		}
+
+		resp := &coder.Response{Result: e.Response.Result}
+		if re := e.Response.Error; re != nil {
+			resp.Error = &coder.Error{Code: re.Code, Message: re.Message, Data: re.Data}
+		}
+		return resp, nil
+	}
+
+	switch {
+	case method == "rpc.discover" && !recorded:
+		return &coder.Response{Result: s.discover()}, nil
+	case recorded:
+		return nil, fmt.Errorf("%s: no recorded call with these params", method)
+	default:
+		return nil, fmt.Errorf("%s: method not recorded", method)
+	}
+}
+
+// discover returns a document that lists the recorded methods.
+func (s *session) discover() *openrpc.Document {
+	seen := make(map[string]bool)
+	for _, e := range s.entries {
+		seen[e.Request.Method] = true
+	}
+
+	doc := &openrpc.Document{
+		OpenRPC: openrpc.Version,
+		Info:    openrpc.Info{Title: s.title, Version: "0.0.0"},
+		Methods: []openrpc.Method{},
+	}
+	for name := range seen {
+		doc.Methods = append(doc.Methods, openrpc.Method{
+			Name:   name,
+			Params: []openrpc.ContentDescriptor{},
+		})
+	}
+	sort.Slice(doc.Methods, func(i, j int) bool {
+		return doc.Methods[i].Name < doc.Methods[j].Name
+	})
+
+	return doc
+}
+
+// normalize returns the JSON representation of params as decoded value, so
+// params can be compared regardless of their Go types. No params and empty
+// by-position params are equal.
+func normalize(params interface{}) interface{} {
+	b, err := json.Marshal(convert.Numbers(params))
+	if err != nil {
+		return nil
+	}
+
+	var v interface{}
+	if err := json.Unmarshal(b, &v); err != nil || v == nil {
+		return []interface{}{}
+	}
+
+	return v
+}
@@ -0,0 +1,72 @@
+package coder
+
+import (
+	"sort"
+	"sync"
+)
+
+// A ClientCodec encodes requests and decodes responses, it's the client side
+// of a Coder. It's used by clients and test harnesses.
+type ClientCodec interface {
+	// NewID should return the wire representation of the numeric request ID
+	// n.
+	NewID(n int) RequestID
+
+	// EncodeRequests should encode the request(s), a single request if batch
+	// is false. Requests with a nil ID (*ID == nil) are notifications.
+	EncodeRequests(s []*Request, batch bool) ([]byte, error)
+
+	// DecodeResponses should decode the response(s) into a slice and
+	// indicate if the input is a batch or return an error.
+	DecodeResponses(data []byte) (s []*Response, batch bool, err error)
+}
+
+var clientMap struct {
+	sync.Mutex
+	m map[string]ClientCodec
+}
+
+// RegisterClient registers a ClientCodec for a particular Content-Type. If
+// RegisterClient is called twice with the same name or if c is nil, it
+// panics.
+func RegisterClient(typ string, c ClientCodec) {
+	if c == nil {
+		panic("coder: client codec is nil")
+	}
+
+	clientMap.Lock()
+	defer clientMap.Unlock()
+
+	if _, dup := clientMap.m[typ]; dup {
+		panic("coder: RegisterClient called twice for type " + typ)
+	}
+
+	if clientMap.m == nil {
+		clientMap.m = make(map[string]ClientCodec)
+	}
+
+	clientMap.m[typ] = c
+}
+
+// NewClient returns the ClientCodec for the given Content-Type. Nil is
+// returned if there is none registered.
+func NewClient(typ string) ClientCodec {
+	clientMap.Lock()
+	defer clientMap.Unlock()
+
+	return clientMap.m[typ]
+}
+
+// MediaTypes returns the sorted Content-Types of the registered coders.
+func MediaTypes() []string {
+	fnMap.Lock()
+	defer fnMap.Unlock()
+
+	types := make([]string, 0, len(fnMap.m))
+	for typ := range fnMap.m {
+		types = append(types, typ)
+	}
+
+	sort.Strings(types)
+	return types
+}
@@ -0,0 +1,30 @@
+package coder
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testClientCodec struct{ ClientCodec }
+
+func TestRegisterClient(t *testing.T) {
+	assert.Panics(t, func() { RegisterClient("application/x-test", nil) })
+
+	c := testClientCodec{}
+	RegisterClient("application/x-test", c)
+	assert.Equal(t, c, NewClient("application/x-test"))
+	assert.Nil(t, NewClient("application/x-unknown"))
+	assert.Panics(t, func() { RegisterClient("application/x-test", c) })
+}
+
+func TestMediaTypes(t *testing.T) {
+	fn := func(w http.ResponseWriter, r *http.Request) Coder { return nil }
+	Register("application/x-b", fn)
+	Register("application/x-a", fn)
+
+	types := MediaTypes()
+	assert.Subset(t, types, []string{"application/x-a", "application/x-b"})
+	assert.IsNonDecreasing(t, types)
+}
@@ -14,7 +14,9 @@ type Coder interface {
 
 	// ReadRequests should decode the request(s) into a slice and indicates if
 	// the input is a batch or return an error. The returned slice may contain
-	// nil values, this indicates that the request data was malformed.
+	// nil values, this indicates that the request data was malformed. A
+	// request with Error set is an invalid Request object, the ID should be
+	// set if it could be read.
 	ReadRequests() (s []*Request, batch bool, e *Error)
 
 	// WriteContentType is called at the start of a response. The coder should
@@ -38,11 +40,27 @@ type Coder interface {
 // parsing and validating the data.
 type RequestID []byte
 
+// IDNormalizer can be implemented by a Coder to define when request IDs are
+// equal. NormalizeID should return the same key for IDs that are semantically
+// equal in the wire format and different keys otherwise. Without it IDs are
+// compared byte-wise.
+type IDNormalizer interface {
+	NormalizeID(id RequestID) string
+}
+
 // Request represents a RPC request.
+//
+// Extensions contains optional members that are not part of the JSON-RPC 2.0
+// specification, coders document how they're represented on the wire.
+//
+// Error is set by the coder if the Request object is invalid, the server
+// responds with it instead of invoking the method.
 type Request struct {
-	Method string
-	Params interface{} // []interface{} or map[string]interface{}
-	ID     *RequestID
+	Method     string
+	Params     interface{} // []interface{} or map[string]interface{}
+	ID         *RequestID
+	Extensions map[string]interface{}
+	Error      *Error
 }
 
 // NewResult returns a response object for the given request. It's
@@ -51,11 +69,12 @@ func NewResult(r *Request, v interface{}) *Response {
 	return &Response{Result: v, ID: r.ID}
 }
 
-// Response represents a RPC response.
+// Response represents a RPC response. See Request for Extensions.
 type Response struct {
-	Result interface{}
-	Error  *Error
-	ID     *RequestID
+	Result     interface{}
+	Error      *Error
+	ID         *RequestID
+	Extensions map[string]interface{}
 }
 
 // Number represents a number value in a particular encoding.
@@ -1,5 +1,7 @@
 package coder
 
+import "fmt"
+
 // Error represents an error during handling the RPC request.
 type Error struct {
 	Code    int
@@ -7,6 +9,16 @@ type Error struct {
 	Data    interface{}
 }
 
+// Error implements the error interface, so a RPC error can be returned (and
+// wrapped) as Go error.
+func (e *Error) Error() string {
+	if e.Data != nil {
+		return fmt.Sprintf("%s (%d): %v", e.Message, e.Code, e.Data)
+	}
+
+	return fmt.Sprintf("%s (%d)", e.Message, e.Code)
+}
+
 // WithString returns an error with str in Data.
 func (e Error) WithString(str string) *Error {
 	v := e
@@ -58,14 +70,15 @@ func serverError(code int) Error {
 	return Error{Code: code, Message: "Server error"}
 }
 
-// ServerError returns a "Server error" RPC error with a particular code. Codes
-// between -32090 and -32099 are reserved and ServerError will panic.
+// ServerError returns a "Server error" RPC error with a particular code. The
+// code should be between -32000 and -32089, ServerError will panic otherwise.
+// Codes between -32090 and -32099 are reserved.
 func ServerError(code int) Error {
-	if code < serverErrorCodeBegin || code > serverErrorCodeEnd {
+	if code > serverErrorCodeBegin || code < serverErrorCodeEnd {
 		panic("coder: error code is not valid for use as server error")
 	}
 
-	if code >= serverErrorCodeBeginReserved && code <= serverErrorCodeEnd {
+	if code <= serverErrorCodeBeginReserved && code >= serverErrorCodeEnd {
 		panic("coder: use of reserved server error code")
 	}
 
@@ -0,0 +1,21 @@
+package coder
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServerError(t *testing.T) {
+	assert.Equal(t, Error{Code: -32000, Message: "Server error"}, ServerError(-32000))
+	assert.Equal(t, Error{Code: -32089, Message: "Server error"}, ServerError(-32089))
+
+	for _, code := range []int{-31999, -32090, -32099, -32100, 1} {
+		assert.Panics(t, func() { ServerError(code) }, "code %d", code)
+	}
+}
+
+func TestError_Error(t *testing.T) {
+	assert.Equal(t, "Parse error (-32700)", ParseError.Error())
+	assert.Equal(t, "Invalid Request (-32600): data", InvalidRequest.WithString("data").Error())
+}
@@ -0,0 +1,325 @@
+// Package codertest provides a conformance test suite for coder.Coder
+// implementations.
+//
+// The suite checks that a coder together with the Server implements the
+// semantics of JSON-RPC 2.0, based on the examples of the specification: it
+// sends requests to a generpc.Server via ServeHTTP and checks the responses.
+// The test cases are coder-neutral, the coder's wire format is provided by an
+// EncodeFunc and a DecodeFunc:
+//
+//	func TestConformance(t *testing.T) {
+//		codertest.Run(t, "application/x-mycoder", encodeRequests, decodeResponses)
+//	}
+package codertest
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/dwlnetnl/generpc"
+	"github.com/dwlnetnl/generpc/coder"
+	"github.com/dwlnetnl/generpc/generpctest"
+)
+
+// Request is a coder-neutral request.
+//
+// ID is an int or string, or nil for notifications. If Malformed is set, the
+// other fields should be ignored and a well-formed value should be encoded
+// that isn't a request object, like 1 in JSON. The coder should decode it as
+// nil entry in a batch or fail with an "Invalid Request" error.
+type Request struct {
+	Method    string
+	Params    interface{} // []interface{} or map[string]interface{}
+	ID        interface{}
+	Malformed bool
+}
+
+// Response is a coder-neutral response. ID is an int, float64 or string, or
+// nil if the response has a null ID.
+type Response struct {
+	Result interface{}
+	Error  *coder.Error
+	ID     interface{}
+}
+
+// EncodeFunc should encode the requests in the wire format of the coder, a
+// single request if batch is false.
+type EncodeFunc func(s []Request, batch bool) ([]byte, error)
+
+// DecodeFunc should decode the response(s) in the wire format of the coder
+// and indicate if the input is a batch.
+type DecodeFunc func(data []byte) (s []Response, batch bool, err error)
+
+// Case is a conformance test case. A nil Want means no response is expected.
+type Case struct {
+	Name      string
+	Reqs      []Request
+	Batch     bool
+	Want      []Response
+	WantBatch bool
+}
+
+// Cases returns the conformance test cases.
+func Cases() []Case {
+	return []Case{
+		{
+			Name: "positional params",
+			Reqs: []Request{{Method: "subtract", Params: params(42, 23), ID: 1}},
+			Want: []Response{{Result: 19, ID: 1}},
+		},
+		{
+			Name: "positional params reversed",
+			Reqs: []Request{{Method: "subtract", Params: params(23, 42), ID: 2}},
+			Want: []Response{{Result: -19, ID: 2}},
+		},
+		{
+			Name: "named params",
+			Reqs: []Request{{Method: "subtract", Params: map[string]interface{}{"subtrahend": 23, "minuend": 42}, ID: 3}},
+			Want: []Response{{Result: 19, ID: 3}},
+		},
+		{
+			Name: "named params reordered",
+			Reqs: []Request{{Method: "subtract", Params: map[string]interface{}{"minuend": 42, "subtrahend": 23}, ID: 4}},
+			Want: []Response{{Result: 19, ID: 4}},
+		},
+		{
+			Name: "notification",
+			Reqs: []Request{{Method: "update", Params: params(1, 2, 3, 4, 5)}},
+		},
+		{
+			Name: "notification of non-existent method",
+			Reqs: []Request{{Method: "foobar", Params: params()}},
+		},
+		{
+			Name: "non-existent method",
+			Reqs: []Request{{Method: "foobar", Params: params(), ID: "1"}},
+			Want: []Response{{Error: errorCode(-32601), ID: "1"}},
+		},
+		{
+			Name: "string id",
+			Reqs: []Request{{Method: "subtract", Params: params(42, 23), ID: "abc"}},
+			Want: []Response{{Result: 19, ID: "abc"}},
+		},
+		{
+			Name: "zero id",
+			Reqs: []Request{{Method: "subtract", Params: params(42, 23), ID: 0}},
+			Want: []Response{{Result: 19, ID: 0}},
+		},
+		{
+			Name: "malformed request",
+			Reqs: []Request{{Malformed: true}},
+			Want: []Response{{Error: errorCode(-32600)}},
+		},
+		{
+			Name:  "empty batch",
+			Batch: true,
+			Want:  []Response{{Error: errorCode(-32600)}},
+		},
+		{
+			Name:      "malformed batch entry",
+			Reqs:      []Request{{Malformed: true}},
+			Batch:     true,
+			Want:      []Response{{Error: errorCode(-32600)}},
+			WantBatch: true,
+		},
+		{
+			Name:  "malformed batch entries",
+			Reqs:  []Request{{Malformed: true}, {Malformed: true}, {Malformed: true}},
+			Batch: true,
+			Want: []Response{
+				{Error: errorCode(-32600)},
+				{Error: errorCode(-32600)},
+				{Error: errorCode(-32600)},
+			},
+			WantBatch: true,
+		},
+		{
+			Name: "batch",
+			Reqs: []Request{
+				{Method: "sum", Params: params(1, 2, 4), ID: "1"},
+				{Method: "notify_hello", Params: params(7)},
+				{Method: "subtract", Params: params(42, 23), ID: "2"},
+				{Malformed: true},
+				{Method: "foo.get", Params: map[string]interface{}{"name": "myself"}, ID: "5"},
+				{Method: "get_data", Params: params(), ID: "9"},
+			},
+			Batch: true,
+			Want: []Response{
+				{Result: 7, ID: "1"},
+				{Result: 19, ID: "2"},
+				{Error: errorCode(-32600)},
+				{Error: errorCode(-32601), ID: "5"},
+				{Result: []interface{}{"hello", 5}, ID: "9"},
+			},
+			WantBatch: true,
+		},
+		{
+			Name: "batch of notifications",
+			Reqs: []Request{
+				{Method: "notify_sum", Params: params(1, 2, 4)},
+				{Method: "notify_hello", Params: params(7)},
+			},
+			Batch: true,
+		},
+		{
+			Name: "exception",
+			Reqs: []Request{{Method: "unencodable", Params: params(), ID: 1}},
+			Want: []Response{{Error: errorCode(coder.ExceptionErrorCode)}},
+		},
+	}
+}
+
+func params(v ...interface{}) []interface{} {
+	if v == nil {
+		return []interface{}{}
+	}
+
+	return v
+}
+
+func errorCode(code int) *coder.Error {
+	return &coder.Error{Code: code}
+}
+
+// Server returns the server that is used by the test cases.
+func Server() *generpc.Server {
+	num := func(v interface{}) float64 {
+		n, _ := v.(coder.Number)
+		if n == nil {
+			return 0
+		}
+		f, _ := n.CastFloat64()
+		return f
+	}
+
+	s := generpc.NewServer()
+	s.ErrorLog = log.New(io.Discard, "", 0)
+	s.Register("subtract", generpc.Method{
+		ParamNames: []string{"minuend", "subtrahend"},
+		Func: func(params []interface{}) interface{} {
+			return num(params[0]) - num(params[1])
+		},
+	})
+	s.Register("sum", generpc.Method{
+		Func: func(params []interface{}) interface{} {
+			var sum float64
+			for _, p := range params {
+				sum += num(p)
+			}
+			return sum
+		},
+	})
+	s.Register("get_data", generpc.Method{
+		Func: func(params []interface{}) interface{} {
+			return []interface{}{"hello", 5}
+		},
+	})
+	s.Register("unencodable", generpc.Method{
+		Func: func(params []interface{}) interface{} {
+			return func() {}
+		},
+	})
+
+	nop := generpc.Method{Func: func([]interface{}) interface{} { return nil }}
+	s.Register("update", nop)
+	s.Register("notify_hello", nop)
+	s.Register("notify_sum", nop)
+
+	return s
+}
+
+// Run runs the conformance test cases for the coder registered for mediaType.
+func Run(t *testing.T, mediaType string, encode EncodeFunc, decode DecodeFunc) {
+	t.Helper()
+
+	s := Server()
+	for _, c := range Cases() {
+		c := c
+		t.Run(c.Name, func(t *testing.T) {
+			if err := runCase(s, mediaType, c, encode, decode); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func runCase(s http.Handler, mediaType string, c Case, encode EncodeFunc, decode DecodeFunc) error {
+	body, err := encode(c.Reqs, c.Batch)
+	if err != nil {
+		return fmt.Errorf("encode: %v", err)
+	}
+
+	r := httptest.NewRequest("POST", "/", bytes.NewReader(body))
+	r.Header.Set("Content-Type", mediaType)
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, r)
+
+	if w.Code == http.StatusUnsupportedMediaType {
+		return fmt.Errorf("no coder registered for media type %q", mediaType)
+	}
+
+	if c.Want == nil {
+		if w.Body.Len() != 0 {
+			return fmt.Errorf("got response %q, want none", w.Body)
+		}
+		return nil
+	}
+
+	if w.Header().Get("Content-Type") == "" {
+		return fmt.Errorf("response has no Content-Type")
+	}
+
+	resps, batch, err := decode(w.Body.Bytes())
+	if err != nil {
+		return fmt.Errorf("decode: %v\n%q", err, w.Body)
+	}
+
+	if batch != c.WantBatch {
+		return fmt.Errorf("got batch %t, want %t", batch, c.WantBatch)
+	}
+
+	if len(resps) != len(c.Want) {
+		return fmt.Errorf("got %d responses, want %d: %+v", len(resps), len(c.Want), resps)
+	}
+
+	for i, want := range c.Want {
+		if err := compare(resps[i], want); err != nil {
+			return fmt.Errorf("response %d: %v", i, err)
+		}
+	}
+
+	return nil
+}
+
+func compare(got, want Response) error {
+	if g, w := generpctest.Normalize(got.ID), generpctest.Normalize(want.ID); g != w {
+		return fmt.Errorf("got id %v, want %v", g, w)
+	}
+
+	if want.Error != nil {
+		if got.Error == nil {
+			return fmt.Errorf("got result %v, want error %d", got.Result, want.Error.Code)
+		}
+		if got.Error.Code != want.Error.Code {
+			return fmt.Errorf("got error %v, want error %d", got.Error, want.Error.Code)
+		}
+		return nil
+	}
+
+	if got.Error != nil {
+		return fmt.Errorf("got error %v, want result %v", got.Error, want.Result)
+	}
+
+	g, w := generpctest.Normalize(got.Result), generpctest.Normalize(want.Result)
+	if !reflect.DeepEqual(g, w) {
+		return fmt.Errorf("got result %v, want %v", g, w)
+	}
+
+	return nil
+}
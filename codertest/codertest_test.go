@@ -0,0 +1,88 @@
+package codertest
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func encodeJSON(s []Request, batch bool) ([]byte, error) {
+	entries := []interface{}{}
+	for _, r := range s {
+		if r.Malformed {
+			entries = append(entries, 1)
+			continue
+		}
+
+		e := map[string]interface{}{"jsonrpc": "2.0", "method": r.Method, "params": r.Params}
+		if r.ID != nil {
+			e["id"] = r.ID
+		}
+		entries = append(entries, e)
+	}
+
+	if !batch {
+		return json.Marshal(entries[0])
+	}
+
+	return json.Marshal(entries)
+}
+
+type jsonResponse struct {
+	Result interface{}
+	Error  *struct{ Code int }
+	ID     interface{}
+}
+
+func (r jsonResponse) response() Response {
+	resp := Response{Result: r.Result, ID: r.ID}
+	if r.Error != nil {
+		resp.Error = errorCode(r.Error.Code)
+	}
+	return resp
+}
+
+func decodeJSON(data []byte) ([]Response, bool, error) {
+	var batch []jsonResponse
+	if err := json.Unmarshal(data, &batch); err == nil {
+		var s []Response
+		for _, r := range batch {
+			s = append(s, r.response())
+		}
+		return s, true, nil
+	}
+
+	var r jsonResponse
+	err := json.Unmarshal(data, &r)
+	return []Response{r.response()}, false, err
+}
+
+func TestRun(t *testing.T) {
+	Run(t, "application/json", encodeJSON, decodeJSON)
+}
+
+func TestRun_failures(t *testing.T) {
+	s := Server()
+
+	// IDs are dropped by the decoder.
+	decode := func(data []byte) ([]Response, bool, error) {
+		resps, batch, err := decodeJSON(data)
+		for i := range resps {
+			resps[i].ID = nil
+		}
+		return resps, batch, err
+	}
+
+	var failed []string
+	for _, c := range Cases() {
+		if runCase(s, "application/json", c, encodeJSON, decode) != nil {
+			failed = append(failed, c.Name)
+		}
+	}
+	assert.Contains(t, failed, "positional params")
+	assert.NotContains(t, failed, "empty batch")
+
+	err := runCase(s, "application/x-unknown", Cases()[0], encodeJSON, decodeJSON)
+	assert.EqualError(t, err, `no coder registered for media type "application/x-unknown"`)
+}
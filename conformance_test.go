@@ -0,0 +1,56 @@
+package generpc_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/dwlnetnl/generpc/coder"
+	"github.com/dwlnetnl/generpc/codertest"
+)
+
+func encodeJSON(s []codertest.Request, batch bool) ([]byte, error) {
+	entries := []interface{}{}
+	for _, r := range s {
+		if r.Malformed {
+			entries = append(entries, 1)
+			continue
+		}
+
+		e := map[string]interface{}{"jsonrpc": "2.0", "method": r.Method, "params": r.Params}
+		if r.ID != nil {
+			e["id"] = r.ID
+		}
+		entries = append(entries, e)
+	}
+
+	if !batch {
+		return json.Marshal(entries[0])
+	}
+
+	return json.Marshal(entries)
+}
+
+func decodeJSON(data []byte) ([]codertest.Response, bool, error) {
+	resps, batch, err := coder.NewClient("application/json").DecodeResponses(data)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var s []codertest.Response
+	for _, r := range resps {
+		var id interface{}
+		if *r.ID != nil {
+			if err := json.Unmarshal(*r.ID, &id); err != nil {
+				return nil, false, err
+			}
+		}
+
+		s = append(s, codertest.Response{Result: r.Result, Error: r.Error, ID: id})
+	}
+
+	return s, batch, nil
+}
+
+func TestJSONConformance(t *testing.T) {
+	codertest.Run(t, "application/json", encodeJSON, decodeJSON)
+}
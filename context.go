@@ -0,0 +1,38 @@
+package generpc
+
+import (
+	"context"
+	"net/http"
+)
+
+type httpRequestKey struct{}
+
+// HTTPRequestFromContext returns the HTTP request of the call, or nil if the
+// call wasn't received via HTTP.
+func HTTPRequestFromContext(ctx context.Context) *http.Request {
+	r, _ := ctx.Value(httpRequestKey{}).(*http.Request)
+	return r
+}
+
+type mediaTypeKey struct{}
+
+// MediaTypeFromContext returns the media type of the call, it's the
+// Content-Type of the HTTP request that selected the coder unless set with
+// ContextWithMediaType.
+func MediaTypeFromContext(ctx context.Context) string {
+	if mt, ok := ctx.Value(mediaTypeKey{}).(string); ok {
+		return mt
+	}
+
+	if r := HTTPRequestFromContext(ctx); r != nil {
+		return r.Header.Get("Content-Type")
+	}
+
+	return ""
+}
+
+// ContextWithMediaType returns a copy of ctx with the media type of the call,
+// for calls that aren't received via HTTP (see Server.Invoke).
+func ContextWithMediaType(ctx context.Context, mediaType string) context.Context {
+	return context.WithValue(ctx, mediaTypeKey{}, mediaType)
+}
@@ -0,0 +1,213 @@
+// Package convert converts decoded RPC values into Go values. Coders decode
+// numbers as coder.Number and objects as map[string]interface{}, the functions
+// in this package convert them into typed values. It's used by generated code,
+// see cmd/generpc-gen.
+package convert
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/dwlnetnl/generpc/coder"
+)
+
+// Int converts a number into an int64 that fits in bitSize bits. A bitSize
+// of 0 means the size of int.
+func Int(v interface{}, bitSize int) (int64, error) {
+	if bitSize == 0 {
+		bitSize = strconv.IntSize
+	}
+
+	n, ok := v.(coder.Number)
+	if !ok {
+		return 0, typeError(v, "an integer")
+	}
+
+	f, ok := n.CastFloat64()
+	if !ok || f != math.Trunc(f) {
+		return 0, fmt.Errorf("%v is not an integer", v)
+	}
+
+	i, ok := n.CastInt()
+	if !ok && math.Abs(f) <= 1<<53 {
+		// Integer in exponent notation, like 1e3.
+		i, ok = int(f), true
+	}
+
+	if !ok || int64(i) < -1<<(bitSize-1) || int64(i) > 1<<(bitSize-1)-1 {
+		return 0, fmt.Errorf("%v is out of range", v)
+	}
+
+	return int64(i), nil
+}
+
+// Uint converts a number into an uint64 that fits in bitSize bits. A bitSize
+// of 0 means the size of uint.
+func Uint(v interface{}, bitSize int) (uint64, error) {
+	if bitSize == 0 {
+		bitSize = strconv.IntSize
+	}
+
+	n, ok := v.(coder.Number)
+	if !ok {
+		return 0, typeError(v, "an integer")
+	}
+
+	f, ok := n.CastFloat64()
+	if !ok || f != math.Trunc(f) {
+		return 0, fmt.Errorf("%v is not an integer", v)
+	}
+
+	u, ok := n.CastUint()
+	if !ok && f >= 0 && f <= 1<<53 {
+		u, ok = uint(f), true
+	}
+
+	if !ok || (bitSize < 64 && uint64(u) > 1<<bitSize-1) {
+		return 0, fmt.Errorf("%v is out of range", v)
+	}
+
+	return uint64(u), nil
+}
+
+// Float converts a number into a float64 that fits in bitSize bits.
+func Float(v interface{}, bitSize int) (float64, error) {
+	n, ok := v.(coder.Number)
+	if !ok {
+		return 0, typeError(v, "a number")
+	}
+
+	f, ok := n.CastFloat64()
+	if !ok || (bitSize == 32 && math.Abs(f) > math.MaxFloat32) {
+		return 0, fmt.Errorf("%v is out of range", v)
+	}
+
+	return f, nil
+}
+
+// String converts a string.
+func String(v interface{}) (string, error) {
+	s, ok := v.(string)
+	if !ok {
+		return "", typeError(v, "a string")
+	}
+
+	return s, nil
+}
+
+// Bool converts a boolean.
+func Bool(v interface{}) (bool, error) {
+	b, ok := v.(bool)
+	if !ok {
+		return false, typeError(v, "a boolean")
+	}
+
+	return b, nil
+}
+
+// Value converts v into the value pointed to by out via its JSON
+// representation, like encoding/json.Unmarshal.
+func Value(v interface{}, out interface{}) error {
+	b, err := json.Marshal(Numbers(v))
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(b, out)
+}
+
+// Numbers returns v with coder.Number values converted into json.Number, so
+// it can be encoded with encoding/json. The text of numbers that implement
+// fmt.Stringer (like those of the JSON coder) is kept, other numbers are
+// converted into an integer if possible so they don't lose precision.
+func Numbers(v interface{}) interface{} {
+	switch v := v.(type) {
+	case json.Number:
+		return v
+
+	case coder.Number:
+		if s, ok := v.(fmt.Stringer); ok && isNumber(s.String()) {
+			return json.Number(s.String())
+		}
+		if i, ok := v.CastInt(); ok {
+			return json.Number(strconv.Itoa(i))
+		}
+		if u, ok := v.CastUint(); ok {
+			return json.Number(strconv.FormatUint(uint64(u), 10))
+		}
+		f, _ := v.CastFloat64()
+		return f
+
+	case []interface{}:
+		s := make([]interface{}, len(v))
+		for i, e := range v {
+			s[i] = Numbers(e)
+		}
+		return s
+
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			m[k] = Numbers(e)
+		}
+		return m
+	}
+
+	return v
+}
+
+// isNumber reports if s is a JSON number.
+func isNumber(s string) bool {
+	return s != "" && (s[0] == '-' || (s[0] >= '0' && s[0] <= '9')) && json.Valid([]byte(s))
+}
+
+func typeError(v interface{}, want string) error {
+	if v == nil {
+		return fmt.Errorf("null is not %s", want)
+	}
+
+	return fmt.Errorf("%T is not %s", v, want)
+}
+
+// ParamError returns an "Invalid params" error for the named parameter.
+func ParamError(name string, err error) *coder.Error {
+	return &coder.Error{
+		Code:    -32602,
+		Message: "Invalid params",
+		Data:    map[string]interface{}{"param": name, "message": err.Error()},
+	}
+}
+
+// ParamCount returns an "Invalid params" error if there aren't n params.
+func ParamCount(params []interface{}, n int) *coder.Error {
+	if len(params) == n {
+		return nil
+	}
+
+	return &coder.Error{
+		Code:    -32602,
+		Message: "Invalid params",
+		Data:    fmt.Sprintf("expected %d params, got %d", n, len(params)),
+	}
+}
+
+// ParamRange returns an "Invalid params" error if there aren't min to max
+// params. Otherwise params is returned with nil for the omitted optional
+// params, so it has max params.
+func ParamRange(params []interface{}, min, max int) ([]interface{}, *coder.Error) {
+	if len(params) < min || len(params) > max {
+		return nil, &coder.Error{
+			Code:    -32602,
+			Message: "Invalid params",
+			Data:    fmt.Sprintf("expected %d to %d params, got %d", min, max, len(params)),
+		}
+	}
+
+	for len(params) < max {
+		params = append(params, nil)
+	}
+
+	return params, nil
+}
@@ -0,0 +1,152 @@
+package convert
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/dwlnetnl/generpc/coder"
+)
+
+type number struct{ json.Number }
+
+func (n number) CastFloat64() (float64, bool) {
+	f, err := n.Float64()
+	return f, err == nil
+}
+
+func (n number) CastInt() (int, bool) {
+	i, err := n.Int64()
+	return int(i), err == nil
+}
+
+func (n number) CastUint() (uint, bool) {
+	i, ok := n.CastInt()
+	return uint(i), ok && i >= 0
+}
+
+func TestInt(t *testing.T) {
+	tests := []struct {
+		v       interface{}
+		bitSize int
+		want    int64
+		err     string
+	}{
+		{number{"42"}, 64, 42, ""},
+		{number{"42"}, 0, 42, ""},
+		{number{"-128"}, 8, -128, ""},
+		{number{"1e3"}, 64, 1000, ""},
+		{number{"128"}, 8, 0, "128 is out of range"},
+		{number{"1.5"}, 64, 0, "1.5 is not an integer"},
+		{"42", 64, 0, "string is not an integer"},
+		{nil, 64, 0, "null is not an integer"},
+	}
+
+	for _, tt := range tests {
+		got, err := Int(tt.v, tt.bitSize)
+		if tt.err != "" {
+			assert.EqualError(t, err, tt.err, tt.v)
+			continue
+		}
+		assert.NoError(t, err, tt.v)
+		assert.Equal(t, tt.want, got, tt.v)
+	}
+}
+
+func TestUint(t *testing.T) {
+	got, err := Uint(number{"255"}, 8)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(255), got)
+
+	_, err = Uint(number{"256"}, 8)
+	assert.EqualError(t, err, "256 is out of range")
+
+	_, err = Uint(number{"-1"}, 64)
+	assert.EqualError(t, err, "-1 is out of range")
+}
+
+func TestFloat(t *testing.T) {
+	got, err := Float(number{"1.5"}, 64)
+	assert.NoError(t, err)
+	assert.Equal(t, 1.5, got)
+
+	_, err = Float(number{"1e300"}, 32)
+	assert.EqualError(t, err, "1e300 is out of range")
+}
+
+func TestStringBool(t *testing.T) {
+	s, err := String("a")
+	assert.NoError(t, err)
+	assert.Equal(t, "a", s)
+
+	_, err = String(true)
+	assert.EqualError(t, err, "bool is not a string")
+
+	b, err := Bool(true)
+	assert.NoError(t, err)
+	assert.True(t, b)
+
+	_, err = Bool("true")
+	assert.EqualError(t, err, "string is not a boolean")
+}
+
+func TestValue(t *testing.T) {
+	var v struct {
+		Name string
+		Age  int
+		Tags []string
+	}
+
+	in := map[string]interface{}{"Name": "alice", "Age": number{"42"}, "Tags": []interface{}{"a"}}
+	assert.NoError(t, Value(in, &v))
+	assert.Equal(t, "alice", v.Name)
+	assert.Equal(t, 42, v.Age)
+	assert.Equal(t, []string{"a"}, v.Tags)
+
+	assert.Error(t, Value("a", &v))
+}
+
+// bigNumber is a coder.Number without its text.
+type bigNumber uint64
+
+func (n bigNumber) CastFloat64() (float64, bool) { return float64(n), true }
+func (n bigNumber) CastInt() (int, bool)         { return int(n), n <= math.MaxInt64 }
+func (n bigNumber) CastUint() (uint, bool)       { return uint(n), true }
+
+func TestNumbers(t *testing.T) {
+	got := Numbers([]interface{}{
+		number{"18446744073709551615"},
+		number{"0.1000000000000000055511151231257827"},
+		map[string]interface{}{"n": bigNumber(math.MaxUint64)},
+		bigNumber(42),
+	})
+	assert.Equal(t, []interface{}{
+		json.Number("18446744073709551615"),
+		json.Number("0.1000000000000000055511151231257827"),
+		map[string]interface{}{"n": json.Number("18446744073709551615")},
+		json.Number("42"),
+	}, got)
+}
+
+func TestParamErrors(t *testing.T) {
+	assert.Equal(t, &coder.Error{
+		Code:    -32602,
+		Message: "Invalid params",
+		Data:    map[string]interface{}{"param": "a", "message": "string is not an integer"},
+	}, ParamError("a", errors.New("string is not an integer")))
+
+	assert.Nil(t, ParamCount([]interface{}{1}, 1))
+	assert.Equal(t, &coder.Error{Code: -32602, Message: "Invalid params", Data: "expected 2 params, got 1"},
+		ParamCount([]interface{}{1}, 2))
+
+	params, e := ParamRange([]interface{}{1}, 1, 3)
+	assert.Nil(t, e)
+	assert.Equal(t, []interface{}{1, nil, nil}, params)
+	_, e = ParamRange([]interface{}{}, 1, 3)
+	assert.Equal(t, &coder.Error{Code: -32602, Message: "Invalid params", Data: "expected 1 to 3 params, got 0"}, e)
+	_, e = ParamRange([]interface{}{1, 2, 3, 4}, 1, 3)
+	assert.NotNil(t, e)
+}
@@ -0,0 +1,185 @@
+package generpc
+
+import (
+	"context"
+	"sort"
+	"strconv"
+
+	"github.com/dwlnetnl/generpc/coder"
+	"github.com/dwlnetnl/generpc/openrpc"
+	"github.com/dwlnetnl/generpc/schema"
+)
+
+// discoverMethod is the OpenRPC service discovery method.
+const discoverMethod = "rpc.discover"
+
+// Discover returns the OpenRPC document describing the registered methods,
+// regardless of their authorization. The result of the rpc.discover method
+// only describes the methods the caller is authorized to call, see
+// Method.Authorization.
+func (s *Server) Discover() *openrpc.Document {
+	return s.discover(s.methods(), nil)
+}
+
+// authorized returns a filter for discover that reports if the caller of ctx
+// is authorized to call a method.
+func authorized(ctx context.Context) func(m *Method) bool {
+	return func(m *Method) bool {
+		return m.authorize(ctx) == nil
+	}
+}
+
+// discover returns the document describing the methods for which visible
+// reports true, or all methods if visible is nil.
+func (s *Server) discover(t methodTable, visible func(m *Method) bool) *openrpc.Document {
+	doc := &openrpc.Document{
+		OpenRPC: openrpc.Version,
+		Info:    s.Info,
+		Methods: []openrpc.Method{},
+	}
+
+	if doc.Info.Title == "" {
+		doc.Info.Title = "GeneRPC"
+	}
+
+	if doc.Info.Version == "" {
+		doc.Info.Version = "0.0.0"
+	}
+
+	doc.Methods = t.describe(visible)
+
+	if defs := ErrorDefs(); len(defs) > 0 {
+		errs := make(map[string]openrpc.Error, len(defs))
+		for _, d := range defs {
+			errs[d.String()] = describeError(d.CoderError())
+		}
+
+		doc.Components = &openrpc.Components{Errors: errs}
+	}
+
+	return doc
+}
+
+// describe describes the methods in the table for which visible reports true
+// (all if it's nil) ordered by name. The methods of mounted servers are
+// included with their prefix.
+func (t methodTable) describe(visible func(m *Method) bool) []openrpc.Method {
+	aliases := make(map[string][]string)
+	for name, m := range t {
+		if m.aliasOf != "" {
+			aliases[m.aliasOf] = append(aliases[m.aliasOf], name)
+		}
+	}
+
+	ms := []openrpc.Method{}
+	for name, m := range t {
+		if m.mount != nil {
+			for _, d := range m.mount.methods().describe(visible) {
+				d.Name = name + d.Name
+				if d.AliasOf != "" {
+					d.AliasOf = name + d.AliasOf
+				}
+				for i := range d.Aliases {
+					d.Aliases[i] = name + d.Aliases[i]
+				}
+				ms = append(ms, d)
+			}
+			continue
+		}
+
+		target := m
+		if m.aliasOf != "" {
+			target = t[m.aliasOf]
+			if target == nil {
+				continue
+			}
+		}
+
+		if visible != nil && !visible(target) {
+			continue
+		}
+
+		d := target.describe(name)
+		d.AliasOf = m.aliasOf
+		d.Aliases = aliases[name]
+		sort.Strings(d.Aliases)
+		d.Deprecated = m.Deprecated != ""
+		d.DeprecationNotice = m.Deprecated
+		ms = append(ms, d)
+	}
+
+	sort.Slice(ms, func(i, j int) bool { return ms[i].Name < ms[j].Name })
+	return ms
+}
+
+func (m *Method) describe(name string) openrpc.Method {
+	d := openrpc.Method{
+		Name:           name,
+		ParamStructure: openrpc.Either,
+		Params:         []openrpc.ContentDescriptor{},
+	}
+
+	n := len(m.ParamNames)
+	if len(m.ParamSchemas) > n {
+		n = len(m.ParamSchemas)
+	}
+
+	if m.ParamStructure != "" {
+		d.ParamStructure = m.ParamStructure
+	}
+
+	if n > len(m.ParamNames) && d.ParamStructure == openrpc.Either {
+		// By-name params cannot be used for parameters without a name.
+		d.ParamStructure = openrpc.ByPosition
+	}
+
+	for i := 0; i < n; i++ {
+		p := openrpc.ContentDescriptor{
+			Name:     "param" + strconv.Itoa(i),
+			Required: !m.optional(i),
+			Schema:   anySchema(nil),
+		}
+
+		if i < len(m.ParamNames) {
+			p.Name = m.ParamNames[i]
+		}
+
+		if i < len(m.ParamSchemas) {
+			p.Schema = anySchema(m.ParamSchemas[i])
+		}
+
+		d.Params = append(d.Params, p)
+	}
+
+	d.Result = &openrpc.ContentDescriptor{
+		Name:   "result",
+		Schema: anySchema(m.ResultSchema),
+	}
+
+	for _, e := range m.Errors {
+		d.Errors = append(d.Errors, describeError(e))
+	}
+
+	return d
+}
+
+// describeError describes e, the data schema is added if the error is
+// defined in the error catalogue.
+func describeError(e coder.Error) openrpc.Error {
+	d := openrpc.Error{Code: e.Code, Message: e.Message, Data: e.Data}
+
+	if def, ok := LookupError(e.Code); ok {
+		d.DataSchema = def.Data
+	}
+
+	return d
+}
+
+// anySchema returns s or a schema that accepts any value if s is nil.
+func anySchema(s *schema.Schema) *schema.Schema {
+	if s == nil {
+		return &schema.Schema{}
+	}
+
+	return s
+}
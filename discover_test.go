@@ -0,0 +1,58 @@
+package generpc
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dwlnetnl/generpc/openrpc"
+)
+
+func TestDiscover(t *testing.T) {
+	body := strings.NewReader(`{"jsonrpc":"2.0","method":"rpc.discover","id":1}`)
+
+	r, err := http.NewRequest("POST", "/", body)
+	r.Header.Add("Content-Type", "application/json")
+	require.NoError(t, err)
+
+	h := NewServer()
+	h.Info = openrpc.Info{Title: "Test", Version: "1.0.0"}
+	h.Register("subtract", schemaSubtractMethod())
+	h.Register("error", errorMethod())
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	want := `{"jsonrpc":"2.0","result":{
+		"openrpc":"1.2.6",
+		"info":{"title":"Test","version":"1.0.0"},
+		"methods":[
+			{
+				"name":"error",
+				"paramStructure":"either",
+				"params":[],
+				"result":{"name":"result","schema":{}}
+			},
+			{
+				"name":"subtract",
+				"paramStructure":"either",
+				"params":[
+					{"name":"minuend","required":true,"schema":{"type":"integer"}},
+					{"name":"subtrahend","required":true,"schema":{"type":"integer","minimum":0}}
+				],
+				"result":{"name":"result","schema":{"type":"integer","minimum":0}}
+			}
+		]
+	},"id":1}`
+	assert.JSONEq(t, want, w.Body.String())
+}
+
+func TestDiscover_defaultInfo(t *testing.T) {
+	doc := NewServer().Discover()
+	assert.Equal(t, openrpc.Info{Title: "GeneRPC", Version: "0.0.0"}, doc.Info)
+	assert.Empty(t, doc.Methods)
+}
@@ -0,0 +1,56 @@
+package generpc
+
+import "github.com/dwlnetnl/generpc/coder"
+
+// DuplicateIDPolicy controls the handling of requests in a batch that share
+// the same ID. Notifications are never considered duplicates.
+type DuplicateIDPolicy int
+
+const (
+	// AllowDuplicateIDs invokes every request, the client receives multiple
+	// responses with the same ID.
+	AllowDuplicateIDs DuplicateIDPolicy = iota
+
+	// RejectDuplicateIDs responds to every request that shares its ID with
+	// another request in the batch with an "Invalid Request" error. The other
+	// requests in the batch are invoked.
+	RejectDuplicateIDs
+
+	// RejectDuplicateBatch rejects the batch with a single "Invalid Request"
+	// error if any requests share an ID.
+	RejectDuplicateBatch
+)
+
+var duplicateID = *coder.InvalidRequest.WithString("duplicate request id")
+
+// duplicateIDs returns the indexes of the requests that share their ID with
+// another request. IDs are compared by the key returned by the coder if it
+// implements coder.IDNormalizer, otherwise they're compared byte-wise.
+func duplicateIDs(c coder.Coder, reqs []*coder.Request) map[int]bool {
+	n, _ := c.(coder.IDNormalizer)
+
+	first := make(map[string]int, len(reqs))
+	dups := make(map[int]bool)
+
+	for i, req := range reqs {
+		if req == nil || req.Error != nil || *req.ID == nil {
+			continue
+		}
+
+		key := string(*req.ID)
+		if n != nil {
+			key = n.NormalizeID(*req.ID)
+		}
+
+		j, ok := first[key]
+		if !ok {
+			first[key] = i
+			continue
+		}
+
+		dups[i] = true
+		dups[j] = true
+	}
+
+	return dups
+}
@@ -0,0 +1,59 @@
+package generpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDuplicateIDs(t *testing.T) {
+	body := `[
+		{"jsonrpc":"2.0","method":"subtract","params":[42,23],"id":1},
+		{"jsonrpc":"2.0","method":"subtract","params":[42,23],"id":"1"},
+		{"jsonrpc":"2.0","method":"subtract","params":[42,23]},
+		{"jsonrpc":"2.0","method":"subtract","params":[42,23]},
+		{"jsonrpc":"2.0","method":"subtract","params":[42,23],"id":1.0}
+	]`
+
+	cases := []struct {
+		policy DuplicateIDPolicy
+		want   string
+	}{
+		{AllowDuplicateIDs, `[
+			{"jsonrpc":"2.0","result":19,"id":1},
+			{"jsonrpc":"2.0","result":19,"id":"1"},
+			{"jsonrpc":"2.0","result":19,"id":1.0}
+		]`},
+		{RejectDuplicateIDs, `[
+			{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid Request","data":"duplicate request id"},"id":1},
+			{"jsonrpc":"2.0","result":19,"id":"1"},
+			{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid Request","data":"duplicate request id"},"id":1.0}
+		]`},
+		{RejectDuplicateBatch, `{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid Request","data":"duplicate request id"},"id":null}`},
+	}
+
+	for _, c := range cases {
+		r, err := http.NewRequest("POST", "/", strings.NewReader(body))
+		r.Header.Add("Content-Type", "application/json")
+		require.NoError(t, err)
+
+		h := NewServer()
+		h.DuplicateIDs = c.policy
+		h.Register("subtract", subtractMethod())
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+
+		want := new(bytes.Buffer)
+		require.NoError(t, json.Compact(want, []byte(c.want)))
+		want.WriteByte('\n')
+
+		assert.Equal(t, want.String(), w.Body.String())
+	}
+}
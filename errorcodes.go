@@ -0,0 +1,123 @@
+package generpc
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+
+	"github.com/dwlnetnl/generpc/coder"
+	"github.com/dwlnetnl/generpc/schema"
+)
+
+// ErrorDef describes an application defined RPC error.
+type ErrorDef struct {
+	Package string
+	Name    string
+	Code    int
+	Message string
+	Data    *schema.Schema
+}
+
+// String returns the qualified name of the error.
+func (d ErrorDef) String() string { return d.Package + "." + d.Name }
+
+// CoderError returns the RPC error without data.
+func (d ErrorDef) CoderError() coder.Error {
+	return coder.Error{Code: d.Code, Message: d.Message}
+}
+
+// JSON-RPC 2.0 specification:
+//
+//	The error codes from and including -32768 to -32000 are reserved for
+//	pre-defined errors.
+const (
+	reservedErrorCodeBegin = -32768
+	reservedErrorCodeEnd   = -32000
+)
+
+var errorDefs struct {
+	sync.Mutex
+	m map[int]ErrorDef
+}
+
+func init() {
+	errorDefs.m = make(map[int]ErrorDef)
+}
+
+// DefineError registers an application error in the error catalogue and
+// returns it as RPC error. It's intended to be called when initializing
+// package level variables:
+//
+//	var ErrNotFound = generpc.DefineError("user", "NotFound", 1001, "User not found", nil)
+//
+// Data optionally describes the error data. DefineError panics if pkg, name or
+// message is empty, if the code is reserved by JSON-RPC 2.0 or if the code or
+// qualified name is already defined, possibly by another package.
+func DefineError(pkg, name string, code int, message string, data *schema.Schema) coder.Error {
+	if pkg == "" || name == "" || message == "" {
+		panic("generpc: package, name and message of error are required")
+	}
+
+	if code >= reservedErrorCodeBegin && code <= reservedErrorCodeEnd {
+		panic(fmt.Sprintf("generpc: error code %d of %s.%s is reserved", code, pkg, name))
+	}
+
+	def := ErrorDef{pkg, name, code, message, data}
+
+	errorDefs.Lock()
+	defer errorDefs.Unlock()
+
+	if d, dup := errorDefs.m[code]; dup {
+		panic(fmt.Sprintf("generpc: error code %d of %s collides with %s", code, def, d))
+	}
+
+	for _, d := range errorDefs.m {
+		if d.Package == pkg && d.Name == name {
+			panic("generpc: error already defined: " + def.String())
+		}
+	}
+
+	errorDefs.m[code] = def
+	return def.CoderError()
+}
+
+// ErrorDefs returns the error catalogue ordered by code.
+func ErrorDefs() []ErrorDef {
+	errorDefs.Lock()
+	defer errorDefs.Unlock()
+
+	defs := make([]ErrorDef, 0, len(errorDefs.m))
+	for _, d := range errorDefs.m {
+		defs = append(defs, d)
+	}
+
+	sort.Slice(defs, func(i, j int) bool { return defs[i].Code < defs[j].Code })
+	return defs
+}
+
+// LookupError returns the definition of an error code.
+func LookupError(code int) (ErrorDef, bool) {
+	errorDefs.Lock()
+	defer errorDefs.Unlock()
+
+	d, ok := errorDefs.m[code]
+	return d, ok
+}
+
+// WriteErrorCatalog writes the error catalogue as Markdown table to w.
+func WriteErrorCatalog(w io.Writer) error {
+	_, err := io.WriteString(w, "| Code | Name | Message |\n|---:|---|---|\n")
+	if err != nil {
+		return err
+	}
+
+	for _, d := range ErrorDefs() {
+		_, err := fmt.Fprintf(w, "| %d | `%s` | %s |\n", d.Code, d, d.Message)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,112 @@
+package generpc
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dwlnetnl/generpc/coder"
+	"github.com/dwlnetnl/generpc/schema"
+)
+
+// resetErrorDefs empties the error catalogue for the duration of the test.
+func resetErrorDefs(t *testing.T) {
+	errorDefs.Lock()
+	m := errorDefs.m
+	errorDefs.m = make(map[int]ErrorDef)
+	errorDefs.Unlock()
+
+	t.Cleanup(func() {
+		errorDefs.Lock()
+		errorDefs.m = m
+		errorDefs.Unlock()
+	})
+}
+
+func TestDefineError(t *testing.T) {
+	resetErrorDefs(t)
+
+	e := DefineError("user", "NotFound", 1001, "User not found", nil)
+	assert.Equal(t, coder.Error{Code: 1001, Message: "User not found"}, e)
+
+	assert.PanicsWithValue(t, "generpc: error code 1001 of billing.Declined collides with user.NotFound", func() {
+		DefineError("billing", "Declined", 1001, "Payment declined", nil)
+	})
+
+	assert.PanicsWithValue(t, "generpc: error already defined: user.NotFound", func() {
+		DefineError("user", "NotFound", 1002, "User not found", nil)
+	})
+
+	assert.PanicsWithValue(t, "generpc: error code -32001 of user.Bad is reserved", func() {
+		DefineError("user", "Bad", -32001, "Bad", nil)
+	})
+
+	assert.Panics(t, func() {
+		DefineError("user", "", 1003, "Empty", nil)
+	})
+
+	DefineError("billing", "Declined", 1000, "Payment declined", nil)
+
+	defs := ErrorDefs()
+	require.Len(t, defs, 2)
+	assert.Equal(t, "billing.Declined", defs[0].String())
+	assert.Equal(t, "user.NotFound", defs[1].String())
+}
+
+func TestWriteErrorCatalog(t *testing.T) {
+	resetErrorDefs(t)
+
+	DefineError("user", "NotFound", 1001, "User not found", nil)
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteErrorCatalog(&buf))
+
+	want := "| Code | Name | Message |\n|---:|---|---|\n| 1001 | `user.NotFound` | User not found |\n"
+	assert.Equal(t, want, buf.String())
+}
+
+func TestDiscover_errors(t *testing.T) {
+	resetErrorDefs(t)
+
+	data := schema.MustParse(`{"type":"string"}`)
+	notFound := DefineError("user", "NotFound", 1001, "User not found", data)
+
+	h := NewServer()
+	h.Register("user.get", Method{
+		Func:   func([]interface{}) interface{} { return notFound },
+		Errors: []coder.Error{notFound},
+	})
+
+	body := strings.NewReader(`{"jsonrpc":"2.0","method":"rpc.discover","id":1}`)
+	r, err := http.NewRequest("POST", "/", body)
+	r.Header.Add("Content-Type", "application/json")
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	want := `{"jsonrpc":"2.0","result":{
+		"openrpc":"1.2.6",
+		"info":{"title":"GeneRPC","version":"0.0.0"},
+		"methods":[
+			{
+				"name":"user.get",
+				"paramStructure":"either",
+				"params":[],
+				"result":{"name":"result","schema":{}},
+				"errors":[{"code":1001,"message":"User not found","x-data-schema":{"type":"string"}}]
+			}
+		],
+		"components":{
+			"errors":{
+				"user.NotFound":{"code":1001,"message":"User not found","x-data-schema":{"type":"string"}}
+			}
+		}
+	},"id":1}`
+	assert.JSONEq(t, want, w.Body.String())
+}
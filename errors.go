@@ -0,0 +1,102 @@
+package generpc
+
+import (
+	"errors"
+	"log"
+
+	"github.com/dwlnetnl/generpc/coder"
+)
+
+// RPCError can be implemented by errors returned from Method.Func to control
+// the RPC error returned to the client. The result of Error is used as
+// message.
+type RPCError interface {
+	error
+	RPCErrorCode() int
+	RPCErrorData() interface{}
+}
+
+// mapError maps err to a RPC error. The chain of err is searched for a
+// *coder.Error first and a RPCError second. Otherwise Server.ErrorFallback is
+// used. The full error is logged if it wraps other errors or if the fallback
+// is used, so wrapped errors never reach the client but aren't lost either.
+func (s *Server) mapError(method string, err error) *coder.Error {
+	var ce *coder.Error
+	if errors.As(err, &ce) && ce != nil {
+		if _, ok := err.(*coder.Error); !ok || wraps(err) {
+			s.logf("generpc: method %q: %v", method, err)
+		}
+		return ce
+	}
+
+	var re RPCError
+	if errors.As(err, &re) {
+		if _, ok := err.(RPCError); !ok || wraps(err) {
+			s.logf("generpc: method %q: %v", method, err)
+		}
+		return &coder.Error{
+			Code:    re.RPCErrorCode(),
+			Message: re.Error(),
+			Data:    re.RPCErrorData(),
+		}
+	}
+
+	s.logf("generpc: method %q: %v", method, err)
+
+	if s.ErrorFallback != nil {
+		if e := s.ErrorFallback(err); e != nil {
+			return e
+		}
+	}
+
+	e := internalError
+	return &e
+}
+
+// wraps reports whether err wraps other errors.
+func wraps(err error) bool {
+	switch err := err.(type) {
+	case interface{ Unwrap() error }:
+		return err.Unwrap() != nil
+	case interface{ Unwrap() []error }:
+		return len(err.Unwrap()) > 0
+	}
+
+	return false
+}
+
+func (s *Server) logf(format string, args ...interface{}) {
+	if s.ErrorLog != nil {
+		s.ErrorLog.Printf(format, args...)
+	} else {
+		log.Printf(format, args...)
+	}
+}
+
+// Server error codes used by GeneRPC. They are not reserved, so they're
+// available to coder.ServerError.
+const (
+	// TimeoutErrorCode is returned when a call exceeds its timeout or the
+	// batch timeout. The error data contains the scope ("method" or "batch"),
+	// the timeout and the elapsed duration of the call.
+	TimeoutErrorCode = -32000
+
+	// ShutdownErrorCode is returned when the server is shutting down, either
+	// for new requests or for calls that are cancelled because the shutdown
+	// deadline passed. See Server.Shutdown.
+	ShutdownErrorCode = -32001
+
+	// UnauthorizedErrorCode is returned when authentication failed or when a
+	// method requires authorization and the caller isn't authenticated.
+	UnauthorizedErrorCode = -32002
+
+	// ForbiddenErrorCode is returned when the authenticated caller doesn't
+	// have the roles or scopes required by a method. The error data contains
+	// the required roles and scopes.
+	ForbiddenErrorCode = -32003
+
+	// LimitErrorCode is returned when a call exceeds a rate limit or
+	// concurrency quota. The error data contains the number of seconds after
+	// which the call may be retried as "retryAfter". See Server.AddLimit.
+	LimitErrorCode = -32004
+)
@@ -0,0 +1,104 @@
+package generpc
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dwlnetnl/generpc/coder"
+)
+
+type testRPCError struct{}
+
+func (testRPCError) Error() string             { return "Test RPC error" }
+func (testRPCError) RPCErrorCode() int         { return 2 }
+func (testRPCError) RPCErrorData() interface{} { return "data" }
+
+func errMethod(err error) Method {
+	return Method{
+		Func: func(params []interface{}) interface{} {
+			return err
+		},
+	}
+}
+
+func TestErrorMapping(t *testing.T) {
+	cases := []struct {
+		err  error
+		want string
+		log  string
+	}{
+		{
+			&coder.Error{Code: 1, Message: "Test error"},
+			`{"jsonrpc":"2.0","error":{"code":1,"message":"Test error"},"id":1}`,
+			"",
+		},
+		{
+			fmt.Errorf("wrapped: %w", &coder.Error{Code: 1, Message: "Test error"}),
+			`{"jsonrpc":"2.0","error":{"code":1,"message":"Test error"},"id":1}`,
+			`generpc: method "err": wrapped: Test error (1)`,
+		},
+		{
+			testRPCError{},
+			`{"jsonrpc":"2.0","error":{"code":2,"message":"Test RPC error","data":"data"},"id":1}`,
+			"",
+		},
+		{
+			fmt.Errorf("wrapped: %w", testRPCError{}),
+			`{"jsonrpc":"2.0","error":{"code":2,"message":"Test RPC error","data":"data"},"id":1}`,
+			`generpc: method "err": wrapped: Test RPC error`,
+		},
+		{
+			fmt.Errorf("wrapped: %w", errors.New("secret")),
+			`{"jsonrpc":"2.0","error":{"code":-32603,"message":"Internal error"},"id":1}`,
+			`generpc: method "err": wrapped: secret`,
+		},
+	}
+
+	for _, c := range cases {
+		var buf bytes.Buffer
+
+		h := NewServer()
+		h.ErrorLog = log.New(&buf, "", 0)
+		h.Register("err", errMethod(c.err))
+
+		body := strings.NewReader(`{"jsonrpc":"2.0","method":"err","params":[],"id":1}`)
+		r, err := http.NewRequest("POST", "/", body)
+		r.Header.Add("Content-Type", "application/json")
+		require.NoError(t, err)
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+
+		assert.Equal(t, c.want+"\n", w.Body.String())
+		assert.Equal(t, c.log, strings.TrimSpace(buf.String()))
+	}
+}
+
+func TestErrorFallback(t *testing.T) {
+	h := NewServer()
+	h.ErrorLog = log.New(new(bytes.Buffer), "", 0)
+	h.ErrorFallback = func(err error) *coder.Error {
+		return &coder.Error{Code: 3, Message: "Unavailable"}
+	}
+	h.Register("err", errMethod(errors.New("error")))
+
+	body := strings.NewReader(`{"jsonrpc":"2.0","method":"err","params":[],"id":1}`)
+	r, err := http.NewRequest("POST", "/", body)
+	r.Header.Add("Content-Type", "application/json")
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	want := `{"jsonrpc":"2.0","error":{"code":3,"message":"Unavailable"},"id":1}` + "\n"
+	assert.Equal(t, want, w.Body.String())
+}
@@ -0,0 +1,248 @@
+package generpc
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/dwlnetnl/generpc/coder"
+)
+
+// jsonSpecExamples are the examples of the JSON-RPC 2.0 specification, they're
+// the seed corpus of the fuzz targets.
+var jsonSpecExamples = []string{
+	`{"jsonrpc":"2.0","method":"subtract","params":[42,23],"id":1}`,
+	`{"jsonrpc":"2.0","method":"subtract","params":[23,42],"id":2}`,
+	`{"jsonrpc":"2.0","method":"subtract","params":{"subtrahend":23,"minuend":42},"id":3}`,
+	`{"jsonrpc":"2.0","method":"subtract","params":{"minuend":42,"subtrahend":23},"id":4}`,
+	`{"jsonrpc":"2.0","method":"update","params":[1,2,3,4,5]}`,
+	`{"jsonrpc":"2.0","method":"foobar"}`,
+	`{"jsonrpc":"2.0","method":"foobar","id":"1"}`,
+	`{"jsonrpc":"2.0","method":"foobar,"params":"bar","baz]`,
+	`{"jsonrpc":"2.0","method":1,"params":"bar"}`,
+	`[
+		{"jsonrpc":"2.0","method":"sum","params":[1,2,4],"id":"1"},
+		{"jsonrpc":"2.0","method"
+	]`,
+	`[]`,
+	`[1]`,
+	`[1,2,3]`,
+	`[
+		{"jsonrpc":"2.0","method":"sum","params":[1,2,4],"id":"1"},
+		{"jsonrpc":"2.0","method":"notify_hello","params":[7]},
+		{"jsonrpc":"2.0","method":"subtract","params":[42,23],"id":"2"},
+		{"foo":"boo"},
+		{"jsonrpc":"2.0","method":"foo.get","params":{"name":"myself"},"id":"5"},
+		{"jsonrpc":"2.0","method":"get_data","id":"9"}
+	]`,
+	`[
+		{"jsonrpc":"2.0","method":"notify_sum","params":[1,2,4]},
+		{"jsonrpc":"2.0","method":"notify_hello","params":[7]}
+	]`,
+	`{"jsonrpc":"2.0","method":"subtract","params":[42,23],"id":null}`,
+	`{"jsonrpc":"2.0","method":"subtract","params":[42,23],"id":1.0,"extensions":{"idempotencyKey":"a"}}`,
+}
+
+func FuzzJSONReadRequests(f *testing.F) {
+	for _, s := range jsonSpecExamples {
+		f.Add([]byte(s))
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		c := &jsonCoder{httptest.NewRecorder(), bufio.NewReader(bytes.NewReader(data))}
+		reqs, batch, e := c.ReadRequests()
+		if e != nil {
+			if reqs != nil {
+				t.Fatalf("got requests and error %v", e)
+			}
+			return
+		}
+
+		if !batch && len(reqs) != 1 {
+			t.Fatalf("got %d requests, want 1", len(reqs))
+		}
+
+		for _, req := range reqs {
+			if req == nil {
+				continue
+			}
+
+			if req.ID == nil {
+				t.Fatalf("request %q has nil ID pointer", req.Method)
+			}
+
+			switch req.Params.(type) {
+			case nil, []interface{}, map[string]interface{}:
+			default:
+				t.Fatalf("request %q has params of type %T", req.Method, req.Params)
+			}
+		}
+	})
+}
+
+func fuzzServer() *Server {
+	s := NewServer()
+	s.ErrorLog = discardLog
+	s.Register("echo", Method{
+		Func: func(params []interface{}) interface{} { return params },
+	})
+	s.Register("subtract", Method{
+		ParamNames: []string{"minuend", "subtrahend"},
+		Func: func(params []interface{}) interface{} {
+			if len(params) != 2 {
+				return invalidParams
+			}
+			a, _ := params[0].(coder.Number)
+			b, _ := params[1].(coder.Number)
+			if a == nil || b == nil {
+				return invalidParams
+			}
+			x, _ := a.CastFloat64()
+			y, _ := b.CastFloat64()
+			return x - y
+		},
+	})
+	s.Register("error", errorMethod())
+	s.Alias("sum", "echo")
+	s.Alias("update", "echo")
+	return s
+}
+
+func FuzzServeHTTP(f *testing.F) {
+	for _, s := range jsonSpecExamples {
+		f.Add([]byte(s))
+	}
+
+	s := fuzzServer()
+	client := coder.NewClient("application/json")
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		r := httptest.NewRequest("POST", "/", bytes.NewReader(data))
+		r.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, r)
+
+		var resps []*coder.Response
+		if w.Body.Len() > 0 {
+			var err error
+			resps, _, err = client.DecodeResponses(w.Body.Bytes())
+			if err != nil {
+				t.Fatalf("invalid response %q: %v", w.Body, err)
+			}
+		}
+
+		// The expected responses are derived from the raw elements, a single
+		// request is an element as well. Invalid data after the first value
+		// is ignored by the server, so only valid JSON is checked.
+		var elems []json.RawMessage
+		batch := len(data) > 0 && data[0] == '['
+		if batch {
+			if json.Unmarshal(data, &elems) != nil {
+				return
+			}
+			if len(elems) == 0 {
+				elems = append(elems, nil)
+			}
+		} else {
+			var elem json.RawMessage
+			if json.Unmarshal(data, &elem) != nil {
+				return
+			}
+			elems = append(elems, elem)
+		}
+
+		want := make(map[string]int)       // responses by id
+		wantErrors := make(map[string]int) // error responses by id
+		for _, elem := range elems {
+			id, invalid := fuzzElement(elem)
+			if id == "" && invalid {
+				id = "null"
+			}
+			if id == "" {
+				continue
+			}
+			if invalid && !batch {
+				// The ID of a single invalid request isn't used.
+				id = "null"
+			}
+
+			want[id]++
+			if invalid {
+				wantErrors[id]++
+			}
+		}
+
+		got := make(map[string]int)
+		for _, resp := range resps {
+			id := fuzzID(*resp.ID)
+			got[id]++
+			if resp.Error != nil {
+				wantErrors[id]--
+			}
+		}
+
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("got responses %v, want %v: %q", got, want, w.Body)
+		}
+		for id, n := range wantErrors {
+			if n > 0 {
+				t.Fatalf("got %d responses without error for invalid id %s: %q", n, id, w.Body)
+			}
+		}
+	})
+}
+
+// fuzzElement returns the normalized ID of the request object elem, it's
+// empty for a notification or if the ID is invalid. Invalid reports whether
+// the object is invalid. Like the server, members are
+// matched case-insensitively and null is the zero value of a member.
+func fuzzElement(elem json.RawMessage) (id string, invalid bool) {
+	var jr struct {
+		V json.RawMessage `json:"jsonrpc"`
+		M json.RawMessage `json:"method"`
+		P json.RawMessage `json:"params"`
+		I json.RawMessage `json:"id"`
+		X json.RawMessage `json:"extensions"`
+	}
+	if elem == nil || json.Unmarshal(elem, &jr) != nil {
+		return "", true
+	}
+
+	if jr.I != nil {
+		switch jr.I[0] {
+		case '{', '[', 't', 'f':
+			invalid = true
+		default:
+			id = fuzzID(coder.RequestID(jr.I))
+		}
+	}
+
+	var v string
+	if json.Unmarshal(jr.V, &v) != nil || v != jsonrpcVersion {
+		invalid = true
+	}
+	if jr.M != nil && json.Unmarshal(jr.M, &v) != nil {
+		invalid = true
+	}
+	if jr.P != nil && jr.P[0] != '[' && jr.P[0] != '{' && jr.P[0] != 'n' {
+		invalid = true
+	}
+	var x map[string]json.RawMessage
+	if jr.X != nil && json.Unmarshal(jr.X, &x) != nil {
+		invalid = true
+	}
+
+	return id, invalid
+}
+
+// fuzzID returns the normalized ID id, null IDs are "null".
+func fuzzID(id coder.RequestID) string {
+	if id == nil || string(id) == "null" {
+		return "null"
+	}
+
+	return new(jsonCoder).NormalizeID(id)
+}
@@ -0,0 +1,392 @@
+// Package generpctest provides utilities for testing RPC methods in-process.
+//
+// A Harness calls methods on a handler (usually a *generpc.Server) via
+// ServeHTTP. Requests are encoded and responses decoded with the client codec
+// of a coder (see coder.ClientCodec), so the same test can be run for every
+// registered coder with ForEachCoder:
+//
+//	func TestSubtract(t *testing.T) {
+//		s := generpc.NewServer()
+//		s.Register("subtract", subtractMethod)
+//
+//		generpctest.ForEachCoder(t, s, func(t *testing.T, h *generpctest.Harness) {
+//			h.Call("subtract", 42, 23).AssertResult(19)
+//			h.Call("subtract", "a", 1).AssertError(-32602)
+//		})
+//	}
+//
+// Results are compared regardless of the coder: numbers are compared by their
+// value and values are compared by their JSON representation, see Normalize.
+//
+// Results can be compared with golden files in testdata with
+// Result.AssertGolden. Run the tests with -generpctest.update to update the
+// golden files.
+package generpctest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/dwlnetnl/generpc/coder"
+)
+
+var update = flag.Bool("generpctest.update", false, "update golden files")
+
+// Harness calls methods on a handler via ServeHTTP with a particular media
+// type. Failures are reported to T.
+type Harness struct {
+	T         testing.TB
+	Handler   http.Handler
+	MediaType string
+
+	// Header contains additional HTTP headers of each request, for example
+	// Authorization.
+	Header http.Header
+
+	// Context is optionally the context of each HTTP request.
+	Context context.Context
+
+	codec  coder.ClientCodec
+	nextID int
+}
+
+// New returns a harness that calls methods on h with the coder for
+// mediaType. It fails the test if there is no client codec for mediaType.
+func New(t testing.TB, h http.Handler, mediaType string) *Harness {
+	t.Helper()
+
+	c := coder.NewClient(mediaType)
+	if c == nil {
+		t.Fatalf("generpctest: no client codec for media type %q", mediaType)
+	}
+
+	return &Harness{T: t, Handler: h, MediaType: mediaType, Header: make(http.Header), codec: c}
+}
+
+// ForEachCoder runs f as subtest for each registered coder that has a client
+// codec, subtests for the other coders are skipped.
+func ForEachCoder(t *testing.T, h http.Handler, f func(t *testing.T, h *Harness)) {
+	t.Helper()
+
+	for _, typ := range coder.MediaTypes() {
+		typ := typ
+		t.Run(typ, func(t *testing.T) {
+			if coder.NewClient(typ) == nil {
+				t.Skipf("no client codec for media type %q", typ)
+			}
+
+			f(t, New(t, h, typ))
+		})
+	}
+}
+
+// Request describes a request or notification in a batch, see Harness.Batch.
+type Request struct {
+	Method       string
+	Params       interface{} // []interface{} or map[string]interface{}
+	Notification bool
+}
+
+// Params returns by-position params.
+func Params(params ...interface{}) []interface{} {
+	if params == nil {
+		return []interface{}{}
+	}
+
+	return params
+}
+
+// Call calls the method with by-position params. Use CallNamed for by-name
+// params.
+func (h *Harness) Call(method string, params ...interface{}) *Result {
+	h.T.Helper()
+	return h.call(method, Params(params...))
+}
+
+// CallNamed calls the method with by-name params.
+func (h *Harness) CallNamed(method string, params map[string]interface{}) *Result {
+	h.T.Helper()
+	return h.call(method, params)
+}
+
+func (h *Harness) call(method string, params interface{}) *Result {
+	h.T.Helper()
+
+	resps := h.Do([]Request{{Method: method, Params: params}}, false)
+	if len(resps) != 1 {
+		h.T.Fatalf("generpctest: %s: got %d responses, want 1", method, len(resps))
+	}
+
+	return resps[0]
+}
+
+// Notify sends a notification with by-position params and checks that there
+// is no response.
+func (h *Harness) Notify(method string, params ...interface{}) {
+	h.T.Helper()
+	h.Do([]Request{{Method: method, Params: Params(params...), Notification: true}}, false)
+}
+
+// Batch sends the requests as batch and returns the results of the requests
+// that aren't notifications, in request order.
+func (h *Harness) Batch(reqs ...Request) []*Result {
+	h.T.Helper()
+	return h.Do(reqs, true)
+}
+
+// Do sends the requests, as batch if batch is true, and returns the results of
+// the requests that aren't notifications in request order. It fails the test
+// if the responses don't match the requests.
+func (h *Harness) Do(reqs []Request, batch bool) []*Result {
+	h.T.Helper()
+
+	var (
+		creqs []*coder.Request
+		ids   []coder.RequestID
+	)
+	for _, r := range reqs {
+		var id coder.RequestID
+		if !r.Notification {
+			h.nextID++
+			id = h.codec.NewID(h.nextID)
+			ids = append(ids, id)
+		}
+
+		params := r.Params
+		if params == nil {
+			params = []interface{}{}
+		}
+
+		creqs = append(creqs, &coder.Request{Method: r.Method, Params: params, ID: &id})
+	}
+
+	body, err := h.codec.EncodeRequests(creqs, batch)
+	if err != nil {
+		h.T.Fatalf("generpctest: encode requests: %v", err)
+	}
+
+	w := h.Post(body)
+	if len(ids) == 0 {
+		if w.Body.Len() != 0 {
+			h.T.Errorf("generpctest: notifications got response: %s", w.Body)
+		}
+		return nil
+	}
+
+	resps, gotBatch, err := h.codec.DecodeResponses(w.Body.Bytes())
+	if err != nil {
+		h.T.Fatalf("generpctest: decode responses: %v\n%s", err, w.Body)
+	}
+
+	if gotBatch != batch {
+		h.T.Fatalf("generpctest: got batch %t, want %t", gotBatch, batch)
+	}
+
+	byID := make(map[string]*coder.Response, len(resps))
+	for _, r := range resps {
+		if r.ID == nil || *r.ID == nil {
+			h.T.Fatalf("generpctest: response without id: %+v", r)
+		}
+		byID[string(*r.ID)] = r
+	}
+
+	if len(byID) != len(ids) {
+		h.T.Fatalf("generpctest: got %d responses, want %d", len(byID), len(ids))
+	}
+
+	results := make([]*Result, len(ids))
+	for i, id := range ids {
+		r, ok := byID[string(id)]
+		if !ok {
+			h.T.Fatalf("generpctest: no response for id %s", id)
+		}
+		results[i] = &Result{t: h.T, Response: r}
+	}
+
+	return results
+}
+
+// Post posts the raw body to the handler and returns the recorded response.
+func (h *Harness) Post(body []byte) *httptest.ResponseRecorder {
+	h.T.Helper()
+
+	ctx := h.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	r, err := http.NewRequestWithContext(ctx, "POST", "/", bytes.NewReader(body))
+	if err != nil {
+		h.T.Fatalf("generpctest: %v", err)
+	}
+
+	for k, v := range h.Header {
+		r.Header[k] = v
+	}
+	r.Header.Set("Content-Type", h.MediaType)
+
+	w := httptest.NewRecorder()
+	h.Handler.ServeHTTP(w, r)
+	return w
+}
+
+// Result is the response of a call.
+type Result struct {
+	t        testing.TB
+	Response *coder.Response
+}
+
+// Value returns the normalized result, see Normalize.
+func (r *Result) Value() interface{} {
+	return Normalize(r.Response.Result)
+}
+
+// Error returns the error or nil if the call succeeded.
+func (r *Result) Error() *coder.Error {
+	return r.Response.Error
+}
+
+// Decode decodes the result into v via its JSON representation.
+func (r *Result) Decode(v interface{}) error {
+	b, err := json.Marshal(r.Value())
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(b, v)
+}
+
+// AssertResult checks that the call succeeded with the given result, compared
+// after normalization.
+func (r *Result) AssertResult(want interface{}) *Result {
+	r.t.Helper()
+
+	if e := r.Response.Error; e != nil {
+		r.t.Errorf("generpctest: got error %v, want result %v", e, want)
+		return r
+	}
+
+	got, w := r.Value(), Normalize(want)
+	if !reflect.DeepEqual(got, w) {
+		r.t.Errorf("generpctest: got result %s, want %s", format(got), format(w))
+	}
+
+	return r
+}
+
+// AssertError checks that the call failed with the given error code.
+func (r *Result) AssertError(code int) *Result {
+	r.t.Helper()
+
+	e := r.Response.Error
+	if e == nil {
+		r.t.Errorf("generpctest: got result %s, want error %d", format(r.Value()), code)
+	} else if e.Code != code {
+		r.t.Errorf("generpctest: got error %v, want error code %d", e, code)
+	}
+
+	return r
+}
+
+// AssertGolden compares the normalized response with the golden file
+// testdata/name.golden. The file contains the result or the error as JSON,
+// so it's the same for every coder.
+func (r *Result) AssertGolden(name string) *Result {
+	r.t.Helper()
+
+	v := map[string]interface{}{"result": r.Value()}
+	if e := r.Response.Error; e != nil {
+		ev := map[string]interface{}{"code": e.Code, "message": e.Message}
+		if e.Data != nil {
+			ev["data"] = Normalize(e.Data)
+		}
+		v = map[string]interface{}{"error": ev}
+	}
+
+	got, err := json.MarshalIndent(v, "", "\t")
+	if err != nil {
+		r.t.Fatalf("generpctest: %v", err)
+	}
+	got = append(got, '\n')
+
+	path := filepath.Join("testdata", name+".golden")
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			r.t.Fatalf("generpctest: %v", err)
+		}
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			r.t.Fatalf("generpctest: %v", err)
+		}
+		return r
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		r.t.Fatalf("generpctest: %v (run with -generpctest.update to create it)", err)
+	}
+
+	if !bytes.Equal(got, want) {
+		r.t.Errorf("generpctest: %s: got\n%s\nwant\n%s", path, got, want)
+	}
+
+	return r
+}
+
+// Normalize returns v as it would be decoded from JSON: numbers (including
+// coder.Number values) are float64 and structs are maps.
+func Normalize(v interface{}) interface{} {
+	b, err := json.Marshal(numbers(v))
+	if err != nil {
+		return fmt.Sprintf("%#v", v)
+	}
+
+	var n interface{}
+	if err := json.Unmarshal(b, &n); err != nil {
+		return fmt.Sprintf("%#v", v)
+	}
+
+	return n
+}
+
+// numbers converts coder.Number values into float64.
+func numbers(v interface{}) interface{} {
+	switch v := v.(type) {
+	case coder.Number:
+		f, _ := v.CastFloat64()
+		return f
+
+	case []interface{}:
+		s := make([]interface{}, len(v))
+		for i, e := range v {
+			s[i] = numbers(e)
+		}
+		return s
+
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			m[k] = numbers(e)
+		}
+		return m
+	}
+
+	return v
+}
+
+func format(v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%#v", v)
+	}
+
+	return string(b)
+}
@@ -0,0 +1,113 @@
+package generpctest
+
+import (
+	"fmt"
+	"sync/atomic"
+	"testing"
+
+	"github.com/dwlnetnl/generpc"
+	"github.com/dwlnetnl/generpc/coder"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testServer(notified *int64) *generpc.Server {
+	s := generpc.NewServer()
+	s.Register("subtract", generpc.Method{
+		ParamNames: []string{"minuend", "subtrahend"},
+		Func: func(params []interface{}) interface{} {
+			a, ok1 := params[0].(coder.Number)
+			b, ok2 := params[1].(coder.Number)
+			if !ok1 || !ok2 {
+				return coder.Error{Code: -32602, Message: "Invalid params"}
+			}
+			x, _ := a.CastFloat64()
+			y, _ := b.CastFloat64()
+			return x - y
+		},
+	})
+	s.Register("user", generpc.Method{
+		Func: func(params []interface{}) interface{} {
+			return struct {
+				Name  string   `json:"name"`
+				Roles []string `json:"roles"`
+			}{"alice", []string{"admin"}}
+		},
+	})
+	s.Register("notify", generpc.Method{
+		Func: func(params []interface{}) interface{} {
+			atomic.AddInt64(notified, 1)
+			return nil
+		},
+	})
+	return s
+}
+
+func TestHarness(t *testing.T) {
+	var notified int64
+	s := testServer(&notified)
+
+	ForEachCoder(t, s, func(t *testing.T, h *Harness) {
+		h.Call("subtract", 42, 23).AssertResult(19)
+		h.Call("subtract", 42.5, 0.5).AssertResult(42)
+		h.CallNamed("subtract", map[string]interface{}{"subtrahend": 23, "minuend": 42}).AssertResult(19)
+		h.Call("subtract", "a", 1).AssertError(-32602)
+		h.Call("missing").AssertError(-32601)
+		h.Call("user").AssertResult(map[string]interface{}{"name": "alice", "roles": []string{"admin"}})
+
+		var u struct{ Name string }
+		require.NoError(t, h.Call("user").Decode(&u))
+		assert.Equal(t, "alice", u.Name)
+
+		h.Notify("notify")
+		assert.EqualValues(t, 1, atomic.LoadInt64(&notified))
+
+		results := h.Batch(
+			Request{Method: "subtract", Params: Params(42, 23)},
+			Request{Method: "notify", Notification: true},
+			Request{Method: "missing"},
+		)
+		require.Len(t, results, 2)
+		results[0].AssertResult(19)
+		results[1].AssertError(-32601)
+		assert.EqualValues(t, 2, atomic.LoadInt64(&notified))
+
+		h.Call("user").AssertGolden("user")
+		h.Call("subtract", "a", 1).AssertGolden("subtract_error")
+	})
+}
+
+type recordT struct {
+	testing.TB
+	errors []string
+}
+
+func (t *recordT) Helper() {}
+
+func (t *recordT) Errorf(format string, args ...interface{}) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+func TestResult_failures(t *testing.T) {
+	var notified int64
+	rt := &recordT{TB: t}
+	h := New(rt, testServer(&notified), "application/json")
+
+	h.Call("subtract", 42, 23).AssertResult(20)
+	h.Call("subtract", 42, 23).AssertError(-32602)
+	h.Call("missing").AssertResult(nil)
+	h.Call("missing").AssertError(-32602)
+
+	assert.Equal(t, []string{
+		"generpctest: got result 19, want 20",
+		"generpctest: got result 19, want error -32602",
+		"generpctest: got error Method not found (-32601), want result <nil>",
+		"generpctest: got error Method not found (-32601), want error code -32602",
+	}, rt.errors)
+}
+
+func TestNormalize(t *testing.T) {
+	assert.Equal(t, 1.0, Normalize(1))
+	assert.Equal(t, []interface{}{1.0, "a"}, Normalize([]interface{}{1, "a"}))
+	assert.Equal(t, map[string]interface{}{"A": 1.5}, Normalize(struct{ A float32 }{1.5}))
+}
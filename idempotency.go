@@ -0,0 +1,180 @@
+package generpc
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/dwlnetnl/generpc/coder"
+)
+
+const (
+	// IdempotencyHeader is the HTTP header that contains the idempotency key.
+	// The key applies to every request in the HTTP request, so batches should
+	// use IdempotencyExtension instead.
+	IdempotencyHeader = "Idempotency-Key"
+
+	// IdempotencyExtension is the request extension that contains the
+	// idempotency key, see coder.Request. It takes precedence over the header.
+	IdempotencyExtension = "idempotencyKey"
+)
+
+// IdempotencyStore stores the responses of calls with an idempotency key.
+// Implementations should be safe for concurrent use.
+type IdempotencyStore interface {
+	Get(key string) (*IdempotencyRecord, bool)
+	Put(key string, r *IdempotencyRecord)
+}
+
+// IdempotencyRecord is the stored response of a call with an idempotency key.
+type IdempotencyRecord struct {
+	ParamsHash string // hash of the canonical params of the call
+	Response   *coder.Response
+}
+
+// NewMemoryStore returns an in-memory IdempotencyStore that holds up to size
+// responses (zero means unlimited) for the duration of ttl (zero means
+// forever). The least recently used responses are evicted first, expired
+// responses are swept on insert.
+func NewMemoryStore(size int, ttl time.Duration) IdempotencyStore {
+	return memoryStore{newLRUCache(size), ttl}
+}
+
+type memoryStore struct {
+	c   *lruCache
+	ttl time.Duration
+}
+
+func (s memoryStore) Get(key string) (*IdempotencyRecord, bool) {
+	v, ok := s.c.get(key)
+	if !ok {
+		return nil, false
+	}
+
+	return v.(*IdempotencyRecord), true
+}
+
+func (s memoryStore) Put(key string, r *IdempotencyRecord) {
+	s.c.put(key, r, s.ttl)
+}
+
+// Idempotency returns an interceptor that caches the responses of calls with
+// an idempotency key, see IdempotencyHeader and IdempotencyExtension. Retried
+// calls with the same key, method and principal receive the stored response
+// instead of invoking the method again. Concurrent retries wait for the first
+// call to finish, a retry whose context is done while waiting receives the
+// timeout or cancellation error. Server errors (like timeouts) and internal
+// errors aren't stored, so these calls can be retried.
+//
+// A key that is reused with different params is rejected with an invalid
+// params error. Unauthenticated callers share one namespace, so their keys
+// should be unpredictable (like random UUIDs).
+func Idempotency(store IdempotencyStore) Interceptor {
+	var mu sync.Mutex
+	inflight := make(map[string]chan struct{})
+
+	return func(ctx context.Context, req *coder.Request, next Invoker) *coder.Response {
+		key := idempotencyKey(ctx, req)
+		if key == "" {
+			return next(ctx, req)
+		}
+
+		hash, err := paramsHash(req.Params)
+		if err != nil {
+			// Params that cannot be hashed bypass the store.
+			return next(ctx, req)
+		}
+
+		start := time.Now()
+		for {
+			if r, ok := store.Get(key); ok {
+				if r.ParamsHash != hash {
+					return keyReused.Response(req)
+				}
+
+				resp := *r.Response
+				resp.ID = req.ID
+				return &resp
+			}
+
+			mu.Lock()
+			wait, busy := inflight[key]
+			if !busy {
+				inflight[key] = make(chan struct{})
+			}
+			mu.Unlock()
+
+			if !busy {
+				break
+			}
+
+			select {
+			case <-wait:
+			case <-ctx.Done():
+				return contextResponse(ctx, req, start)
+			}
+		}
+
+		defer func() {
+			mu.Lock()
+			close(inflight[key])
+			delete(inflight, key)
+			mu.Unlock()
+		}()
+
+		resp := next(ctx, req)
+		if storable(resp) {
+			store.Put(key, &IdempotencyRecord{hash, resp})
+		}
+
+		return resp
+	}
+}
+
+func idempotencyKey(ctx context.Context, req *coder.Request) string {
+	key, _ := req.Extensions[IdempotencyExtension].(string)
+	if key == "" {
+		if r := HTTPRequestFromContext(ctx); r != nil {
+			key = r.Header.Get(IdempotencyHeader)
+		}
+	}
+
+	if key == "" {
+		return ""
+	}
+
+	var principal string
+	if p := PrincipalFromContext(ctx); p != nil {
+		principal = p.Name
+	}
+
+	return principal + "\x00" + req.Method + "\x00" + key
+}
+
+var keyReused = invalidParams.WithString("idempotency key reused with different params")
+
+// paramsHash returns the hash of the canonical encoding of params, see
+// canonical.
+func paramsHash(params interface{}) (string, error) {
+	b, err := json.Marshal(canonical(params))
+	if err != nil {
+		return "", err
+	}
+
+	h := sha256.Sum256(b)
+	return hex.EncodeToString(h[:]), nil
+}
+
+// storable reports if the response can be replayed to retried calls.
+func storable(r *coder.Response) bool {
+	if r.Error == nil {
+		return true
+	}
+
+	code := r.Error.Code
+	isServerError := code <= -32000 && code >= -32099
+	return !isServerError && code != internalError.Code
+}
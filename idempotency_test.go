@@ -0,0 +1,217 @@
+package generpc
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dwlnetnl/generpc/coder"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func counterMethod(n *int64) Method {
+	return Method{
+		Func: func([]interface{}) interface{} {
+			return atomic.AddInt64(n, 1)
+		},
+	}
+}
+
+func TestIdempotency(t *testing.T) {
+	var n int64
+	h := NewServer()
+	h.Register("create", counterMethod(&n))
+	h.Use(Idempotency(NewMemoryStore(0, 0)))
+
+	serve := func(key, body string) string {
+		r, err := http.NewRequest("POST", "/", strings.NewReader(body))
+		require.NoError(t, err)
+		r.Header.Add("Content-Type", "application/json")
+		if key != "" {
+			r.Header.Add(IdempotencyHeader, key)
+		}
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w.Body.String()
+	}
+
+	const body = `{"jsonrpc":"2.0","method":"create","params":[],"id":1}`
+	assert.Equal(t, `{"jsonrpc":"2.0","result":1,"id":1}`+"\n", serve("a", body))
+	assert.Equal(t, `{"jsonrpc":"2.0","result":1,"id":1}`+"\n", serve("a", body))
+	assert.Equal(t, `{"jsonrpc":"2.0","result":2,"id":1}`+"\n", serve("b", body))
+	assert.Equal(t, `{"jsonrpc":"2.0","result":3,"id":1}`+"\n", serve("", body))
+
+	// The stored response gets the ID of the retried request.
+	assert.Equal(t, `{"jsonrpc":"2.0","result":1,"id":"retry"}`+"\n",
+		serve("a", `{"jsonrpc":"2.0","method":"create","params":[],"id":"retry"}`))
+
+	// The extension takes precedence over the header.
+	got := serve("a", `[
+		{"jsonrpc":"2.0","method":"create","params":[],"id":1,"extensions":{"idempotencyKey":"c"}},
+		{"jsonrpc":"2.0","method":"create","params":[],"id":2,"extensions":{"idempotencyKey":"c"}}
+	]`)
+	assert.JSONEq(t, `[
+		{"jsonrpc":"2.0","result":4,"id":1},
+		{"jsonrpc":"2.0","result":4,"id":2}
+	]`, got)
+}
+
+func TestIdempotency_principal(t *testing.T) {
+	var n int64
+	h := NewServer()
+	h.Authenticator = tokenAuth()
+	h.Register("create", counterMethod(&n))
+	h.Use(Idempotency(NewMemoryStore(0, 0)))
+
+	const body = `{"jsonrpc":"2.0","method":"create","params":[],"id":1,"extensions":{"idempotencyKey":"a"}}`
+	assert.Equal(t, `{"jsonrpc":"2.0","result":1,"id":1}`+"\n", serveAuth(t, h, "Bearer admin", body))
+	assert.Equal(t, `{"jsonrpc":"2.0","result":2,"id":1}`+"\n", serveAuth(t, h, "Bearer reader", body))
+	assert.Equal(t, `{"jsonrpc":"2.0","result":1,"id":1}`+"\n", serveAuth(t, h, "Bearer admin", body))
+}
+
+func TestIdempotency_params(t *testing.T) {
+	var n int64
+	h := NewServer()
+	h.Register("create", counterMethod(&n))
+	h.Use(Idempotency(NewMemoryStore(0, 0)))
+
+	const body = `{"jsonrpc":"2.0","method":"create","params":{"n":1.0},"id":1,"extensions":{"idempotencyKey":"a"}}`
+	assert.Equal(t, `{"jsonrpc":"2.0","result":1,"id":1}`+"\n", serveJSON(t, h, body).Body.String())
+
+	// Equal params are retries.
+	assert.Equal(t, `{"jsonrpc":"2.0","result":1,"id":1}`+"\n",
+		serveJSON(t, h, strings.Replace(body, "1.0", "1", 1)).Body.String())
+
+	want := `{"jsonrpc":"2.0","error":{"code":-32602,"message":"Invalid params","data":"idempotency key reused with different params"},"id":1}` + "\n"
+	assert.Equal(t, want, serveJSON(t, h, strings.Replace(body, "1.0", "2", 1)).Body.String())
+	assert.Equal(t, int64(1), atomic.LoadInt64(&n))
+}
+
+func TestIdempotency_serverError(t *testing.T) {
+	var n int64
+	h := NewServer()
+	h.Register("create", counterMethod(&n))
+	h.AddLimit(Limit{Method: "create", Rate: 0.001})
+	h.Use(Idempotency(NewMemoryStore(0, 0)))
+
+	const body = `{"jsonrpc":"2.0","method":"create","params":[],"id":1,"extensions":{"idempotencyKey":"a"}}`
+	assert.Equal(t, `{"jsonrpc":"2.0","result":1,"id":1}`+"\n", serveJSON(t, h, body).Body.String())
+	assert.Equal(t, `{"jsonrpc":"2.0","result":1,"id":1}`+"\n", serveJSON(t, h, body).Body.String())
+
+	// Limited calls aren't stored, so they can be retried.
+	body2 := strings.Replace(body, `"a"`, `"b"`, 1)
+	assert.Contains(t, serveJSON(t, h, body2).Body.String(), `"code":-32004`)
+	assert.Contains(t, serveJSON(t, h, body2).Body.String(), `"code":-32004`)
+}
+
+func TestIdempotency_wait(t *testing.T) {
+	var n int64
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	h := NewServer()
+	h.BatchTimeout = 10 * time.Millisecond
+	h.Register("create", Method{
+		FuncContext: func(ctx context.Context, params []interface{}) interface{} {
+			if atomic.AddInt64(&n, 1) == 1 {
+				close(started)
+			}
+			<-release
+			return atomic.LoadInt64(&n)
+		},
+	})
+	h.Use(Idempotency(NewMemoryStore(0, 0)))
+
+	const body = `{"jsonrpc":"2.0","method":"create","params":[],"id":1,"extensions":{"idempotencyKey":"a"}}`
+	done := make(chan string)
+	go func() {
+		done <- serveJSON(t, h, body).Body.String()
+	}()
+	<-started
+
+	// The retry times out while waiting for the first call.
+	var resps []testResponse
+	require.NoError(t, json.Unmarshal(serveJSON(t, h, "["+body+"]").Body.Bytes(), &resps))
+	require.Len(t, resps, 1)
+	require.NotNil(t, resps[0].Error)
+	assert.Equal(t, TimeoutErrorCode, resps[0].Error.Code)
+	assert.Equal(t, "batch", resps[0].Error.Data["scope"])
+
+	close(release)
+	assert.Equal(t, `{"jsonrpc":"2.0","result":1,"id":1}`+"\n", <-done)
+	assert.Equal(t, int64(1), atomic.LoadInt64(&n))
+}
+
+func TestIdempotency_unhashable(t *testing.T) {
+	var n int
+	next := func(ctx context.Context, req *coder.Request) *coder.Response {
+		n++
+		return coder.NewResult(req, n)
+	}
+
+	id := coder.RequestID("1")
+	req := &coder.Request{
+		Method:     "create",
+		Params:     []interface{}{math.Inf(1)},
+		ID:         &id,
+		Extensions: map[string]interface{}{IdempotencyExtension: "a"},
+	}
+
+	// Params that cannot be hashed bypass the store.
+	i := Idempotency(NewMemoryStore(0, 0))
+	assert.Equal(t, 1, i(context.Background(), req, next).Result)
+	assert.Equal(t, 2, i(context.Background(), req, next).Result)
+}
+
+func TestMemoryStore(t *testing.T) {
+	s := NewMemoryStore(2, time.Hour)
+	a := &IdempotencyRecord{Response: &coder.Response{Result: 1}}
+	b := &IdempotencyRecord{Response: &coder.Response{Result: 2}}
+	c := &IdempotencyRecord{Response: &coder.Response{Result: 3}}
+	s.Put("a", a)
+	s.Put("b", b)
+
+	got, ok := s.Get("a")
+	require.True(t, ok)
+	assert.Same(t, a, got)
+
+	// b is least recently used.
+	s.Put("c", c)
+	_, ok = s.Get("b")
+	assert.False(t, ok)
+	_, ok = s.Get("a")
+	assert.True(t, ok)
+	_, ok = s.Get("c")
+	assert.True(t, ok)
+
+	s = NewMemoryStore(0, time.Nanosecond)
+	s.Put("a", a)
+	time.Sleep(time.Millisecond)
+	_, ok = s.Get("a")
+	assert.False(t, ok)
+}
+
+func TestMemoryStore_sweep(t *testing.T) {
+	s := NewMemoryStore(0, time.Millisecond)
+	s.Put("a", &IdempotencyRecord{Response: &coder.Response{Result: 1}})
+	time.Sleep(2 * time.Millisecond)
+
+	// Expired entries are swept on insert without being read.
+	c := s.(memoryStore).c
+	for i := 0; i < minSweep; i++ {
+		c.put(strconv.Itoa(i), i, time.Hour)
+	}
+	c.mu.Lock()
+	_, ok := c.m["a"]
+	c.mu.Unlock()
+	assert.False(t, ok)
+}
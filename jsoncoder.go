@@ -4,13 +4,17 @@ import (
 	"bufio"
 	"bytes"
 	"encoding/json"
+	"errors"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/dwlnetnl/generpc/coder"
 )
 
 func init() {
 	coder.Register("application/json", jsonCoderFor)
+	coder.RegisterClient("application/json", jsonClientCodec{})
 }
 
 type jsonCoder struct {
@@ -78,16 +82,19 @@ func (c *jsonCoder) jsonReadBatch() (reqs []*coder.Request, e *coder.Error) {
 		d := json.NewDecoder(bytes.NewReader(raw))
 		d.UseNumber()
 
-		err := d.Decode(&jr)
-		if err != nil {
-			// Error during parsing request, nil requests will be ignored.
-			reqs = append(reqs, nil)
-			continue
+		// Invalid objects in batch are answered with the error and their ID
+		// if it's valid. The decoder sets the members it can decode, so the
+		// ID is known even if another member has the wrong type.
+		var r *coder.Request
+		var e *coder.Error
+		if err := d.Decode(&jr); err != nil {
+			e = &coder.InvalidRequest
+		} else {
+			r, e = jr.Request()
 		}
-
-		r, e := jr.Request()
 		if e != nil {
-			// Ignore malformed objects in batch.
+			id, _ := jr.id()
+			reqs = append(reqs, &coder.Request{ID: &id, Error: e})
 			continue
 		}
 
@@ -125,40 +132,117 @@ func (c *jsonCoder) WriteException(id *coder.RequestID, err error) error {
 	return json.NewEncoder(c).Encode(jsonResponseFor(r))
 }
 
+// NormalizeID implements coder.IDNormalizer. Numbers are equal if their values
+// are equal, so 1 and 1.0 are the same ID, but never equal to a string.
+func (c *jsonCoder) NormalizeID(id coder.RequestID) string {
+	d := json.NewDecoder(bytes.NewReader(id))
+	d.UseNumber()
+
+	var v interface{}
+	if d.Decode(&v) != nil {
+		return "raw:" + string(id)
+	}
+
+	switch v := v.(type) {
+	case string:
+		return "string:" + v
+
+	case json.Number:
+		if n, ok := normalizeNumber(v.String()); ok {
+			return "number:" + n
+		}
+	}
+
+	return "raw:" + string(id)
+}
+
+// maxIDExponent bounds the exponent of a normalized number ID.
+const maxIDExponent = 1 << 40
+
+// normalizeNumber returns the JSON number s as significant digits and
+// exponent, like "15e-1" for "1.50". It works on the decimal text, so the
+// cost doesn't depend on the value of the exponent.
+func normalizeNumber(s string) (string, bool) {
+	sign := ""
+	if strings.HasPrefix(s, "-") {
+		sign, s = "-", s[1:]
+	}
+
+	mant, exp := s, int64(0)
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		e, err := strconv.ParseInt(strings.TrimPrefix(s[i+1:], "+"), 10, 64)
+		if err != nil || e > maxIDExponent || e < -maxIDExponent {
+			return "", false
+		}
+		mant, exp = s[:i], e
+	}
+
+	digits := mant
+	if i := strings.IndexByte(mant, '.'); i >= 0 {
+		digits = mant[:i] + mant[i+1:]
+		exp -= int64(len(mant) - i - 1)
+	}
+
+	digits = strings.TrimLeft(digits, "0")
+	if digits == "" {
+		return "0", true
+	}
+
+	n := len(digits)
+	digits = strings.TrimRight(digits, "0")
+	exp += int64(n - len(digits))
+
+	return sign + digits + "e" + strconv.FormatInt(exp, 10), true
+}
+
+// jsonRequest is the wire format of a request. Request extensions (see
+// coder.Request) are represented by the "extensions" object member.
 type jsonRequest struct {
-	V string          `json:"jsonrpc"`
-	M string          `json:"method"`
-	P interface{}     `json:"params,omitempty"`
-	I json.RawMessage `json:"id,omitempty"`
+	V string                 `json:"jsonrpc"`
+	M string                 `json:"method"`
+	P interface{}            `json:"params,omitempty"`
+	I json.RawMessage        `json:"id,omitempty"`
+	X map[string]interface{} `json:"extensions,omitempty"`
 }
 
 const jsonrpcVersion = "2.0"
 
-func (jr jsonRequest) Request() (*coder.Request, *coder.Error) {
-	var id coder.RequestID
+// id returns the ID of the request, it's nil for a notification. It reports
+// whether the ID has a valid type, the ID is nil otherwise.
+func (jr jsonRequest) id() (coder.RequestID, bool) {
+	if jr.I == nil {
+		return nil, true
+	}
 
-	if jr.V != jsonrpcVersion {
-		return nil, coder.InvalidRequest.WithString("invalid version")
+	d := json.NewDecoder(bytes.NewReader(jr.I))
+	d.UseNumber()
+
+	var v interface{}
+	if d.Decode(&v) != nil {
+		return nil, false
 	}
 
-	if jr.I != nil {
-		var v interface{}
+	switch v.(type) {
+	case string, json.Number, nil:
+		return coder.RequestID(jr.I), true
+	}
 
-		err := json.Unmarshal(jr.I, &v)
-		if err != nil {
-			return nil, coder.ParseError.WithError(err)
-		}
+	return nil, false
+}
 
-		switch v.(type) {
-		case string, float64, nil: // json numbers are unmarshaled as float64
-		default:
-			return nil, coder.InvalidRequest.WithString("invalid id type")
-		}
+func (jr jsonRequest) Request() (*coder.Request, *coder.Error) {
+	if jr.V != jsonrpcVersion {
+		return nil, coder.InvalidRequest.WithString("invalid version")
+	}
 
-		id = coder.RequestID(jr.I)
+	id, ok := jr.id()
+	if !ok {
+		return nil, coder.InvalidRequest.WithString("invalid id type")
 	}
 
 	switch p := jr.P.(type) {
+	case nil:
+
 	case []interface{}:
 		for i, v := range p {
 			if v, ok := v.(json.Number); ok {
@@ -172,22 +256,36 @@ func (jr jsonRequest) Request() (*coder.Request, *coder.Error) {
 				p[k] = jsonNumber{v}
 			}
 		}
+
+	default:
+		// Params must be a structured value (JSON-RPC 2.0 section 4), so
+		// this is an invalid Request object rather than invalid params.
+		return nil, coder.InvalidRequest.WithString("invalid params type")
 	}
 
-	return &coder.Request{Method: jr.M, Params: jr.P, ID: &id}, nil
+	for k, v := range jr.X {
+		if v, ok := v.(json.Number); ok {
+			jr.X[k] = jsonNumber{v}
+		}
+	}
+
+	return &coder.Request{Method: jr.M, Params: jr.P, ID: &id, Extensions: jr.X}, nil
 }
 
+// jsonResponse is the wire format of a response. Response extensions (see
+// coder.Response) are represented by the "extensions" object member.
 type jsonResponse struct {
-	V string           `json:"jsonrpc"`
-	R *interface{}     `json:"result,omitempty"`
-	E *jsonError       `json:"error,omitempty"`
-	I *json.RawMessage `json:"id,omitempty"`
+	V string                 `json:"jsonrpc"`
+	R *interface{}           `json:"result,omitempty"`
+	E *jsonError             `json:"error,omitempty"`
+	I *json.RawMessage       `json:"id,omitempty"`
+	X map[string]interface{} `json:"extensions,omitempty"`
 }
 
 var jsonNull = json.RawMessage([]byte("null"))
 
 func jsonResponseFor(r coder.Response) jsonResponse {
-	jr := jsonResponse{V: jsonrpcVersion, I: &jsonNull}
+	jr := jsonResponse{V: jsonrpcVersion, I: &jsonNull, X: r.Extensions}
 
 	if r.ID != nil {
 		rm := json.RawMessage(*r.ID)
@@ -236,3 +334,129 @@ func (n jsonNumber) CastUint() (uint, bool) {
 
 	return uint(v), ok
 }
+
+// jsonClientCodec implements coder.ClientCodec for the JSON wire format.
+type jsonClientCodec struct{}
+
+func (jsonClientCodec) NewID(n int) coder.RequestID {
+	return coder.RequestID(strconv.Itoa(n))
+}
+
+func (jsonClientCodec) EncodeRequests(s []*coder.Request, batch bool) ([]byte, error) {
+	js := make([]jsonRequest, len(s))
+	for i, r := range s {
+		js[i] = jsonRequest{V: jsonrpcVersion, M: r.Method, P: r.Params, X: r.Extensions}
+		if r.ID != nil && *r.ID != nil {
+			js[i].I = json.RawMessage(*r.ID)
+		}
+	}
+
+	if !batch {
+		if len(js) != 1 {
+			return nil, errors.New("generpc: single request expected")
+		}
+
+		return json.Marshal(js[0])
+	}
+
+	return json.Marshal(js)
+}
+
+func (jsonClientCodec) DecodeResponses(data []byte) (s []*coder.Response, batch bool, err error) {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '[' {
+		batch = true
+
+		var raw []json.RawMessage
+		if err := json.Unmarshal(data, &raw); err != nil {
+			return nil, true, err
+		}
+
+		for _, data := range raw {
+			r, err := jsonDecodeResponse(data)
+			if err != nil {
+				return nil, true, err
+			}
+			s = append(s, r)
+		}
+
+		return s, true, nil
+	}
+
+	r, err := jsonDecodeResponse(data)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return []*coder.Response{r}, false, nil
+}
+
+func jsonDecodeResponse(data []byte) (*coder.Response, error) {
+	var jr struct {
+		V string                 `json:"jsonrpc"`
+		R json.RawMessage        `json:"result"`
+		E *jsonError             `json:"error"`
+		I json.RawMessage        `json:"id"`
+		X map[string]interface{} `json:"extensions"`
+	}
+
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+	if err := d.Decode(&jr); err != nil {
+		return nil, err
+	}
+
+	if jr.V != jsonrpcVersion {
+		return nil, errors.New("generpc: invalid version")
+	}
+
+	if (jr.R == nil) == (jr.E == nil) {
+		return nil, errors.New("generpc: response should have either result or error")
+	}
+
+	if jr.I == nil {
+		return nil, errors.New("generpc: response without id")
+	}
+
+	r := &coder.Response{Extensions: jsonNumbers(jr.X).(map[string]interface{})}
+
+	id := coder.RequestID(jr.I)
+	if string(jr.I) == "null" {
+		id = nil
+	}
+	r.ID = &id
+
+	if jr.E != nil {
+		r.Error = &coder.Error{Code: jr.E.C, Message: jr.E.M, Data: jsonNumbers(jr.E.D)}
+		return r, nil
+	}
+
+	d = json.NewDecoder(bytes.NewReader(jr.R))
+	d.UseNumber()
+	if err := d.Decode(&r.Result); err != nil {
+		return nil, err
+	}
+
+	r.Result = jsonNumbers(r.Result)
+	return r, nil
+}
+
+// jsonNumbers converts json.Number values in v into jsonNumber.
+func jsonNumbers(v interface{}) interface{} {
+	switch v := v.(type) {
+	case json.Number:
+		return jsonNumber{v}
+
+	case []interface{}:
+		for i, e := range v {
+			v[i] = jsonNumbers(e)
+		}
+
+	case map[string]interface{}:
+		for k, e := range v {
+			v[k] = jsonNumbers(e)
+		}
+	}
+
+	return v
+}
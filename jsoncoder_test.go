@@ -17,8 +17,8 @@ import (
 
 func subtractMethod() Method {
 	return Method{
-		[]string{"minuend", "subtrahend"},
-		func(params []interface{}) interface{} {
+		ParamNames: []string{"minuend", "subtrahend"},
+		Func: func(params []interface{}) interface{} {
 			// This implementation is unsafe because it doesn't validate the input
 			// types. It could panic if params don't has 2 values or aren't numbers.
 			p0, _ := params[0].(coder.Number).CastInt()
@@ -30,8 +30,8 @@ func subtractMethod() Method {
 
 func errorMethod() Method {
 	return Method{
-		[]string{},
-		func(params []interface{}) interface{} {
+		ParamNames: []string{},
+		Func: func(params []interface{}) interface{} {
 			return coder.Error{Code: 1, Message: "Test error"}
 		},
 	}
@@ -491,6 +491,7 @@ func (s *jsonCoderBatchTestSuite) TestRequests() {
 	err = json.Compact(want, []byte(`[
 		{"jsonrpc":"2.0","result":19,"id":1},
 		{"jsonrpc":"2.0","result":19,"id":2},
+		{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid Request","data":"invalid version"},"id":null},
 		{"jsonrpc":"2.0","result":19,"id":3}
 	]`))
 
@@ -524,6 +525,66 @@ func Test_jsonCoder_WriteException(t *testing.T) {
 	assert.Equal(t, want, r.Body.String())
 }
 
+func Test_jsonCoder_NormalizeID(t *testing.T) {
+	c := &jsonCoder{}
+
+	cases := []struct {
+		a, b  string
+		equal bool
+	}{
+		{`1`, `1`, true},
+		{`1`, `1.0`, true},
+		{`1`, `1e0`, true},
+		{`1`, `"1"`, false},
+		{`"1"`, `"\u0031"`, true},
+		{`1`, `2`, false},
+		{`null`, `null`, true},
+		{`150`, `1.5e2`, true},
+		{`0.015`, `15E-3`, true},
+		{`0`, `-0.0e5`, true},
+		{`-1`, `1`, false},
+		{`1e1000000`, `10e999999`, true},
+		{`1e1000000`, `1e1000001`, false},
+	}
+
+	for _, tc := range cases {
+		a := c.NormalizeID(coder.RequestID(tc.a))
+		b := c.NormalizeID(coder.RequestID(tc.b))
+		assert.Equal(t, tc.equal, a == b, "%s == %s", tc.a, tc.b)
+	}
+
+	// The key doesn't expand the exponent.
+	assert.Equal(t, "number:1e1000000", c.NormalizeID(coder.RequestID(`1e1000000`)))
+	assert.Equal(t, "raw:1e99999999999999999999", c.NormalizeID(coder.RequestID(`1e99999999999999999999`)))
+}
+
+func Test_jsonRequest_extensions(t *testing.T) {
+	var jr jsonRequest
+
+	d := json.NewDecoder(strings.NewReader(`{"jsonrpc":"2.0","method":"m","extensions":{"n":1,"s":"v"}}`))
+	d.UseNumber()
+	assert.NoError(t, d.Decode(&jr))
+
+	r, e := jr.Request()
+	assert.Nil(t, e)
+	assert.Equal(t, map[string]interface{}{"n": jsonNumber{"1"}, "s": "v"}, r.Extensions)
+}
+
+func Test_jsonRequest_params(t *testing.T) {
+	for _, params := range []string{`"bar"`, `0`, `true`} {
+		var jr jsonRequest
+
+		d := json.NewDecoder(strings.NewReader(`{"jsonrpc":"2.0","method":"m","params":` + params + `}`))
+		d.UseNumber()
+		assert.NoError(t, d.Decode(&jr))
+
+		_, e := jr.Request()
+		if assert.NotNil(t, e, params) {
+			assert.Equal(t, coder.InvalidRequest.Code, e.Code)
+		}
+	}
+}
+
 func Test_jsonNumber_CastFloat64(t *testing.T) {
 	cases := []struct {
 		in json.Number
@@ -583,3 +644,59 @@ func Test_jsonNumber_CastUint(t *testing.T) {
 		assert.Equal(t, c.ok, ok)
 	}
 }
+
+func Test_jsonClientCodec(t *testing.T) {
+	c := coder.NewClient("application/json")
+	if !assert.NotNil(t, c) {
+		return
+	}
+
+	id := c.NewID(1)
+	assert.Equal(t, coder.RequestID("1"), id)
+
+	reqs := []*coder.Request{
+		{Method: "subtract", Params: []interface{}{42, 23}, ID: &id},
+		{Method: "update", Params: map[string]interface{}{"a": 1}, ID: new(coder.RequestID)},
+	}
+
+	data, err := c.EncodeRequests(reqs, true)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `[
+		{"jsonrpc":"2.0","method":"subtract","params":[42,23],"id":1},
+		{"jsonrpc":"2.0","method":"update","params":{"a":1}}
+	]`, string(data))
+
+	data, err = c.EncodeRequests(reqs[:1], false)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"jsonrpc":"2.0","method":"subtract","params":[42,23],"id":1}`, string(data))
+
+	_, err = c.EncodeRequests(reqs, false)
+	assert.Error(t, err)
+
+	resps, batch, err := c.DecodeResponses([]byte(`[
+		{"jsonrpc":"2.0","result":19,"id":1},
+		{"jsonrpc":"2.0","result":null,"id":"a"},
+		{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid Request"},"id":null}
+	]`))
+	assert.NoError(t, err)
+	assert.True(t, batch)
+	if assert.Len(t, resps, 3) {
+		assert.Equal(t, jsonNumber{"19"}, resps[0].Result)
+		assert.Equal(t, coder.RequestID("1"), *resps[0].ID)
+		assert.Nil(t, resps[1].Result)
+		assert.Nil(t, resps[1].Error)
+		assert.Equal(t, &coder.Error{Code: -32600, Message: "Invalid Request"}, resps[2].Error)
+		assert.Nil(t, *resps[2].ID)
+	}
+
+	for _, data := range []string{
+		`{"jsonrpc":"2.0","result":19}`,
+		`{"jsonrpc":"1.0","result":19,"id":1}`,
+		`{"jsonrpc":"2.0","id":1}`,
+		`{"jsonrpc":"2.0","result":19,"error":{"code":1,"message":""},"id":1}`,
+		`[{"jsonrpc":"2.0","id":1}]`,
+	} {
+		_, _, err := c.DecodeResponses([]byte(data))
+		assert.Error(t, err, data)
+	}
+}
@@ -0,0 +1,278 @@
+package generpc
+
+import (
+	"context"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/dwlnetnl/generpc/coder"
+)
+
+// Limit describes a rate limit and/or concurrency quota of calls. Calls are
+// limited per client key, see KeyFunc.
+//
+// Rate is the number of calls per second that are allowed on average, Burst
+// is the number of calls that are allowed at once (at least 1). A zero Rate
+// doesn't limit the rate.
+//
+// MaxConcurrent is the maximum number of concurrent calls, zero means no
+// limit.
+type Limit struct {
+	Method        string  // name of the method, empty matches all methods
+	Key           KeyFunc // nil limits all clients together
+	Rate          float64
+	Burst         int
+	MaxConcurrent int
+}
+
+// KeyFunc returns the client key of a call.
+type KeyFunc func(ctx context.Context) string
+
+// ClientIP is a KeyFunc that returns the remote IP address of the HTTP request.
+func ClientIP(ctx context.Context) string {
+	r := HTTPRequestFromContext(ctx)
+	if r == nil {
+		return ""
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
+
+// ClientPrincipal is a KeyFunc that returns the name of the authenticated
+// principal, see PrincipalFromContext.
+func ClientPrincipal(ctx context.Context) string {
+	if p := PrincipalFromContext(ctx); p != nil {
+		return p.Name
+	}
+
+	return ""
+}
+
+// ClientHeader returns a KeyFunc that returns the value of a HTTP request
+// header, like an API key.
+func ClientHeader(name string) KeyFunc {
+	return func(ctx context.Context) string {
+		if r := HTTPRequestFromContext(ctx); r != nil {
+			return r.Header.Get(name)
+		}
+
+		return ""
+	}
+}
+
+// AddLimit adds a limit to the calls of the server. Calls of an alias are
+// limited as calls of its target method and calls of the methods of mounted
+// servers by their prefixed name (see Mount). Limited calls fail with a
+// LimitErrorCode error, other requests in a batch are unaffected. If a single
+// request is limited, the Retry-After HTTP header is set as well. AddLimit
+// should be called before the server is serving requests. It panics if the
+// limit has a negative Rate, Burst or MaxConcurrent.
+func (s *Server) AddLimit(l Limit) {
+	if l.Rate < 0 || l.Burst < 0 || l.MaxConcurrent < 0 {
+		panic("generpc: invalid limit")
+	}
+
+	if l.Burst == 0 {
+		l.Burst = 1
+	}
+
+	s.limits = append(s.limits, &limiter{
+		Limit:   l,
+		buckets: make(map[string]*bucket),
+		active:  make(map[string]int),
+	})
+}
+
+type limiter struct {
+	Limit
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	active  map[string]int
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// maxBuckets is the number of buckets after which full buckets are removed.
+const maxBuckets = 10000
+
+// take takes a token from the bucket for key. If the bucket is empty, the
+// duration until the next token is available is returned.
+func (l *limiter) take(key string, now time.Time) (time.Duration, bool) {
+	if l.Rate == 0 {
+		return 0, true
+	}
+
+	burst := float64(l.Burst)
+
+	b := l.buckets[key]
+	if b == nil {
+		if len(l.buckets) >= maxBuckets {
+			l.sweep(now)
+		}
+
+		b = &bucket{tokens: burst, last: now}
+		l.buckets[key] = b
+	}
+
+	b.tokens = math.Min(burst, b.tokens+now.Sub(b.last).Seconds()*l.Rate)
+	b.last = now
+
+	if b.tokens < 1 {
+		wait := (1 - b.tokens) / l.Rate
+		return time.Duration(wait * float64(time.Second)), false
+	}
+
+	b.tokens--
+	return 0, true
+}
+
+// sweep removes the buckets that are full.
+func (l *limiter) sweep(now time.Time) {
+	for key, b := range l.buckets {
+		if b.tokens+now.Sub(b.last).Seconds()*l.Rate >= float64(l.Burst) {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// refund returns a token taken by take to the bucket for key.
+func (l *limiter) refund(key string) {
+	if b := l.buckets[key]; b != nil {
+		b.tokens = math.Min(float64(l.Burst), b.tokens+1)
+	}
+}
+
+// grant holds the limits acquired for a call.
+type grant struct {
+	releases []func() // release the concurrency quotas
+	refunds  []func() // return the taken tokens
+}
+
+// release releases the concurrency quotas when the call is finished.
+func (g *grant) release() {
+	for _, f := range g.releases {
+		f()
+	}
+}
+
+// cancel releases the concurrency quotas and returns the taken tokens, for a
+// call that is rejected by a later limit.
+func (g *grant) cancel() {
+	for _, f := range g.refunds {
+		f()
+	}
+	g.release()
+}
+
+// acquire applies the limits to a call of method and adds them to g. If a
+// limit rejects the call, the limits acquired before should be cancelled,
+// see grant.cancel.
+func (s *Server) acquire(ctx context.Context, method string, g *grant) *coder.Error {
+	now := time.Now()
+	for _, l := range s.limits {
+		if l.Method != "" && l.Method != method {
+			continue
+		}
+
+		var key string
+		if l.Key != nil {
+			key = l.Key(ctx)
+		}
+
+		l.mu.Lock()
+
+		if l.MaxConcurrent > 0 && l.active[key] >= l.MaxConcurrent {
+			l.mu.Unlock()
+			return limitError(time.Second)
+		}
+
+		if wait, ok := l.take(key, now); !ok {
+			l.mu.Unlock()
+			return limitError(wait)
+		}
+
+		l, key := l, key
+		if l.Rate > 0 {
+			g.refunds = append(g.refunds, func() {
+				l.mu.Lock()
+				defer l.mu.Unlock()
+				l.refund(key)
+			})
+		}
+
+		if l.MaxConcurrent > 0 {
+			l.active[key]++
+			g.releases = append(g.releases, func() {
+				l.mu.Lock()
+				defer l.mu.Unlock()
+
+				if l.active[key]--; l.active[key] == 0 {
+					delete(l.active, key)
+				}
+			})
+		}
+
+		l.mu.Unlock()
+	}
+
+	return nil
+}
+
+// acquireCall applies the limits of s and of the servers s is mounted in to
+// a call of the method with the canonical name, see acquire. If a limit
+// rejects the call, the tokens taken by the other limits are returned. The
+// returned function should be called when the call is finished.
+func (s *Server) acquireCall(ctx context.Context, name string) (release func(), e *coder.Error) {
+	g := new(grant)
+	if e := s.acquire(ctx, name, g); e != nil {
+		g.cancel()
+		return nil, e
+	}
+
+	for c := mountChainFromContext(ctx); c != nil; c = c.up {
+		name = c.prefix + name
+		if e := c.parent.acquire(ctx, name, g); e != nil {
+			g.cancel()
+			return nil, e
+		}
+	}
+
+	return g.release, nil
+}
+
+func limitError(retryAfter time.Duration) *coder.Error {
+	e := coder.ServerError(LimitErrorCode)
+	e.Data = map[string]interface{}{
+		"reason":     "limit exceeded",
+		"retryAfter": math.Ceil(retryAfter.Seconds()*1000) / 1000,
+	}
+	return &e
+}
+
+// setRetryAfter sets the Retry-After header if the response is a limit error.
+func setRetryAfter(w http.ResponseWriter, r *coder.Response) {
+	if r.Error == nil || r.Error.Code != LimitErrorCode {
+		return
+	}
+
+	data, _ := r.Error.Data.(map[string]interface{})
+	seconds, ok := data["retryAfter"].(float64)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Max(1, math.Ceil(seconds)))))
+}
@@ -0,0 +1,237 @@
+package generpc
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimit(t *testing.T) {
+	h := NewServer()
+	h.Register("subtract", subtractMethod())
+	h.Register("other", valueMethod(nil))
+	h.AddLimit(Limit{Method: "subtract", Key: ClientIP, Rate: 0.5, Burst: 2})
+
+	serve := func(addr, body string) *httptest.ResponseRecorder {
+		r, err := http.NewRequest("POST", "/", strings.NewReader(body))
+		r.Header.Add("Content-Type", "application/json")
+		r.RemoteAddr = addr
+		require.NoError(t, err)
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+
+	w := serve("192.0.2.1:1234", `[
+		{"jsonrpc":"2.0","method":"subtract","params":[42,23],"id":1},
+		{"jsonrpc":"2.0","method":"subtract","params":[42,23],"id":2},
+		{"jsonrpc":"2.0","method":"subtract","params":[42,23],"id":3},
+		{"jsonrpc":"2.0","method":"other","params":[],"id":4}
+	]`)
+
+	var resps []testResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resps))
+	require.Len(t, resps, 4)
+	assert.Nil(t, resps[0].Error)
+	assert.Nil(t, resps[1].Error)
+	require.NotNil(t, resps[2].Error)
+	assert.Equal(t, LimitErrorCode, resps[2].Error.Code)
+	assert.InDelta(t, 2, resps[2].Error.Data["retryAfter"], 0.1)
+	assert.Nil(t, resps[3].Error)
+	assert.Empty(t, w.Header().Get("Retry-After"))
+
+	w = serve("192.0.2.1:1234", `{"jsonrpc":"2.0","method":"subtract","params":[42,23],"id":1}`)
+	assert.Equal(t, "2", w.Header().Get("Retry-After"))
+
+	// Other clients have their own bucket.
+	w = serve("192.0.2.2:1234", `{"jsonrpc":"2.0","method":"subtract","params":[42,23],"id":1}`)
+	assert.Equal(t, `{"jsonrpc":"2.0","result":19,"id":1}`+"\n", w.Body.String())
+}
+
+func TestRateLimit_overlapping(t *testing.T) {
+	h := NewServer()
+	h.Register("a", valueMethod("a"))
+	h.Register("b", valueMethod("b"))
+	h.AddLimit(Limit{Rate: 0.001, Burst: 3})
+	h.AddLimit(Limit{Method: "a", Rate: 0.001, Burst: 1})
+
+	call := func(method string) int {
+		w := serveJSON(t, h, `{"jsonrpc":"2.0","method":"`+method+`","params":[],"id":1}`)
+		var resp testResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		if resp.Error != nil {
+			return resp.Error.Code
+		}
+		return 0
+	}
+
+	assert.Equal(t, 0, call("a"))
+	assert.Equal(t, LimitErrorCode, call("a"))
+
+	// The token taken by the first limit is returned when the second limit
+	// rejects the call.
+	assert.Equal(t, 0, call("b"))
+	assert.Equal(t, 0, call("b"))
+	assert.Equal(t, LimitErrorCode, call("b"))
+}
+
+func TestConcurrencyLimit(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	h := NewServer()
+	h.Register("block", Method{
+		FuncContext: func(ctx context.Context, params []interface{}) interface{} {
+			started <- struct{}{}
+			<-release
+			return "done"
+		},
+	})
+	h.AddLimit(Limit{MaxConcurrent: 1})
+
+	done := make(chan string)
+	go func() {
+		done <- serveJSON(t, h, `{"jsonrpc":"2.0","method":"block","params":[],"id":1}`).Body.String()
+	}()
+	<-started
+
+	w := serveJSON(t, h, `{"jsonrpc":"2.0","method":"block","params":[],"id":2}`)
+	want := `{"jsonrpc":"2.0","error":{"code":-32004,"message":"Server error","data":{"reason":"limit exceeded","retryAfter":1}},"id":2}` + "\n"
+	assert.Equal(t, want, w.Body.String())
+	assert.Equal(t, "1", w.Header().Get("Retry-After"))
+
+	close(release)
+	assert.Equal(t, `{"jsonrpc":"2.0","result":"done","id":1}`+"\n", <-done)
+
+	// The quota is released after the call.
+	go func() { <-started }()
+	w = serveJSON(t, h, `{"jsonrpc":"2.0","method":"block","params":[],"id":3}`)
+	assert.Equal(t, `{"jsonrpc":"2.0","result":"done","id":3}`+"\n", w.Body.String())
+}
+
+func TestClientKeys(t *testing.T) {
+	r, err := http.NewRequest("POST", "/", nil)
+	require.NoError(t, err)
+	r.RemoteAddr = "[2001:db8::1]:443"
+	r.Header.Set("X-API-Key", "key")
+
+	ctx := context.WithValue(context.Background(), httpRequestKey{}, r)
+	ctx = context.WithValue(ctx, authKey{}, &authInfo{p: &Principal{Name: "alice"}})
+
+	assert.Equal(t, "2001:db8::1", ClientIP(ctx))
+	assert.Equal(t, "alice", ClientPrincipal(ctx))
+	assert.Equal(t, "key", ClientHeader("X-API-Key")(ctx))
+
+	assert.Empty(t, ClientIP(context.Background()))
+	assert.Empty(t, ClientPrincipal(context.Background()))
+	assert.Empty(t, ClientHeader("X-API-Key")(context.Background()))
+}
+
+func TestAddLimit_panics(t *testing.T) {
+	assert.Panics(t, func() { NewServer().AddLimit(Limit{Rate: -1}) })
+}
+
+func TestLimit_canonicalName(t *testing.T) {
+	child := NewServer()
+	child.Register("subtract", subtractMethod())
+	child.Alias("minus", "subtract")
+	child.AddLimit(Limit{Method: "subtract", Rate: 0.5, Burst: 1})
+
+	h := NewServer()
+	h.Register("subtract", subtractMethod())
+	h.Alias("minus", "subtract")
+	h.Mount("math", child)
+	h.AddLimit(Limit{Method: "subtract", Rate: 0.5, Burst: 1})
+	h.AddLimit(Limit{Method: "math.subtract", Rate: 0.5, Burst: 2})
+
+	codes := func(body string) []int {
+		var resps []testResponse
+		require.NoError(t, json.Unmarshal(serveJSON(t, h, body).Body.Bytes(), &resps))
+
+		codes := make([]int, len(resps))
+		for i, resp := range resps {
+			if resp.Error != nil {
+				codes[i] = resp.Error.Code
+			}
+		}
+		return codes
+	}
+
+	// Aliases share the limits of their target.
+	assert.Equal(t, []int{0, LimitErrorCode}, codes(`[
+		{"jsonrpc":"2.0","method":"subtract","params":[42,23],"id":1},
+		{"jsonrpc":"2.0","method":"minus","params":[42,23],"id":2}
+	]`))
+
+	// Mounted methods are limited by the child and by the prefixed name.
+	assert.Equal(t, []int{0, LimitErrorCode}, codes(`[
+		{"jsonrpc":"2.0","method":"math.minus","params":[42,23],"id":1},
+		{"jsonrpc":"2.0","method":"math.subtract","params":[42,23],"id":2}
+	]`))
+
+	child = NewServer()
+	child.Register("subtract", subtractMethod())
+	child.Alias("minus", "subtract")
+	h.Mount("calc", child)
+	h.AddLimit(Limit{Method: "calc.subtract", Rate: 0.5, Burst: 1})
+	assert.Equal(t, []int{0, LimitErrorCode}, codes(`[
+		{"jsonrpc":"2.0","method":"calc.minus","params":[42,23],"id":1},
+		{"jsonrpc":"2.0","method":"calc.subtract","params":[42,23],"id":2}
+	]`))
+}
+
+func TestConcurrencyLimit_timeout(t *testing.T) {
+	var (
+		mu              sync.Mutex
+		running, maxRun int
+	)
+
+	h := NewServer()
+	h.Timeout = 20 * time.Millisecond
+	h.Register("slow", Method{
+		Func: func(params []interface{}) interface{} {
+			mu.Lock()
+			running++
+			if running > maxRun {
+				maxRun = running
+			}
+			mu.Unlock()
+
+			// Ignores the timeout.
+			time.Sleep(50 * time.Millisecond)
+
+			mu.Lock()
+			running--
+			mu.Unlock()
+			return nil
+		},
+	})
+	h.AddLimit(Limit{MaxConcurrent: 1})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 3; j++ {
+				serveJSON(t, h, `{"jsonrpc":"2.0","method":"slow","params":[],"id":1}`)
+				time.Sleep(10 * time.Millisecond)
+			}
+		}()
+	}
+	wg.Wait()
+	time.Sleep(60 * time.Millisecond)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, 1, maxRun)
+}
@@ -0,0 +1,117 @@
+package generpc
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// lruCache is a least recently used cache with expiring entries. It's safe for
+// concurrent use.
+type lruCache struct {
+	size int
+
+	mu      sync.Mutex
+	l       *list.List // of *lruEntry, front is most recently used
+	m       map[string]*list.Element
+	sweepAt int // length at which expired entries are swept
+}
+
+type lruEntry struct {
+	key     string
+	value   interface{}
+	expires time.Time
+}
+
+func newLRUCache(size int) *lruCache {
+	return &lruCache{
+		size: size,
+		l:    list.New(),
+		m:    make(map[string]*list.Element),
+	}
+}
+
+func (c *lruCache) get(key string) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.m[key]
+	if !ok {
+		return nil, false
+	}
+
+	entry := e.Value.(*lruEntry)
+	if !entry.expires.IsZero() && time.Now().After(entry.expires) {
+		c.remove(e)
+		return nil, false
+	}
+
+	c.l.MoveToFront(e)
+	return entry.value, true
+}
+
+// put stores the value for key, it expires after ttl unless ttl is zero.
+func (c *lruCache) put(key string, value interface{}, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var expires time.Time
+	if ttl > 0 {
+		expires = time.Now().Add(ttl)
+	}
+
+	if e, ok := c.m[key]; ok {
+		entry := e.Value.(*lruEntry)
+		entry.value = value
+		entry.expires = expires
+		c.l.MoveToFront(e)
+		return
+	}
+
+	c.m[key] = c.l.PushFront(&lruEntry{key, value, expires})
+
+	for c.size > 0 && c.l.Len() > c.size {
+		c.remove(c.l.Back())
+	}
+
+	// Expired entries that aren't read again are swept when the cache
+	// doubled in length since the last sweep, so the cost of a sweep is
+	// amortized over the insertions.
+	if c.l.Len() >= c.sweepAt {
+		now := time.Now()
+		for e := c.l.Front(); e != nil; {
+			next := e.Next()
+			if x := e.Value.(*lruEntry).expires; !x.IsZero() && now.After(x) {
+				c.remove(e)
+			}
+			e = next
+		}
+		c.sweepAt = 2 * c.l.Len()
+		if c.sweepAt < minSweep {
+			c.sweepAt = minSweep
+		}
+	}
+}
+
+// minSweep is the minimal length of a cache at which expired entries are
+// swept.
+const minSweep = 64
+
+// removeFunc removes the entries for which f returns true.
+func (c *lruCache) removeFunc(f func(key string) bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for e := c.l.Front(); e != nil; {
+		next := e.Next()
+		if f(e.Value.(*lruEntry).key) {
+			c.remove(e)
+		}
+		e = next
+	}
+}
+
+func (c *lruCache) remove(e *list.Element) {
+	c.l.Remove(e)
+	delete(c.m, e.Value.(*lruEntry).key)
+}
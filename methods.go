@@ -0,0 +1,117 @@
+package generpc
+
+import "github.com/dwlnetnl/generpc/openrpc"
+
+// Methods maps names to methods, see Server.SwapMethods.
+type Methods map[string]Method
+
+// methodTable is an immutable snapshot of the registered methods. Requests use
+// the snapshot that was current when they were received.
+type methodTable map[string]*Method
+
+func (t methodTable) clone() methodTable {
+	c := make(methodTable, len(t)+1)
+	for name, m := range t {
+		c[name] = m
+	}
+
+	return c
+}
+
+func (s *Server) methods() methodTable {
+	return s.table.Load().(methodTable)
+}
+
+func checkMethod(name string, m Method) {
+	if name == "" {
+		panic("generpc: name is empty")
+	}
+
+	if m.aliasOf != "" || m.mount != nil {
+		// Aliases and mount points are created by Server.Alias and Server.Mount.
+		return
+	}
+
+	if m.Func == nil && m.FuncContext == nil {
+		panic("generpc: Method.Func is nil")
+	}
+
+	if m.Func != nil && m.FuncContext != nil {
+		panic("generpc: both Method.Func and Method.FuncContext are set")
+	}
+
+	switch m.ParamStructure {
+	case "", openrpc.Either, openrpc.ByName, openrpc.ByPosition:
+	default:
+		panic("generpc: invalid Method.ParamStructure: " + m.ParamStructure)
+	}
+
+	if m.ParamStructure == openrpc.ByName && len(m.ParamSchemas) > len(m.ParamNames) {
+		panic("generpc: by-name Method.ParamStructure without Method.ParamNames")
+	}
+}
+
+// Unregister removes the method for the given name and reports if it was
+// registered. Requests that are being served keep using the method.
+func (s *Server) Unregister(name string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t := s.methods()
+	if _, ok := t[name]; !ok {
+		return false
+	}
+
+	t = t.clone()
+	delete(t, name)
+	s.table.Store(t)
+	return true
+}
+
+// Replace registers a RPC method like Register, except it replaces any
+// existing method for the name. Requests that are being served keep using the
+// replaced method.
+func (s *Server) Replace(name string, m Method) {
+	checkMethod(name, m)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t := s.methods().clone()
+	t[name] = &m
+	s.table.Store(t)
+}
+
+// SwapMethods atomically replaces all registered methods with ms and returns
+// the previously registered methods. It panics like Register if a method is
+// invalid, in which case no methods are replaced. Requests that are being
+// served keep using the previous methods.
+func (s *Server) SwapMethods(ms Methods) Methods {
+	t := make(methodTable, len(ms))
+	for name, m := range ms {
+		checkMethod(name, m)
+		m := m
+		t[name] = &m
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	old := s.methods()
+	s.table.Store(t)
+	return old.export()
+}
+
+// Methods returns a copy of the registered methods.
+func (s *Server) Methods() Methods {
+	return s.methods().export()
+}
+
+func (t methodTable) export() Methods {
+	ms := make(Methods, len(t))
+	for name, m := range t {
+		ms[name] = *m
+	}
+
+	return ms
+}
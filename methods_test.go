@@ -0,0 +1,108 @@
+package generpc
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func valueMethod(v interface{}) Method {
+	return Method{
+		Func: func([]interface{}) interface{} { return v },
+	}
+}
+
+func TestUnregister(t *testing.T) {
+	h := NewServer()
+	h.Register("subtract", subtractMethod())
+
+	assert.True(t, h.Unregister("subtract"))
+	assert.False(t, h.Unregister("subtract"))
+
+	w := serveJSON(t, h, `{"jsonrpc":"2.0","method":"subtract","params":[42,23],"id":1}`)
+	want := `{"jsonrpc":"2.0","error":{"code":-32601,"message":"Method not found"},"id":1}` + "\n"
+	assert.Equal(t, want, w.Body.String())
+
+	h.Register("subtract", subtractMethod())
+}
+
+func TestReplace(t *testing.T) {
+	h := NewServer()
+	h.Replace("value", valueMethod(1))
+	h.Replace("value", valueMethod(2))
+
+	w := serveJSON(t, h, `{"jsonrpc":"2.0","method":"value","params":[],"id":1}`)
+	assert.Equal(t, `{"jsonrpc":"2.0","result":2,"id":1}`+"\n", w.Body.String())
+
+	assert.Panics(t, func() { h.Replace("value", Method{}) })
+}
+
+func TestSwapMethods(t *testing.T) {
+	h := NewServer()
+	h.Register("a", valueMethod("a"))
+
+	old := h.SwapMethods(Methods{"b": valueMethod("b")})
+	assert.Len(t, old, 1)
+	assert.Contains(t, old, "a")
+	assert.Len(t, h.Methods(), 1)
+	assert.Contains(t, h.Methods(), "b")
+
+	assert.Panics(t, func() {
+		h.SwapMethods(Methods{"c": valueMethod("c"), "": valueMethod("")})
+	})
+	assert.Contains(t, h.Methods(), "b")
+}
+
+func TestSwapMethods_inFlight(t *testing.T) {
+	h := NewServer()
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	h.Register("block", Method{
+		Func: func([]interface{}) interface{} {
+			close(started)
+			<-release
+			return "v1"
+		},
+	})
+	h.Register("value", valueMethod("v1"))
+
+	done := make(chan string)
+	go func() {
+		w := serveJSON(t, h, `[
+			{"jsonrpc":"2.0","method":"block","params":[],"id":1},
+			{"jsonrpc":"2.0","method":"value","params":[],"id":2}
+		]`)
+		done <- w.Body.String()
+	}()
+	<-started
+
+	h.SwapMethods(Methods{"value": valueMethod("v2")})
+	close(release)
+
+	want := `[{"jsonrpc":"2.0","result":"v1","id":1},{"jsonrpc":"2.0","result":"v1","id":2}]` + "\n"
+	assert.Equal(t, want, <-done)
+
+	w := serveJSON(t, h, `{"jsonrpc":"2.0","method":"value","params":[],"id":1}`)
+	assert.Equal(t, `{"jsonrpc":"2.0","result":"v2","id":1}`+"\n", w.Body.String())
+}
+
+func TestRegister_concurrent(t *testing.T) {
+	h := NewServer()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			name := strconv.Itoa(i)
+			h.Register(name, valueMethod(i))
+			serveJSON(t, h, `{"jsonrpc":"2.0","method":"`+name+`","params":[],"id":1}`)
+		}(i)
+	}
+	wg.Wait()
+
+	assert.Len(t, h.Methods(), 10)
+}
@@ -0,0 +1,134 @@
+package metrics
+
+import (
+	"expvar"
+	"strconv"
+	"sync"
+
+	"github.com/dwlnetnl/generpc"
+)
+
+// Expvar collects metrics and publishes them as an expvar variable. The
+// variable is a map from media type to:
+//
+//	payloads        number of payloads
+//	payloadErrors   number of failed payloads by error code
+//	batchSize       batch size histogram: count per upper bound
+//	requests        number of requests by method
+//	notifications   number of notifications by method
+//	latency         latency histogram by method: count per upper bound in seconds
+//	errors          number of errors by method and error code ("method code")
+//
+// Unsupported media types are counted as generpc.OtherMediaType and methods
+// that don't exist as generpc.UnknownMethod ("rpc.unknown").
+type Expvar struct {
+	mu    sync.Mutex
+	m     *expvar.Map
+	types map[string]*mediaTypeStats
+}
+
+type mediaTypeStats struct {
+	payloads      expvar.Int
+	payloadErrors expvar.Map
+	batchSize     *histogram
+	requests      expvar.Map
+	notifications expvar.Map
+	latency       map[string]*histogram
+	errors        expvar.Map
+}
+
+var _ generpc.Observer = (*Expvar)(nil)
+
+// NewExpvar returns a collector that is published with expvar.Publish under
+// the given name. Like expvar.Publish it panics if the name is already in
+// use.
+func NewExpvar(name string) *Expvar {
+	return &Expvar{
+		m:     expvar.NewMap(name),
+		types: make(map[string]*mediaTypeStats),
+	}
+}
+
+func (e *Expvar) stats(mediaType string) *mediaTypeStats {
+	st := e.types[mediaType]
+	if st != nil {
+		return st
+	}
+
+	st = &mediaTypeStats{
+		batchSize: newHistogram(BatchBuckets),
+		latency:   make(map[string]*histogram),
+	}
+	st.payloadErrors.Init()
+	st.requests.Init()
+	st.notifications.Init()
+	st.errors.Init()
+	e.types[mediaType] = st
+
+	m := new(expvar.Map).Init()
+	m.Set("payloads", &st.payloads)
+	m.Set("payloadErrors", &st.payloadErrors)
+	m.Set("batchSize", expvar.Func(func() interface{} {
+		e.mu.Lock()
+		defer e.mu.Unlock()
+		return st.batchSize.buckets()
+	}))
+	m.Set("requests", &st.requests)
+	m.Set("notifications", &st.notifications)
+	m.Set("latency", expvar.Func(func() interface{} {
+		e.mu.Lock()
+		defer e.mu.Unlock()
+
+		v := make(map[string]interface{}, len(st.latency))
+		for method, h := range st.latency {
+			v[method] = h.buckets()
+		}
+		return v
+	}))
+	m.Set("errors", &st.errors)
+	e.m.Set(mediaType, m)
+
+	return st
+}
+
+// ObservePayload implements generpc.Observer.
+func (e *Expvar) ObservePayload(info generpc.PayloadInfo) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	st := e.stats(info.MediaType)
+	st.payloads.Add(1)
+
+	if info.Error != nil {
+		st.payloadErrors.Add(strconv.Itoa(info.Error.Code), 1)
+		return
+	}
+
+	if info.Batch {
+		st.batchSize.observe(float64(info.Requests))
+	}
+}
+
+// ObserveCall implements generpc.Observer.
+func (e *Expvar) ObserveCall(info generpc.CallInfo) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	st := e.stats(info.MediaType)
+	if info.Notification {
+		st.notifications.Add(info.Method, 1)
+	} else {
+		st.requests.Add(info.Method, 1)
+	}
+
+	h := st.latency[info.Method]
+	if h == nil {
+		h = newHistogram(LatencyBuckets)
+		st.latency[info.Method] = h
+	}
+	h.observe(info.Duration.Seconds())
+
+	if info.Error != nil {
+		st.errors.Add(info.Method+" "+strconv.Itoa(info.Error.Code), 1)
+	}
+}
@@ -0,0 +1,63 @@
+// Package metrics provides generpc.Observer implementations that collect
+// request metrics for expvar and Prometheus.
+//
+// Metrics are split by media type (the Content-Type that selected the coder):
+// the number of payloads and their batch size, the number of requests and
+// notifications per method, the latency per method and the number of errors
+// per method and error code.
+package metrics
+
+import (
+	"sort"
+	"strconv"
+)
+
+var (
+	// BatchBuckets are the upper bounds of the batch size histogram.
+	BatchBuckets = []float64{1, 2, 5, 10, 20, 50, 100}
+
+	// LatencyBuckets are the upper bounds in seconds of the latency
+	// histogram.
+	LatencyBuckets = []float64{.001, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+)
+
+// histogram is a cumulative histogram.
+type histogram struct {
+	bounds []float64
+	counts []uint64 // per bound, not cumulative
+	count  uint64
+	sum    float64
+}
+
+func newHistogram(bounds []float64) *histogram {
+	return &histogram{bounds: bounds, counts: make([]uint64, len(bounds))}
+}
+
+func (h *histogram) observe(v float64) {
+	i := sort.SearchFloat64s(h.bounds, v)
+	if i < len(h.counts) {
+		h.counts[i]++
+	}
+
+	h.count++
+	h.sum += v
+}
+
+// buckets returns the cumulative counts per upper bound, the last bucket is
+// "+Inf".
+func (h *histogram) buckets() map[string]uint64 {
+	m := make(map[string]uint64, len(h.bounds)+1)
+
+	var n uint64
+	for i, b := range h.bounds {
+		n += h.counts[i]
+		m[formatFloat(b)] = n
+	}
+
+	m["+Inf"] = h.count
+	return m
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
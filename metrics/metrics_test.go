@@ -0,0 +1,134 @@
+package metrics
+
+import (
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dwlnetnl/generpc"
+	"github.com/dwlnetnl/generpc/coder"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testServer(o generpc.Observer) *generpc.Server {
+	h := generpc.NewServer()
+	h.Observer = o
+	h.Register("ok", generpc.Method{
+		Func: func([]interface{}) interface{} { return true },
+	})
+	h.Register("fail", generpc.Method{
+		Func: func([]interface{}) interface{} {
+			return coder.Error{Code: 1, Message: "Test error"}
+		},
+	})
+
+	serve := func(body string) {
+		r := httptest.NewRequest("POST", "/", strings.NewReader(body))
+		r.Header.Set("Content-Type", "application/json")
+		h.ServeHTTP(httptest.NewRecorder(), r)
+	}
+
+	serve(`[
+		{"jsonrpc":"2.0","method":"ok","params":[],"id":1},
+		{"jsonrpc":"2.0","method":"fail","params":[],"id":2},
+		{"jsonrpc":"2.0","method":"ok","params":[]}
+	]`)
+	serve(`{"jsonrpc":"2.0","method":"ok","params":[],"id":3}`)
+	serve(`{"jsonrpc":"2.0","method"`)
+
+	return h
+}
+
+func TestPrometheus(t *testing.T) {
+	p := new(Prometheus)
+	testServer(p)
+
+	w := httptest.NewRecorder()
+	p.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	assert.Equal(t, "text/plain; version=0.0.4; charset=utf-8", w.Header().Get("Content-Type"))
+
+	out := w.Body.String()
+	for _, line := range []string{
+		`# TYPE generpc_payloads_total counter`,
+		`generpc_payloads_total{media_type="application/json"} 3`,
+		`generpc_payload_errors_total{media_type="application/json",code="-32600"} 1`,
+		`generpc_requests_total{media_type="application/json",method="ok"} 2`,
+		`generpc_notifications_total{media_type="application/json",method="ok"} 1`,
+		`generpc_errors_total{media_type="application/json",method="fail",code="1"} 1`,
+		`# TYPE generpc_batch_size histogram`,
+		`generpc_batch_size_bucket{media_type="application/json",le="2"} 0`,
+		`generpc_batch_size_bucket{media_type="application/json",le="5"} 1`,
+		`generpc_batch_size_count{media_type="application/json"} 1`,
+		`generpc_call_duration_seconds_count{media_type="application/json",method="ok"} 3`,
+	} {
+		assert.Contains(t, out, line+"\n")
+	}
+}
+
+func TestExpvar(t *testing.T) {
+	testServer(NewExpvar("generpc_test"))
+
+	var v map[string]struct {
+		Payloads      int
+		PayloadErrors map[string]int
+		BatchSize     map[string]int
+		Requests      map[string]int
+		Notifications map[string]int
+		Latency       map[string]map[string]int
+		Errors        map[string]int
+	}
+	require.NoError(t, json.Unmarshal([]byte(expvar.Get("generpc_test").String()), &v))
+
+	st := v["application/json"]
+	assert.Equal(t, 3, st.Payloads)
+	assert.Equal(t, map[string]int{"-32600": 1}, st.PayloadErrors)
+	assert.Equal(t, 1, st.BatchSize["5"])
+	assert.Equal(t, 0, st.BatchSize["2"])
+	assert.Equal(t, map[string]int{"ok": 2, "fail": 1}, st.Requests)
+	assert.Equal(t, map[string]int{"ok": 1}, st.Notifications)
+	assert.Equal(t, 3, st.Latency["ok"]["+Inf"])
+	assert.Equal(t, map[string]int{"fail 1": 1}, st.Errors)
+}
+
+func TestPrometheus_boundedLabels(t *testing.T) {
+	p := new(Prometheus)
+	h := testServer(p)
+
+	for i := 0; i < 100; i++ {
+		body := fmt.Sprintf(`{"jsonrpc":"2.0","method":"m%d","params":[],"id":1}`, rand.Int())
+		r := httptest.NewRequest("POST", "/", strings.NewReader(body))
+		r.Header.Set("Content-Type", "application/json")
+		h.ServeHTTP(httptest.NewRecorder(), r)
+
+		r = httptest.NewRequest("POST", "/", strings.NewReader(body))
+		r.Header.Set("Content-Type", fmt.Sprintf("text/x-%d", rand.Int()))
+		h.ServeHTTP(httptest.NewRecorder(), r)
+	}
+
+	w := httptest.NewRecorder()
+	p.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+
+	out := w.Body.String()
+	assert.Contains(t, out, `generpc_requests_total{media_type="application/json",method="rpc.unknown"} 100`+"\n")
+	assert.Contains(t, out, `generpc_errors_total{media_type="application/json",method="rpc.unknown",code="-32601"} 100`+"\n")
+	assert.Contains(t, out, `generpc_payloads_total{media_type="other"} 100`+"\n")
+	assert.NotContains(t, out, `method="m`)
+	assert.NotContains(t, out, `text/x-`)
+}
+
+func TestHistogram(t *testing.T) {
+	h := newHistogram([]float64{1, 2})
+	h.observe(0.5)
+	h.observe(1)
+	h.observe(3)
+	assert.Equal(t, map[string]uint64{"1": 2, "2": 2, "+Inf": 3}, h.buckets())
+	assert.Equal(t, 4.5, h.sum)
+}
+
+var _ http.Handler = (*Prometheus)(nil)
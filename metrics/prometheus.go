@@ -0,0 +1,218 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/dwlnetnl/generpc"
+)
+
+// Prometheus collects metrics and exposes them in the Prometheus text
+// exposition format. The zero value is ready to use.
+//
+// The following metrics are exposed, all labeled with media_type:
+//
+//	generpc_payloads_total                   counter
+//	generpc_payload_errors_total{code}       counter
+//	generpc_batch_size                       histogram
+//	generpc_requests_total{method}           counter
+//	generpc_notifications_total{method}      counter
+//	generpc_call_duration_seconds{method}    histogram
+//	generpc_errors_total{method,code}        counter
+//
+// Unsupported media types are labeled generpc.OtherMediaType and methods
+// that don't exist generpc.UnknownMethod ("rpc.unknown"), so the number of
+// series is bounded.
+type Prometheus struct {
+	mu        sync.Mutex
+	counters  map[string]map[labels]uint64
+	histogram map[string]map[labels]*histogram
+}
+
+// labels is a rendered label set like `media_type="application/json"`.
+type labels string
+
+func makeLabels(kv ...string) labels {
+	var b strings.Builder
+	for i := 0; i < len(kv); i += 2 {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(kv[i])
+		b.WriteString(`="`)
+		b.WriteString(escapeLabel(kv[i+1]))
+		b.WriteByte('"')
+	}
+
+	return labels(b.String())
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(v string) string {
+	return labelEscaper.Replace(v)
+}
+
+var _ generpc.Observer = (*Prometheus)(nil)
+
+// ObservePayload implements generpc.Observer.
+func (p *Prometheus) ObservePayload(info generpc.PayloadInfo) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	l := makeLabels("media_type", info.MediaType)
+	p.add("generpc_payloads_total", l)
+
+	if info.Error != nil {
+		code := strconv.Itoa(info.Error.Code)
+		p.add("generpc_payload_errors_total", makeLabels("media_type", info.MediaType, "code", code))
+		return
+	}
+
+	if info.Batch {
+		p.observe("generpc_batch_size", l, BatchBuckets, float64(info.Requests))
+	}
+}
+
+// ObserveCall implements generpc.Observer.
+func (p *Prometheus) ObserveCall(info generpc.CallInfo) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	l := makeLabels("media_type", info.MediaType, "method", info.Method)
+	if info.Notification {
+		p.add("generpc_notifications_total", l)
+	} else {
+		p.add("generpc_requests_total", l)
+	}
+
+	p.observe("generpc_call_duration_seconds", l, LatencyBuckets, info.Duration.Seconds())
+
+	if info.Error != nil {
+		code := strconv.Itoa(info.Error.Code)
+		p.add("generpc_errors_total", makeLabels("media_type", info.MediaType, "method", info.Method, "code", code))
+	}
+}
+
+func (p *Prometheus) add(name string, l labels) {
+	if p.counters == nil {
+		p.counters = make(map[string]map[labels]uint64)
+	}
+
+	if p.counters[name] == nil {
+		p.counters[name] = make(map[labels]uint64)
+	}
+
+	p.counters[name][l]++
+}
+
+func (p *Prometheus) observe(name string, l labels, bounds []float64, v float64) {
+	if p.histogram == nil {
+		p.histogram = make(map[string]map[labels]*histogram)
+	}
+
+	if p.histogram[name] == nil {
+		p.histogram[name] = make(map[labels]*histogram)
+	}
+
+	h := p.histogram[name][l]
+	if h == nil {
+		h = newHistogram(bounds)
+		p.histogram[name][l] = h
+	}
+
+	h.observe(v)
+}
+
+// ServeHTTP writes the metrics in the Prometheus text exposition format.
+func (p *Prometheus) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	p.WriteTo(w)
+}
+
+// WriteTo writes the metrics in the Prometheus text exposition format.
+func (p *Prometheus) WriteTo(w io.Writer) (int64, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	cw := &countWriter{w: w}
+
+	var names []string
+	for name := range p.counters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		set := make(map[labels]bool)
+		for l := range p.counters[name] {
+			set[l] = true
+		}
+
+		fmt.Fprintf(cw, "# TYPE %s counter\n", name)
+		for _, l := range sortedLabels(set) {
+			fmt.Fprintf(cw, "%s{%s} %d\n", name, l, p.counters[name][l])
+		}
+	}
+
+	names = names[:0]
+	for name := range p.histogram {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		set := make(map[labels]bool)
+		for l := range p.histogram[name] {
+			set[l] = true
+		}
+
+		fmt.Fprintf(cw, "# TYPE %s histogram\n", name)
+		for _, l := range sortedLabels(set) {
+			h := p.histogram[name][l]
+
+			var n uint64
+			for i, b := range h.bounds {
+				n += h.counts[i]
+				fmt.Fprintf(cw, "%s_bucket{%s,le=\"%s\"} %d\n", name, l, formatFloat(b), n)
+			}
+			fmt.Fprintf(cw, "%s_bucket{%s,le=\"+Inf\"} %d\n", name, l, h.count)
+			fmt.Fprintf(cw, "%s_sum{%s} %s\n", name, l, formatFloat(h.sum))
+			fmt.Fprintf(cw, "%s_count{%s} %d\n", name, l, h.count)
+		}
+	}
+
+	return cw.n, cw.err
+}
+
+func sortedLabels(m map[labels]bool) []labels {
+	keys := make([]labels, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	return keys
+}
+
+type countWriter struct {
+	w   io.Writer
+	n   int64
+	err error
+}
+
+func (w *countWriter) Write(p []byte) (int, error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+
+	n, err := w.w.Write(p)
+	w.n += int64(n)
+	w.err = err
+	return n, err
+}
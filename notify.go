@@ -0,0 +1,161 @@
+package generpc
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/dwlnetnl/generpc/coder"
+)
+
+const (
+	defaultNotificationWorkers = 1
+	defaultNotificationQueue   = 64
+)
+
+// notifier processes notifications in the background.
+type notifier struct {
+	once  sync.Once
+	queue chan notification
+	wg    sync.WaitGroup // workers
+
+	mu      sync.RWMutex
+	drained bool
+	stop    chan struct{}  // closed when draining starts
+	senders sync.WaitGroup // goroutines sending to queue
+	done    chan struct{}  // closed when the queue is drained
+}
+
+type notification struct {
+	ctx context.Context
+	t   methodTable
+	req *coder.Request
+}
+
+func onlyNotifications(reqs []*coder.Request) bool {
+	for _, req := range reqs {
+		if req == nil || req.Error != nil || *req.ID != nil {
+			return false
+		}
+	}
+
+	return len(reqs) > 0
+}
+
+func (s *Server) startNotifier() {
+	workers := s.NotificationWorkers
+	if workers <= 0 {
+		workers = defaultNotificationWorkers
+	}
+
+	size := s.NotificationQueue
+	if size <= 0 {
+		size = defaultNotificationQueue
+	}
+
+	s.n.queue = make(chan notification, size)
+	s.n.stop = make(chan struct{})
+	s.n.done = make(chan struct{})
+	s.n.wg.Add(workers)
+
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer s.n.wg.Done()
+
+			for n := range s.n.queue {
+				ctx, cancel := s.withShutdown(n.ctx)
+				s.invokeNotification(ctx, n.t, n.req)
+				cancel()
+			}
+		}()
+	}
+}
+
+// enqueueNotifications queues the notifications for background processing.
+// It blocks while the queue is full. Notifications that cannot be queued
+// because ctx is done are reported as failed. After draining started,
+// notifications are invoked synchronously.
+func (s *Server) enqueueNotifications(ctx context.Context, t methodTable, reqs []*coder.Request) {
+	s.n.once.Do(s.startNotifier)
+
+	// Background processing shouldn't be cancelled when the HTTP request is.
+	bg := context.WithoutCancel(ctx)
+
+	for _, req := range reqs {
+		if !s.queueNotification(ctx, notification{bg, t, req}) {
+			nctx, cancel := s.withShutdown(bg)
+			s.invokeNotification(nctx, t, req)
+			cancel()
+		}
+	}
+}
+
+// queueNotification sends n to the queue. It returns false if draining
+// started, the notification should be invoked synchronously then. The lock
+// isn't held while sending, so draining isn't blocked by a full queue.
+func (s *Server) queueNotification(ctx context.Context, n notification) bool {
+	s.n.mu.RLock()
+	if s.n.drained {
+		s.n.mu.RUnlock()
+		return false
+	}
+	s.n.senders.Add(1)
+	s.n.mu.RUnlock()
+	defer s.n.senders.Done()
+
+	select {
+	case s.n.queue <- n:
+	case <-ctx.Done():
+		s.notificationFailed(n.req, ctx.Err())
+	case <-s.n.stop:
+		return false
+	}
+
+	return true
+}
+
+func (s *Server) invokeNotification(ctx context.Context, t methodTable, req *coder.Request) {
+	defer func() {
+		if v := recover(); v != nil {
+			s.notificationFailed(req, fmt.Errorf("panic: %v", v))
+		}
+	}()
+
+	s.invokeRequest(ctx, t, req)
+}
+
+func (s *Server) notificationFailed(req *coder.Request, err error) {
+	e := s.mapError(req.Method, err)
+	if s.NotificationError != nil {
+		s.NotificationError(req, e)
+	}
+}
+
+// DrainNotifications stops queueing notifications and waits until the queued
+// notifications are processed or ctx is done. Notifications received after
+// draining started are processed synchronously.
+func (s *Server) DrainNotifications(ctx context.Context) error {
+	s.n.once.Do(s.startNotifier)
+
+	s.n.mu.Lock()
+	if !s.n.drained {
+		s.n.drained = true
+		close(s.n.stop)
+
+		go func() {
+			// The queue is closed when no goroutine can send to it anymore.
+			s.n.senders.Wait()
+			close(s.n.queue)
+			s.n.wg.Wait()
+			close(s.n.done)
+		}()
+	}
+	s.n.mu.Unlock()
+
+	select {
+	case <-s.n.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,172 @@
+package generpc
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dwlnetnl/generpc/coder"
+)
+
+func TestAsyncNotifications(t *testing.T) {
+	var calls int32
+	release := make(chan struct{})
+
+	h := NewServer()
+	h.AsyncNotifications = true
+	h.Register("count", Method{
+		Func: func([]interface{}) interface{} {
+			<-release
+			atomic.AddInt32(&calls, 1)
+			return nil
+		},
+	})
+
+	w := serveJSON(t, h, `{"jsonrpc":"2.0","method":"count","params":[]}`)
+	assert.Equal(t, 204, w.Code)
+	assert.Empty(t, w.Body.String())
+	assert.Empty(t, w.Header().Get("Content-Type"))
+
+	w = serveJSON(t, h, `[
+		{"jsonrpc":"2.0","method":"count","params":[]},
+		{"jsonrpc":"2.0","method":"count","params":[]}
+	]`)
+	assert.Equal(t, 204, w.Code)
+	assert.Equal(t, int32(0), atomic.LoadInt32(&calls))
+
+	close(release)
+	require.NoError(t, h.DrainNotifications(context.Background()))
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+
+	// Notifications are processed synchronously after draining.
+	serveJSON(t, h, `{"jsonrpc":"2.0","method":"count","params":[]}`)
+	assert.Equal(t, int32(4), atomic.LoadInt32(&calls))
+}
+
+func TestAsyncNotifications_mixed(t *testing.T) {
+	h := NewServer()
+	h.AsyncNotifications = true
+	h.Register("subtract", subtractMethod())
+
+	w := serveJSON(t, h, `[
+		{"jsonrpc":"2.0","method":"subtract","params":[42,23]},
+		{"jsonrpc":"2.0","method":"subtract","params":[42,23],"id":1}
+	]`)
+
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, `[{"jsonrpc":"2.0","result":19,"id":1}]`+"\n", w.Body.String())
+}
+
+func TestAsyncNotifications_backpressure(t *testing.T) {
+	release := make(chan struct{})
+
+	h := NewServer()
+	h.AsyncNotifications = true
+	h.NotificationQueue = 1
+	h.Register("block", Method{
+		Func: func([]interface{}) interface{} {
+			<-release
+			return nil
+		},
+	})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		// One notification is processed, one is queued and one waits.
+		serveJSON(t, h, `[
+			{"jsonrpc":"2.0","method":"block","params":[]},
+			{"jsonrpc":"2.0","method":"block","params":[]},
+			{"jsonrpc":"2.0","method":"block","params":[]}
+		]`)
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("request not blocked by full queue")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	close(release)
+	<-done
+	require.NoError(t, h.DrainNotifications(context.Background()))
+}
+
+func TestNotificationError(t *testing.T) {
+	var mu sync.Mutex
+	var errs []*coder.Error
+
+	h := NewServer()
+	h.NotificationError = func(req *coder.Request, e *coder.Error) {
+		mu.Lock()
+		defer mu.Unlock()
+		errs = append(errs, e)
+	}
+
+	w := serveJSON(t, h, `{"jsonrpc":"2.0","method":"unregistered","params":[]}`)
+	assert.Empty(t, w.Body.String())
+
+	h.AsyncNotifications = true
+	serveJSON(t, h, `{"jsonrpc":"2.0","method":"unregistered","params":[]}`)
+	require.NoError(t, h.DrainNotifications(context.Background()))
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []*coder.Error{&methodNotFound, &methodNotFound}, errs)
+}
+
+func TestDrainNotifications_deadline(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+
+	h := NewServer()
+	h.AsyncNotifications = true
+	h.Register("block", Method{
+		Func: func([]interface{}) interface{} {
+			<-release
+			return nil
+		},
+	})
+
+	serveJSON(t, h, `{"jsonrpc":"2.0","method":"block","params":[]}`)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, h.DrainNotifications(ctx))
+}
+
+func TestDrainNotifications_fullQueue(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+
+	h := NewServer()
+	h.AsyncNotifications = true
+	h.NotificationQueue = 1
+	h.Register("block", Method{
+		Func: func([]interface{}) interface{} {
+			<-release
+			return nil
+		},
+	})
+
+	// One notification is processed, one is queued and one blocks the
+	// request on the full queue.
+	go serveJSON(t, h, `[
+		{"jsonrpc":"2.0","method":"block","params":[]},
+		{"jsonrpc":"2.0","method":"block","params":[]},
+		{"jsonrpc":"2.0","method":"block","params":[]}
+	]`)
+	time.Sleep(10 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	assert.Equal(t, context.DeadlineExceeded, h.DrainNotifications(ctx))
+	assert.Less(t, time.Since(start), time.Second)
+}
@@ -0,0 +1,97 @@
+package generpc
+
+import (
+	"context"
+	"time"
+
+	"github.com/dwlnetnl/generpc/coder"
+)
+
+// Observer is notified of the payloads and calls handled by a Server, see
+// Server.Observer. The metrics package contains implementations. Methods are
+// called concurrently.
+type Observer interface {
+	// ObservePayload is called when ServeHTTP has handled a payload.
+	ObservePayload(PayloadInfo)
+
+	// ObserveCall is called when a request or notification has been invoked.
+	ObserveCall(CallInfo)
+}
+
+const (
+	// UnknownMethod is the CallInfo.Method of calls of methods that don't
+	// exist, so observers see a bounded set of method names. Names starting
+	// with "rpc." are reserved, so it doesn't collide with a method.
+	UnknownMethod = "rpc.unknown"
+
+	// OtherMediaType is the PayloadInfo.MediaType of payloads with an
+	// unsupported media type.
+	OtherMediaType = "other"
+)
+
+// PayloadInfo describes a payload handled by ServeHTTP.
+//
+// MediaType is OtherMediaType if the media type isn't supported. Requests is
+// the number of decoded requests, including malformed batch entries. Error is
+// set if the payload as a whole failed, for example because it could not be
+// parsed.
+type PayloadInfo struct {
+	MediaType string
+	Batch     bool
+	Requests  int
+	Duration  time.Duration
+	Error     *coder.Error
+}
+
+// CallInfo describes an invoked request or notification. Error is nil if the
+// call succeeded. Method is UnknownMethod if the method doesn't exist.
+type CallInfo struct {
+	MediaType    string
+	Method       string
+	Notification bool
+	Duration     time.Duration
+	Error        *coder.Error
+}
+
+func (s *Server) observePayload(start time.Time, info *PayloadInfo) {
+	if s.Observer == nil {
+		return
+	}
+
+	info.Duration = time.Since(start)
+	s.Observer.ObservePayload(*info)
+}
+
+func (s *Server) observeCall(ctx context.Context, t methodTable, start time.Time, req *coder.Request, resp *coder.Response) {
+	if s.Observer == nil {
+		return
+	}
+
+	method := req.Method
+	if !t.exists(method) {
+		method = UnknownMethod
+	}
+
+	s.Observer.ObserveCall(CallInfo{
+		MediaType:    MediaTypeFromContext(ctx),
+		Method:       method,
+		Notification: *req.ID == nil,
+		Duration:     time.Since(start),
+		Error:        resp.Error,
+	})
+}
+
+// exists reports if name is a method or alias in the table or a mounted
+// server, or the discover method.
+func (t methodTable) exists(name string) bool {
+	if name == discoverMethod {
+		return true
+	}
+
+	if m, _, _ := t.lookup(name); m != nil {
+		return true
+	}
+
+	child, sub := t.mount(name)
+	return child != nil && child.methods().exists(sub)
+}
@@ -0,0 +1,63 @@
+package generpc
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordObserver struct {
+	mu       sync.Mutex
+	payloads []PayloadInfo
+	calls    []CallInfo
+}
+
+func (o *recordObserver) ObservePayload(info PayloadInfo) {
+	o.mu.Lock()
+	o.payloads = append(o.payloads, info)
+	o.mu.Unlock()
+}
+
+func (o *recordObserver) ObserveCall(info CallInfo) {
+	o.mu.Lock()
+	o.calls = append(o.calls, info)
+	o.mu.Unlock()
+}
+
+func TestObserver(t *testing.T) {
+	child := NewServer()
+	child.Register("error", errorMethod())
+
+	o := new(recordObserver)
+	h := NewServer()
+	h.Observer = o
+	h.Register("subtract", subtractMethod())
+	h.Mount("child", child)
+
+	serveJSON(t, h, `[
+		{"jsonrpc":"2.0","method":"subtract","params":[42,23],"id":1},
+		{"jsonrpc":"2.0","method":"child.error","params":[],"id":2},
+		{"jsonrpc":"2.0","method":"subtract","params":[42,23]},
+		1
+	]`)
+	serveJSON(t, h, `{"jsonrpc":"2.0","method"`)
+
+	require.Len(t, o.payloads, 2)
+	assert.Equal(t, "application/json", o.payloads[0].MediaType)
+	assert.True(t, o.payloads[0].Batch)
+	assert.Equal(t, 4, o.payloads[0].Requests)
+	assert.Nil(t, o.payloads[0].Error)
+	require.NotNil(t, o.payloads[1].Error)
+	assert.Equal(t, -32600, o.payloads[1].Error.Code)
+
+	require.Len(t, o.calls, 3)
+	assert.Equal(t, "subtract", o.calls[0].Method)
+	assert.Nil(t, o.calls[0].Error)
+	assert.Equal(t, "child.error", o.calls[1].Method)
+	require.NotNil(t, o.calls[1].Error)
+	assert.Equal(t, 1, o.calls[1].Error.Code)
+	assert.True(t, o.calls[2].Notification)
+	assert.Equal(t, "application/json", o.calls[2].MediaType)
+}
@@ -0,0 +1,78 @@
+// Package openrpc provides the data types of an OpenRPC document.
+//
+// The OpenRPC specification can be found at https://spec.open-rpc.org.
+package openrpc
+
+import "github.com/dwlnetnl/generpc/schema"
+
+// Version is the version of the OpenRPC specification.
+const Version = "1.2.6"
+
+// Document represents an OpenRPC document.
+type Document struct {
+	OpenRPC    string      `json:"openrpc"`
+	Info       Info        `json:"info"`
+	Methods    []Method    `json:"methods"`
+	Components *Components `json:"components,omitempty"`
+}
+
+// Info provides metadata about the API.
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+// Param structures.
+const (
+	ByName     = "by-name"
+	ByPosition = "by-position"
+	Either     = "either"
+)
+
+// Method describes a RPC method. AliasOf, Aliases and DeprecationNotice are
+// extensions that describe method aliases and deprecation.
+type Method struct {
+	Name           string              `json:"name"`
+	Summary        string              `json:"summary,omitempty"`
+	Description    string              `json:"description,omitempty"`
+	ParamStructure string              `json:"paramStructure,omitempty"`
+	Params         []ContentDescriptor `json:"params"`
+	Result         *ContentDescriptor  `json:"result,omitempty"`
+	Errors         []Error             `json:"errors,omitempty"`
+	Deprecated     bool                `json:"deprecated,omitempty"`
+
+	AliasOf           string   `json:"x-alias-of,omitempty"`
+	Aliases           []string `json:"x-aliases,omitempty"`
+	DeprecationNotice string   `json:"x-deprecation,omitempty"`
+}
+
+// ContentDescriptor describes a parameter or result. If Ref is set, it's a
+// reference to a component, like "#/components/contentDescriptors/UserID".
+type ContentDescriptor struct {
+	Ref         string         `json:"$ref,omitempty"`
+	Name        string         `json:"name"`
+	Summary     string         `json:"summary,omitempty"`
+	Description string         `json:"description,omitempty"`
+	Required    bool           `json:"required,omitempty"`
+	Schema      *schema.Schema `json:"schema"`
+	Deprecated  bool           `json:"deprecated,omitempty"`
+}
+
+// Error describes an application defined error. DataSchema is an extension
+// that describes the error data. If Ref is set, it's a reference to a
+// component, like "#/components/errors/user.NotFound".
+type Error struct {
+	Ref        string         `json:"$ref,omitempty"`
+	Code       int            `json:"code"`
+	Message    string         `json:"message"`
+	Data       interface{}    `json:"data,omitempty"`
+	DataSchema *schema.Schema `json:"x-data-schema,omitempty"`
+}
+
+// Components holds reusable objects of the document.
+type Components struct {
+	Schemas            map[string]*schema.Schema    `json:"schemas,omitempty"`
+	ContentDescriptors map[string]ContentDescriptor `json:"contentDescriptors,omitempty"`
+	Errors             map[string]Error             `json:"errors,omitempty"`
+}
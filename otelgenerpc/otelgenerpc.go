@@ -0,0 +1,90 @@
+// Package otelgenerpc adapts an OpenTelemetry tracer to generpc.Tracer.
+//
+//	s := generpc.NewServer()
+//	s.Tracer = otelgenerpc.NewTracer(otel.Tracer("generpc"))
+//
+// The span context the server received from the client is used as remote
+// parent, unless ctx already contains an OpenTelemetry span (for example
+// because the server is wrapped by otelhttp).
+package otelgenerpc
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dwlnetnl/generpc"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// NewTracer returns a generpc.Tracer that starts server spans with t.
+func NewTracer(t trace.Tracer) generpc.Tracer {
+	return tracer{t}
+}
+
+type tracer struct {
+	t trace.Tracer
+}
+
+func (t tracer) Start(ctx context.Context, name string) (context.Context, generpc.Span) {
+	if sc := generpc.SpanContextFromContext(ctx); sc.IsValid() {
+		current := trace.SpanContextFromContext(ctx)
+		if current.SpanID() != trace.SpanID(sc.SpanID) {
+			ctx = trace.ContextWithRemoteSpanContext(ctx, toOTel(sc))
+		}
+	}
+
+	ctx, s := t.t.Start(ctx, name, trace.WithSpanKind(trace.SpanKindServer))
+	ctx = generpc.ContextWithSpanContext(ctx, fromOTel(s.SpanContext()))
+	return ctx, span{s}
+}
+
+type span struct {
+	s trace.Span
+}
+
+func (s span) SetAttribute(key string, value interface{}) {
+	s.s.SetAttributes(attributeOf(key, value))
+}
+
+func (s span) End() {
+	s.s.End()
+}
+
+func attributeOf(key string, value interface{}) attribute.KeyValue {
+	switch v := value.(type) {
+	case string:
+		return attribute.String(key, v)
+	case int:
+		return attribute.Int(key, v)
+	case int64:
+		return attribute.Int64(key, v)
+	case float64:
+		return attribute.Float64(key, v)
+	case bool:
+		return attribute.Bool(key, v)
+	}
+
+	return attribute.String(key, fmt.Sprint(value))
+}
+
+func toOTel(sc generpc.SpanContext) trace.SpanContext {
+	state, _ := trace.ParseTraceState(sc.TraceState)
+	return trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    sc.TraceID,
+		SpanID:     sc.SpanID,
+		TraceFlags: trace.TraceFlags(sc.TraceFlags),
+		TraceState: state,
+		Remote:     sc.Remote,
+	})
+}
+
+func fromOTel(sc trace.SpanContext) generpc.SpanContext {
+	return generpc.SpanContext{
+		TraceID:    sc.TraceID(),
+		SpanID:     sc.SpanID(),
+		TraceFlags: byte(sc.TraceFlags()),
+		TraceState: sc.TraceState().String(),
+		Remote:     sc.IsRemote(),
+	}
+}
@@ -0,0 +1,53 @@
+package otelgenerpc
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dwlnetnl/generpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTracer(t *testing.T) {
+	rec := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec))
+
+	h := generpc.NewServer()
+	h.Tracer = NewTracer(tp.Tracer("test"))
+	h.Register("echo", generpc.Method{
+		Func: func(params []interface{}) interface{} { return params[0] },
+	})
+
+	r := httptest.NewRequest("POST", "/", strings.NewReader(`[
+		{"jsonrpc":"2.0","method":"echo","params":["a"],"id":1},
+		{"jsonrpc":"2.0","method":"missing","params":[],"id":2}
+	]`))
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	r.Header.Set("tracestate", "congo=t61rcWkgMzE")
+	h.ServeHTTP(httptest.NewRecorder(), r)
+
+	spans := rec.Ended()
+	require.Len(t, spans, 3)
+
+	batch := spans[2]
+	assert.Equal(t, "batch", batch.Name())
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", batch.SpanContext().TraceID().String())
+	assert.Equal(t, "00f067aa0ba902b7", batch.Parent().SpanID().String())
+	assert.True(t, batch.Parent().IsRemote())
+	assert.Equal(t, "congo=t61rcWkgMzE", batch.SpanContext().TraceState().String())
+
+	echo, missing := spans[0], spans[1]
+	assert.Equal(t, "echo", echo.Name())
+	assert.Equal(t, batch.SpanContext().SpanID(), echo.Parent().SpanID())
+	assert.Contains(t, echo.Attributes(), attribute.String(generpc.AttrRequestID, "1"))
+	assert.Contains(t, echo.Attributes(), attribute.String(generpc.AttrMediaType, "application/json"))
+
+	assert.Equal(t, batch.SpanContext().SpanID(), missing.Parent().SpanID())
+	assert.Contains(t, missing.Attributes(), attribute.Int(generpc.AttrErrorCode, -32601))
+}
@@ -0,0 +1,122 @@
+package record
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// Writer writes entries to a recording. It's safe for concurrent use.
+type Writer struct {
+	mu  sync.Mutex
+	out io.Writer
+
+	// Set if the recording is a file, see Create.
+	f          *os.File
+	path       string
+	size       int64
+	maxSize    int64
+	maxBackups int
+}
+
+// NewWriter returns a writer that writes the recording to w.
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{out: w}
+}
+
+// Create returns a writer that appends the recording to the file at path. The
+// file is created readable by the owner only, recordings can contain
+// sensitive params and results. When the file exceeds maxSize bytes (zero
+// means no limit) it's rotated: it's renamed to path.1, path.1 to path.2 and
+// so on. At most maxBackups rotated files are kept.
+func Create(path string, maxSize int64, maxBackups int) (*Writer, error) {
+	w := &Writer{path: path, maxSize: maxSize, maxBackups: maxBackups}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+
+	return w, nil
+}
+
+func (w *Writer) open() error {
+	f, err := os.OpenFile(w.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	w.f, w.out, w.size = f, f, fi.Size()
+	return nil
+}
+
+// Write writes the entry as a single line.
+func (w *Writer) Write(e *Entry) error {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	b = append(b, '\n')
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.f != nil && w.maxSize > 0 && w.size > 0 && w.size+int64(len(b)) > w.maxSize {
+		if err := w.rotate(); err != nil {
+			return err
+		}
+	}
+
+	n, err := w.out.Write(b)
+	w.size += int64(n)
+	return err
+}
+
+func (w *Writer) rotate() error {
+	if err := w.f.Close(); err != nil {
+		return err
+	}
+
+	if w.maxBackups <= 0 {
+		if err := os.Remove(w.path); err != nil {
+			return err
+		}
+		return w.open()
+	}
+
+	os.Remove(backup(w.path, w.maxBackups))
+	for i := w.maxBackups - 1; i > 0; i-- {
+		err := os.Rename(backup(w.path, i), backup(w.path, i+1))
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	if err := os.Rename(w.path, backup(w.path, 1)); err != nil {
+		return err
+	}
+
+	return w.open()
+}
+
+func backup(path string, i int) string {
+	return fmt.Sprintf("%s.%d", path, i)
+}
+
+// Close closes the file, if the recording is written to a file.
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.f == nil {
+		return nil
+	}
+
+	return w.f.Close()
+}
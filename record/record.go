@@ -0,0 +1,199 @@
+// Package record records calls handled by a generpc.Server and replays them
+// for debugging.
+//
+// Recordings use the JSON Lines format: each line is a JSON object describing
+// a call, see Entry. For example:
+//
+//	{"time":"2024-01-02T15:04:05.123Z","duration":0.0012,"mediaType":"application/json",
+//	 "request":{"method":"subtract","params":[42,23],"id":"MQ=="},
+//	 "response":{"result":19}}
+//
+// (An entry is a single line, it's wrapped here for readability.)
+//
+// The request and response are recorded as decoded by the coder, so the format
+// is the same for every coder:
+//
+//   - time is the start of the call in RFC 3339 format.
+//   - duration is the duration of the call in seconds.
+//   - mediaType is the media type of the coder that decoded the request.
+//   - principal is the authenticated principal, if any, with its name, roles
+//     and scopes.
+//   - request.method and request.params are the method and params.
+//   - request.id is the coder's wire representation of the request ID,
+//     base64 encoded. It's null for notifications.
+//   - request.extensions and response.extensions are the extensions, if any.
+//   - response.result is the result encoded with encoding/json, or null if
+//     the call failed.
+//   - response.error is the error with code, message and data, if any.
+//
+// Numbers in params are recorded as JSON numbers and are replayed as values
+// that implement coder.Number.
+package record
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"time"
+
+	"github.com/dwlnetnl/generpc"
+	"github.com/dwlnetnl/generpc/coder"
+)
+
+// Entry is a recorded call.
+type Entry struct {
+	Time      time.Time          `json:"time"`
+	Duration  float64            `json:"duration"`
+	MediaType string             `json:"mediaType"`
+	Principal *generpc.Principal `json:"principal,omitempty"`
+	Request   Request            `json:"request"`
+	Response  Response           `json:"response"`
+}
+
+// Request is a recorded coder.Request.
+type Request struct {
+	Method     string                 `json:"method"`
+	Params     interface{}            `json:"params"`
+	ID         coder.RequestID        `json:"id"`
+	Extensions map[string]interface{} `json:"extensions,omitempty"`
+}
+
+// Response is a recorded coder.Response.
+type Response struct {
+	Result     interface{}            `json:"result"`
+	Error      *Error                 `json:"error,omitempty"`
+	Extensions map[string]interface{} `json:"extensions,omitempty"`
+}
+
+// Error is a recorded coder.Error.
+type Error struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+// NewEntry returns the entry for a call.
+func NewEntry(start time.Time, mediaType string, req *coder.Request, resp *coder.Response) *Entry {
+	e := &Entry{
+		Time:      start.UTC(),
+		Duration:  time.Since(start).Seconds(),
+		MediaType: mediaType,
+		Request: Request{
+			Method:     req.Method,
+			Params:     encodeValue(req.Params),
+			ID:         *req.ID,
+			Extensions: encodeMap(req.Extensions),
+		},
+	}
+
+	if resp != nil {
+		e.Response.Result = encodeValue(resp.Result)
+		e.Response.Extensions = encodeMap(resp.Extensions)
+		if resp.Error != nil {
+			e.Response.Error = &Error{
+				Code:    resp.Error.Code,
+				Message: resp.Error.Message,
+				Data:    encodeValue(resp.Error.Data),
+			}
+		}
+	}
+
+	return e
+}
+
+// CoderRequest returns the recorded request.
+func (e *Entry) CoderRequest() *coder.Request {
+	id := e.Request.ID
+	return &coder.Request{
+		Method:     e.Request.Method,
+		Params:     e.Request.Params,
+		ID:         &id,
+		Extensions: e.Request.Extensions,
+	}
+}
+
+// Interceptor returns an interceptor that records every call to w. Errors
+// writing the entry are reported to onError, if not nil.
+func Interceptor(w *Writer, onError func(error)) generpc.Interceptor {
+	return interceptor(w, onError, nil)
+}
+
+// RedactedInterceptor returns an interceptor like Interceptor that records the
+// params with the access log redaction of s applied, see
+// generpc.Server.RedactParams. Redacted calls cannot be replayed faithfully.
+func RedactedInterceptor(s *generpc.Server, w *Writer, onError func(error)) generpc.Interceptor {
+	return interceptor(w, onError, s.RedactParams)
+}
+
+func interceptor(w *Writer, onError func(error), redact func(method string, params interface{}) interface{}) generpc.Interceptor {
+	return func(ctx context.Context, req *coder.Request, next generpc.Invoker) *coder.Response {
+		start := time.Now()
+		resp := next(ctx, req)
+
+		e := NewEntry(start, generpc.MediaTypeFromContext(ctx), req, resp)
+		e.Principal = generpc.PrincipalFromContext(ctx)
+		if redact != nil {
+			e.Request.Params = encodeValue(redact(req.Method, req.Params))
+		}
+		if err := w.Write(e); err != nil && onError != nil {
+			onError(err)
+		}
+
+		return resp
+	}
+}
+
+// Reader reads a recording.
+type Reader struct {
+	s *bufio.Scanner
+}
+
+// NewReader returns a reader that reads the recording from r.
+func NewReader(r io.Reader) *Reader {
+	s := bufio.NewScanner(r)
+	s.Buffer(nil, 64<<20)
+	return &Reader{s}
+}
+
+// Next returns the next entry or io.EOF if there are no more entries.
+func (r *Reader) Next() (*Entry, error) {
+	for r.s.Scan() {
+		line := r.s.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+
+		var e Entry
+		if err := unmarshal(line, &e); err != nil {
+			return nil, err
+		}
+
+		e.Request.Params = decodeValue(e.Request.Params)
+		e.Request.Extensions = decodeMap(e.Request.Extensions)
+		return &e, nil
+	}
+
+	if err := r.s.Err(); err != nil {
+		return nil, err
+	}
+
+	return nil, io.EOF
+}
+
+// ReadAll reads all entries of the recording.
+func ReadAll(r io.Reader) ([]*Entry, error) {
+	var entries []*Entry
+
+	rd := NewReader(r)
+	for {
+		e, err := rd.Next()
+		if err == io.EOF {
+			return entries, nil
+		}
+		if err != nil {
+			return entries, err
+		}
+
+		entries = append(entries, e)
+	}
+}
@@ -0,0 +1,155 @@
+package record
+
+import (
+	"bytes"
+	"context"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dwlnetnl/generpc"
+	"github.com/dwlnetnl/generpc/coder"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func subtract(params []interface{}) interface{} {
+	a, _ := params[0].(coder.Number).CastInt()
+	b, _ := params[1].(coder.Number).CastInt()
+	return a - b
+}
+
+func testServer(fn func([]interface{}) interface{}) *generpc.Server {
+	h := generpc.NewServer()
+	h.Register("subtract", generpc.Method{
+		ParamNames: []string{"minuend", "subtrahend"},
+		Func:       fn,
+	})
+	h.Register("whoami", generpc.Method{
+		FuncContext: func(ctx context.Context, params []interface{}) interface{} {
+			return generpc.PrincipalFromContext(ctx).Name
+		},
+		Authorization: &generpc.Authorization{Roles: []string{"admin"}},
+	})
+	return h
+}
+
+func serve(h *generpc.Server, body string) {
+	r := httptest.NewRequest("POST", "/", strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set("Authorization", "Bearer admin")
+	h.ServeHTTP(httptest.NewRecorder(), r)
+}
+
+func record(t *testing.T) *bytes.Buffer {
+	var buf bytes.Buffer
+	h := testServer(subtract)
+	h.Authenticator = generpc.BearerAuth(func(token string) (*generpc.Principal, error) {
+		return &generpc.Principal{Name: "alice", Roles: []string{"admin"}}, nil
+	})
+	h.Use(Interceptor(NewWriter(&buf), func(err error) { t.Error(err) }))
+
+	serve(h, `[
+		{"jsonrpc":"2.0","method":"subtract","params":[42,23],"id":1},
+		{"jsonrpc":"2.0","method":"subtract","params":{"minuend":1.5,"subtrahend":1},"id":"a"},
+		{"jsonrpc":"2.0","method":"whoami","params":[],"id":2},
+		{"jsonrpc":"2.0","method":"subtract","params":[1,1]},
+		{"jsonrpc":"2.0","method":"missing","params":[],"id":3}
+	]`)
+
+	return &buf
+}
+
+func TestRecord(t *testing.T) {
+	entries, err := ReadAll(record(t))
+	require.NoError(t, err)
+	require.Len(t, entries, 5)
+
+	e := entries[0]
+	assert.Equal(t, "application/json", e.MediaType)
+	assert.Equal(t, "alice", e.Principal.Name)
+	assert.Equal(t, "subtract", e.Request.Method)
+	assert.Equal(t, coder.RequestID("1"), e.Request.ID)
+	assert.Equal(t, []interface{}{Number{"42"}, Number{"23"}}, e.Request.Params)
+	assert.EqualValues(t, "19", e.Response.Result)
+	assert.Nil(t, e.Response.Error)
+	assert.False(t, e.Time.IsZero())
+
+	assert.Equal(t, coder.RequestID(`"a"`), entries[1].Request.ID)
+	assert.Equal(t, map[string]interface{}{"minuend": Number{"1.5"}, "subtrahend": Number{"1"}}, entries[1].Request.Params)
+
+	assert.Nil(t, entries[3].Request.ID)
+	assert.Equal(t, -32601, entries[4].Response.Error.Code)
+}
+
+func TestRedactedInterceptor(t *testing.T) {
+	var buf bytes.Buffer
+	h := testServer(subtract)
+	h.AccessLog = &generpc.AccessLog{RedactFields: []string{"subtrahend"}}
+	h.Use(RedactedInterceptor(h, NewWriter(&buf), func(err error) { t.Error(err) }))
+
+	serve(h, `[
+		{"jsonrpc":"2.0","method":"subtract","params":[42,23],"id":1},
+		{"jsonrpc":"2.0","method":"subtract","params":{"minuend":1.5,"subtrahend":1},"id":2}
+	]`)
+
+	entries, err := ReadAll(&buf)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, []interface{}{Number{"42"}, "[REDACTED]"}, entries[0].Request.Params)
+	assert.Equal(t, map[string]interface{}{"minuend": Number{"1.5"}, "subtrahend": "[REDACTED]"}, entries[1].Request.Params)
+	assert.EqualValues(t, "19", entries[0].Response.Result)
+}
+
+func TestReplay(t *testing.T) {
+	buf := record(t)
+
+	diffs, err := Replay(context.Background(), testServer(subtract), bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+	assert.Empty(t, diffs)
+
+	add := func(params []interface{}) interface{} {
+		a, _ := params[0].(coder.Number).CastInt()
+		b, _ := params[1].(coder.Number).CastInt()
+		return a + b
+	}
+
+	diffs, err = Replay(context.Background(), testServer(add), buf)
+	require.NoError(t, err)
+	require.Len(t, diffs, 2)
+	assert.Equal(t, `subtract (id 1): got {"result":65}, want {"result":19}`, diffs[0].String())
+	assert.Equal(t, `subtract (id "a"): got {"result":1}, want {"result":-1}`, diffs[1].String())
+}
+
+func TestWriter_rotate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "calls.jsonl")
+	w, err := Create(path, 200, 2)
+	require.NoError(t, err)
+
+	req := &coder.Request{Method: "subtract", Params: []interface{}{}, ID: new(coder.RequestID)}
+	e := NewEntry(time.Now(), "application/json", req, coder.NewResult(req, 1))
+	for i := 0; i < 10; i++ {
+		require.NoError(t, w.Write(e))
+	}
+	require.NoError(t, w.Close())
+
+	for _, name := range []string{path, path + ".1", path + ".2"} {
+		fi, err := os.Stat(name)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0o600), fi.Mode().Perm())
+
+		b, err := os.ReadFile(name)
+		require.NoError(t, err)
+		assert.LessOrEqual(t, len(b), 200)
+
+		entries, err := ReadAll(bytes.NewReader(b))
+		require.NoError(t, err)
+		assert.NotEmpty(t, entries)
+	}
+
+	_, err = os.Stat(path + ".3")
+	assert.True(t, os.IsNotExist(err))
+}
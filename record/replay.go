@@ -0,0 +1,92 @@
+package record
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+
+	"github.com/dwlnetnl/generpc"
+)
+
+// Diff describes a replayed call whose response differs from the recorded
+// response.
+type Diff struct {
+	Entry *Entry
+	Got   Response // response of the replayed call
+	Desc  string   // description of the differences
+}
+
+func (d *Diff) String() string {
+	return fmt.Sprintf("%s (id %s): %s", d.Entry.Request.Method, d.Entry.Request.ID, d.Desc)
+}
+
+// Replay invokes the recorded calls on s in order and returns the calls whose
+// response differs from the recorded response. The recorded principal and
+// media type are added to the context of each call. Notifications are
+// invoked but not compared, since they have no response.
+func Replay(ctx context.Context, s *generpc.Server, r io.Reader) ([]*Diff, error) {
+	var diffs []*Diff
+
+	rd := NewReader(r)
+	for {
+		e, err := rd.Next()
+		if err == io.EOF {
+			return diffs, nil
+		}
+		if err != nil {
+			return diffs, err
+		}
+
+		if d := ReplayEntry(ctx, s, e); d != nil {
+			diffs = append(diffs, d)
+		}
+	}
+}
+
+// ReplayEntry invokes the recorded call on s and returns the differences, or
+// nil if the response equals the recorded response.
+func ReplayEntry(ctx context.Context, s *generpc.Server, e *Entry) *Diff {
+	ctx = generpc.ContextWithMediaType(ctx, e.MediaType)
+	if e.Principal != nil {
+		ctx = generpc.ContextWithPrincipal(ctx, e.Principal)
+	}
+
+	req := e.CoderRequest()
+	resp := s.Invoke(ctx, req)
+	if resp == nil {
+		return nil
+	}
+
+	got := NewEntry(e.Time, e.MediaType, req, resp).Response
+
+	desc := compare(e.Response, got)
+	if desc == "" {
+		return nil
+	}
+
+	return &Diff{Entry: e, Got: got, Desc: desc}
+}
+
+// compare describes the differences between the recorded response want and
+// the replayed response got.
+func compare(want, got Response) string {
+	w, err := normalize(want)
+	if err != nil {
+		return "cannot normalize recorded response: " + err.Error()
+	}
+
+	g, err := normalize(got)
+	if err != nil {
+		return "cannot normalize response: " + err.Error()
+	}
+
+	if reflect.DeepEqual(w, g) {
+		return ""
+	}
+
+	wb, _ := json.Marshal(w)
+	gb, _ := json.Marshal(g)
+	return fmt.Sprintf("got %s, want %s", gb, wb)
+}
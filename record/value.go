@@ -0,0 +1,121 @@
+package record
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/dwlnetnl/generpc/coder"
+	"github.com/dwlnetnl/generpc/convert"
+)
+
+// Number is a recorded number, it implements coder.Number.
+type Number struct {
+	json.Number
+}
+
+// CastFloat64 implements coder.Number.
+func (n Number) CastFloat64() (float64, bool) {
+	v, err := n.Float64()
+	return v, err == nil
+}
+
+// CastInt implements coder.Number.
+func (n Number) CastInt() (int, bool) {
+	v, err := n.Int64()
+	return int(v), err == nil && int64(int(v)) == v
+}
+
+// CastUint implements coder.Number.
+func (n Number) CastUint() (uint, bool) {
+	v, ok := n.CastInt()
+	if v < 0 {
+		return 0, false
+	}
+
+	return uint(v), ok
+}
+
+// encodeValue converts v into a value that encoding/json encodes faithfully.
+// Coder specific numbers are converted into json.Number.
+func encodeValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case nil, bool, string, json.Number:
+		return v
+
+	case Number:
+		return v.Number
+
+	case coder.Number:
+		// The text of the number is kept if possible, see convert.Numbers.
+		return convert.Numbers(v)
+
+	case []interface{}:
+		s := make([]interface{}, len(v))
+		for i, e := range v {
+			s[i] = encodeValue(e)
+		}
+		return s
+
+	case map[string]interface{}:
+		return encodeMap(v)
+	}
+
+	return v
+}
+
+func encodeMap(m map[string]interface{}) map[string]interface{} {
+	if m == nil {
+		return nil
+	}
+
+	c := make(map[string]interface{}, len(m))
+	for k, e := range m {
+		c[k] = encodeValue(e)
+	}
+	return c
+}
+
+// decodeValue converts json.Number values in v into Number.
+func decodeValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case json.Number:
+		return Number{v}
+
+	case []interface{}:
+		for i, e := range v {
+			v[i] = decodeValue(e)
+		}
+
+	case map[string]interface{}:
+		return decodeMap(v)
+	}
+
+	return v
+}
+
+func decodeMap(m map[string]interface{}) map[string]interface{} {
+	for k, e := range m {
+		m[k] = decodeValue(e)
+	}
+	return m
+}
+
+// unmarshal decodes data into v with numbers as json.Number.
+func unmarshal(data []byte, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	return dec.Decode(v)
+}
+
+// normalize returns the JSON representation of v as decoded value with
+// numbers as float64, so values can be compared regardless of their Go types.
+func normalize(v interface{}) (interface{}, error) {
+	b, err := json.Marshal(encodeValue(v))
+	if err != nil {
+		return nil, err
+	}
+
+	var n interface{}
+	err = json.Unmarshal(b, &n)
+	return n, err
+}
@@ -0,0 +1,148 @@
+package generpc
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/dwlnetnl/generpc/coder"
+)
+
+// Invoker invokes a request and returns its response. The response of a
+// notification is discarded.
+type Invoker func(ctx context.Context, req *coder.Request) *coder.Response
+
+// Interceptor intercepts the invocation of requests. It should call next to
+// continue the invocation or return a response itself.
+type Interceptor func(ctx context.Context, req *coder.Request, next Invoker) *coder.Response
+
+// Use adds interceptors to the server. The first interceptor is the outermost.
+// Interceptors of a mounted server only intercept requests for its methods,
+// after the interceptors of the parent server. Use should be called before
+// the server is serving requests.
+func (s *Server) Use(interceptors ...Interceptor) {
+	s.interceptors = append(s.interceptors, interceptors...)
+}
+
+// handle invokes the request via the interceptors.
+func (s *Server) handle(ctx context.Context, t methodTable, req *coder.Request) *coder.Response {
+	next := func(ctx context.Context, req *coder.Request) *coder.Response {
+		return s.invoke(ctx, t, req)
+	}
+
+	for i := len(s.interceptors) - 1; i >= 0; i-- {
+		interceptor, inner := s.interceptors[i], next
+		next = func(ctx context.Context, req *coder.Request) *coder.Response {
+			return interceptor(ctx, req, inner)
+		}
+	}
+
+	return next(ctx, req)
+}
+
+// Mount dispatches calls to methods named prefix.method to the child server,
+// which receives the request for method. The child has its own methods,
+// interceptors, limits (like Timeout) and error handling, but it isn't
+// served over HTTP itself. The limits added to s with AddLimit apply to the
+// methods of child as well, by their prefixed name. Its methods are part of
+// the rpc.discover document of the parent. The longest matching prefix is
+// used, registered methods take precedence over mounted servers. It panics
+// if prefix is empty or already mounted, if child is nil or if mounting child
+// would create a cycle.
+func (s *Server) Mount(prefix string, child *Server) {
+	if prefix == "" {
+		panic("generpc: prefix is empty")
+	}
+
+	if child == nil {
+		panic("generpc: invalid child server")
+	}
+
+	key := prefix + "."
+
+	// Mounts are serialized, so concurrent mounts cannot create a cycle.
+	mountMu.Lock()
+	defer mountMu.Unlock()
+
+	if child.mounts(s) {
+		panic("generpc: mounting server creates a cycle: " + prefix)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t := s.methods()
+	if _, ok := t[key]; ok {
+		panic("generpc: prefix already mounted: " + prefix)
+	}
+
+	t = t.clone()
+	t[key] = &Method{mount: child}
+	s.table.Store(t)
+}
+
+// mountKey is the context key of the mount chain of a call.
+type mountKey struct{}
+
+// mountChain describes the servers a mounted server was called through,
+// innermost first.
+type mountChain struct {
+	parent *Server
+	prefix string // prefix of the mounted server, including the dot
+	up     *mountChain
+}
+
+func mountChainFromContext(ctx context.Context) *mountChain {
+	c, _ := ctx.Value(mountKey{}).(*mountChain)
+	return c
+}
+
+// mountMu serializes Mount calls of all servers.
+var mountMu sync.Mutex
+
+// mounts reports whether s is target or mounts target, directly or
+// indirectly.
+func (s *Server) mounts(target *Server) bool {
+	if s == target {
+		return true
+	}
+
+	for _, m := range s.methods() {
+		if m.mount != nil && m.mount.mounts(target) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Unmount removes the server mounted at prefix and reports if a server was
+// mounted.
+func (s *Server) Unmount(prefix string) bool {
+	key := prefix + "."
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t := s.methods()
+	if m, ok := t[key]; !ok || m.mount == nil {
+		return false
+	}
+
+	t = t.clone()
+	delete(t, key)
+	s.table.Store(t)
+	return true
+}
+
+// mount returns the server mounted at the longest prefix of name and the name
+// of the method within that server.
+func (t methodTable) mount(name string) (*Server, string) {
+	for i := strings.LastIndexByte(name, '.'); i > 0; i = strings.LastIndexByte(name[:i], '.') {
+		if m := t[name[:i+1]]; m != nil && m.mount != nil {
+			return m.mount, name[i+1:]
+		}
+	}
+
+	return nil, ""
+}
@@ -0,0 +1,135 @@
+package generpc
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dwlnetnl/generpc/coder"
+)
+
+func recordInterceptor(name string, calls *[]string) Interceptor {
+	return func(ctx context.Context, req *coder.Request, next Invoker) *coder.Response {
+		*calls = append(*calls, name+":"+req.Method)
+		return next(ctx, req)
+	}
+}
+
+func TestUse(t *testing.T) {
+	var calls []string
+
+	h := NewServer()
+	h.Register("subtract", subtractMethod())
+	h.Use(recordInterceptor("a", &calls), recordInterceptor("b", &calls))
+	h.Use(func(ctx context.Context, req *coder.Request, next Invoker) *coder.Response {
+		if req.Method == "blocked" {
+			return coder.Error{Code: 1, Message: "Blocked"}.Response(req)
+		}
+		return next(ctx, req)
+	})
+
+	w := serveJSON(t, h, `{"jsonrpc":"2.0","method":"subtract","params":[42,23],"id":1}`)
+	assert.Equal(t, `{"jsonrpc":"2.0","result":19,"id":1}`+"\n", w.Body.String())
+
+	w = serveJSON(t, h, `{"jsonrpc":"2.0","method":"blocked","params":[],"id":2}`)
+	assert.Equal(t, `{"jsonrpc":"2.0","error":{"code":1,"message":"Blocked"},"id":2}`+"\n", w.Body.String())
+
+	assert.Equal(t, []string{"a:subtract", "b:subtract", "a:blocked", "b:blocked"}, calls)
+}
+
+func TestMount(t *testing.T) {
+	var calls []string
+
+	math := NewServer()
+	math.Register("subtract", subtractMethod())
+	math.Use(recordInterceptor("math", &calls))
+
+	slow := NewServer()
+	slow.Timeout = 10 * time.Millisecond
+	slow.Register("sleep", sleepMethod(time.Second))
+	math.Mount("slow", slow)
+
+	h := NewServer()
+	h.Use(recordInterceptor("root", &calls))
+	h.Register("math.add", valueMethod("registered"))
+	h.Mount("math", math)
+
+	w := serveJSON(t, h, `[
+		{"jsonrpc":"2.0","method":"math.subtract","params":[42,23],"id":1},
+		{"jsonrpc":"2.0","method":"math.add","params":[],"id":2},
+		{"jsonrpc":"2.0","method":"math.missing","params":[],"id":3},
+		{"jsonrpc":"2.0","method":"math.slow.sleep","params":[],"id":4},
+		{"jsonrpc":"2.0","method":"subtract","params":[42,23],"id":5}
+	]`)
+
+	var resps []testResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resps))
+	require.Len(t, resps, 5)
+
+	assert.Equal(t, float64(19), resps[0].Result)
+	assert.Equal(t, "registered", resps[1].Result)
+	assert.Equal(t, -32601, resps[2].Error.Code)
+	assert.Equal(t, TimeoutErrorCode, resps[3].Error.Code)
+	assert.Equal(t, -32601, resps[4].Error.Code)
+
+	want := []string{
+		"root:math.subtract", "math:subtract",
+		"root:math.add",
+		"root:math.missing", "math:missing",
+		"root:math.slow.sleep", "math:slow.sleep",
+		"root:subtract",
+	}
+	assert.Equal(t, want, calls)
+
+	assert.True(t, h.Unmount("math"))
+	assert.False(t, h.Unmount("math"))
+
+	w = serveJSON(t, h, `{"jsonrpc":"2.0","method":"math.subtract","params":[42,23],"id":1}`)
+	want1 := `{"jsonrpc":"2.0","error":{"code":-32601,"message":"Method not found"},"id":1}` + "\n"
+	assert.Equal(t, want1, w.Body.String())
+}
+
+func TestMount_panics(t *testing.T) {
+	h := NewServer()
+	h.Mount("a", NewServer())
+
+	assert.Panics(t, func() { h.Mount("", NewServer()) })
+	assert.Panics(t, func() { h.Mount("b", nil) })
+	assert.Panics(t, func() { h.Mount("b", h) })
+	assert.Panics(t, func() { h.Mount("a", NewServer()) })
+
+	// Cycles
+	a, b, c := NewServer(), NewServer(), NewServer()
+	a.Mount("b", b)
+	b.Mount("c", c)
+	assert.Panics(t, func() { b.Mount("a", a) })
+	assert.Panics(t, func() { c.Mount("a", a) })
+	assert.NotPanics(t, func() { a.Mount("c", c) })
+}
+
+func TestDiscover_mount(t *testing.T) {
+	child := NewServer()
+	child.Register("get.v2", valueMethod(nil))
+	child.Alias("get", "get.v2")
+
+	h := NewServer()
+	h.Register("ping", valueMethod("pong"))
+	h.Mount("user", child)
+
+	var names []string
+	for _, m := range h.Discover().Methods {
+		names = append(names, m.Name)
+		if m.Name == "user.get" {
+			assert.Equal(t, "user.get.v2", m.AliasOf)
+		}
+		if m.Name == "user.get.v2" {
+			assert.Equal(t, []string{"user.get"}, m.Aliases)
+		}
+	}
+
+	assert.Equal(t, []string{"ping", "user.get", "user.get.v2"}, names)
+}
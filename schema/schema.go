@@ -0,0 +1,212 @@
+// Package schema implements a JSON Schema (draft 2020-12) validator for
+// decoded RPC values.
+//
+// Values are validated in their decoded representation: nil, bool, string,
+// numbers (any Go number type or a value implementing CastFloat64, like
+// coder.Number), []interface{} and map[string]interface{}. Other Go values are
+// normalized using their JSON representation.
+//
+// The validator implements the assertion keywords of the core, applicator and
+// validation vocabularies. References ($ref) are resolved within the root
+// schema only, either to the root itself ("#"), to a JSON pointer ("#/...") or
+// to a $defs entry. The format keyword is treated as an annotation.
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+)
+
+// Draft is the URI of the JSON Schema dialect implemented by this package.
+const Draft = "https://json-schema.org/draft/2020-12/schema"
+
+// Schema represents a JSON Schema. The zero value accepts any instance. Use
+// Bool to create a boolean schema.
+type Schema struct {
+	Schema string             `json:"$schema,omitempty"`
+	ID     string             `json:"$id,omitempty"`
+	Ref    string             `json:"$ref,omitempty"`
+	Defs   map[string]*Schema `json:"$defs,omitempty"`
+
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	Format      string `json:"format,omitempty"`
+
+	Type  Types         `json:"type,omitempty"`
+	Enum  []interface{} `json:"enum,omitempty"`
+	Const interface{}   `json:"-"` // see HasConst
+
+	// HasConst indicates if Const is set, this allows a null constant.
+	HasConst bool `json:"-"`
+
+	MultipleOf       *float64 `json:"multipleOf,omitempty"`
+	Maximum          *float64 `json:"maximum,omitempty"`
+	ExclusiveMaximum *float64 `json:"exclusiveMaximum,omitempty"`
+	Minimum          *float64 `json:"minimum,omitempty"`
+	ExclusiveMinimum *float64 `json:"exclusiveMinimum,omitempty"`
+
+	MaxLength *int   `json:"maxLength,omitempty"`
+	MinLength *int   `json:"minLength,omitempty"`
+	Pattern   string `json:"pattern,omitempty"`
+
+	PrefixItems []*Schema `json:"prefixItems,omitempty"`
+	Items       *Schema   `json:"items,omitempty"`
+	Contains    *Schema   `json:"contains,omitempty"`
+	MaxItems    *int      `json:"maxItems,omitempty"`
+	MinItems    *int      `json:"minItems,omitempty"`
+	UniqueItems bool      `json:"uniqueItems,omitempty"`
+
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	PatternProperties    map[string]*Schema `json:"patternProperties,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	PropertyNames        *Schema            `json:"propertyNames,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	MaxProperties        *int               `json:"maxProperties,omitempty"`
+	MinProperties        *int               `json:"minProperties,omitempty"`
+
+	AllOf []*Schema `json:"allOf,omitempty"`
+	AnyOf []*Schema `json:"anyOf,omitempty"`
+	OneOf []*Schema `json:"oneOf,omitempty"`
+	Not   *Schema   `json:"not,omitempty"`
+	If    *Schema   `json:"if,omitempty"`
+	Then  *Schema   `json:"then,omitempty"`
+	Else  *Schema   `json:"else,omitempty"`
+
+	boolean *bool
+}
+
+// Bool returns a boolean schema. The true schema accepts any instance, the
+// false schema rejects every instance.
+func Bool(b bool) *Schema {
+	return &Schema{boolean: &b}
+}
+
+// IsBool reports if s is a boolean schema and its value.
+func (s *Schema) IsBool() (v, ok bool) {
+	if s == nil || s.boolean == nil {
+		return false, false
+	}
+
+	return *s.boolean, true
+}
+
+// Parse parses a JSON encoded schema.
+func Parse(data []byte) (*Schema, error) {
+	s := new(Schema)
+	err := json.Unmarshal(data, s)
+	if err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// MustParse is like Parse but panics if the schema cannot be parsed. It
+// simplifies declaring schemas in package level variables.
+func MustParse(str string) *Schema {
+	s, err := Parse([]byte(str))
+	if err != nil {
+		panic("schema: " + err.Error())
+	}
+
+	return s
+}
+
+// schemaFields prevents recursion in (un)marshaling Schema.
+type schemaFields Schema
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (s *Schema) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+
+	switch string(data) {
+	case "true":
+		*s = *Bool(true)
+		return nil
+	case "false":
+		*s = *Bool(false)
+		return nil
+	}
+
+	var f schemaFields
+	err := json.Unmarshal(data, &f)
+	if err != nil {
+		return err
+	}
+
+	var m map[string]json.RawMessage
+	err = json.Unmarshal(data, &m)
+	if err != nil {
+		return err
+	}
+
+	if raw, ok := m["const"]; ok {
+		err := json.Unmarshal(raw, &f.Const)
+		if err != nil {
+			return err
+		}
+
+		f.HasConst = true
+	}
+
+	*s = Schema(f)
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler.
+func (s *Schema) MarshalJSON() ([]byte, error) {
+	if v, ok := s.IsBool(); ok {
+		return json.Marshal(v)
+	}
+
+	data, err := json.Marshal((*schemaFields)(s))
+	if err != nil || !s.HasConst {
+		return data, err
+	}
+
+	c, err := json.Marshal(s.Const)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(`{"const":`)
+	buf.Write(c)
+	if len(data) > 2 {
+		buf.WriteByte(',')
+	}
+	buf.Write(data[1:])
+	return buf.Bytes(), nil
+}
+
+// Types represents the value of the type keyword. It's encoded as a string if
+// it contains a single type.
+type Types []string
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (t *Types) UnmarshalJSON(data []byte) error {
+	var str string
+	if json.Unmarshal(data, &str) == nil {
+		*t = Types{str}
+		return nil
+	}
+
+	var s []string
+	err := json.Unmarshal(data, &s)
+	if err != nil {
+		return errors.New("schema: type should be a string or array of strings")
+	}
+
+	*t = Types(s)
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler.
+func (t Types) MarshalJSON() ([]byte, error) {
+	if len(t) == 1 {
+		return json.Marshal(t[0])
+	}
+
+	return json.Marshal([]string(t))
+}
@@ -0,0 +1,107 @@
+package schema
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testNumber float64
+
+func (n testNumber) CastFloat64() (float64, bool) { return float64(n), true }
+
+func TestValidate(t *testing.T) {
+	cases := []struct {
+		schema string
+		value  interface{}
+		errs   []ValidationError
+	}{
+		{`true`, "anything", nil},
+		{`false`, 1, []ValidationError{{"", "value is not allowed"}}},
+		{`{}`, nil, nil},
+		{`{"type":"integer"}`, testNumber(42), nil},
+		{`{"type":"integer"}`, 4.2, []ValidationError{{"", "expected integer, got number"}}},
+		{`{"type":"number"}`, 42, nil},
+		{`{"type":["string","null"]}`, nil, nil},
+		{`{"type":"string"}`, true, []ValidationError{{"", "expected string, got boolean"}}},
+		{`{"enum":[1,"a"]}`, "a", nil},
+		{`{"enum":[1,"a"]}`, "b", []ValidationError{{"", "value is not one of the enumerated values"}}},
+		{`{"const":null}`, 0, []ValidationError{{"", "value does not equal the constant"}}},
+		{`{"minimum":1,"exclusiveMaximum":3}`, 3, []ValidationError{{"", "value should be < 3"}}},
+		{`{"multipleOf":2}`, 3, []ValidationError{{"", "value should be a multiple of 2"}}},
+		{`{"minLength":2,"pattern":"^a"}`, "ba", []ValidationError{{"", `value does not match pattern "^a"`}}},
+		{`{"maxLength":1}`, "é", nil},
+		{`{"items":{"type":"string"}}`, []interface{}{"a", 1}, []ValidationError{{"/1", "expected string, got integer"}}},
+		{`{"prefixItems":[{"type":"integer"}],"items":false}`, []interface{}{1, 2}, []ValidationError{{"/1", "value is not allowed"}}},
+		{`{"uniqueItems":true}`, []interface{}{1, 1.0}, []ValidationError{{"", "array items 0 and 1 are equal"}}},
+		{`{"contains":{"const":2}}`, []interface{}{1, 2}, nil},
+		{
+			`{"properties":{"a/b":{"type":"string"}},"required":["c"],"additionalProperties":false}`,
+			map[string]interface{}{"a/b": 1, "d": true},
+			[]ValidationError{
+				{"", `required property "c" is missing`},
+				{"/a~1b", "expected string, got integer"},
+				{"/d", "value is not allowed"},
+			},
+		},
+		{`{"anyOf":[{"type":"string"},{"type":"integer"}]}`, 1, nil},
+		{`{"oneOf":[{"type":"number"},{"type":"integer"}]}`, 1, []ValidationError{{"", "value matches 2 schemas of oneOf instead of exactly one"}}},
+		{`{"not":{"type":"null"}}`, nil, []ValidationError{{"", "value should not match schema of not"}}},
+		{`{"if":{"type":"string"},"then":{"minLength":1},"else":{"type":"integer"}}`, "", []ValidationError{{"", "length should be >= 1"}}},
+		{`{"$defs":{"pos":{"minimum":0}},"items":{"$ref":"#/$defs/pos"}}`, []interface{}{-1}, []ValidationError{{"/0", "value should be >= 0"}}},
+		{`{"$ref":"#/$defs/missing"}`, 1, []ValidationError{{"", `unresolvable reference "#/$defs/missing"`}}},
+		{`{"type":"object"}`, struct{ A int }{1}, nil},
+	}
+
+	for _, c := range cases {
+		s, err := Parse([]byte(c.schema))
+		require.NoError(t, err, c.schema)
+		assert.Equal(t, c.errs, s.Validate(c.value), c.schema)
+	}
+}
+
+func TestValidate_recursive(t *testing.T) {
+	s := MustParse(`{"type":"array","items":{"$ref":"#"}}`)
+	assert.Nil(t, s.Validate([]interface{}{[]interface{}{}}))
+	assert.Equal(t, []ValidationError{{"/0/0", "expected array, got integer"}},
+		s.Validate([]interface{}{[]interface{}{1}}))
+}
+
+func TestValidateAt(t *testing.T) {
+	s := MustParse(`{"type":"string"}`)
+	assert.Equal(t, []ValidationError{{"/name", "expected string, got null"}}, s.ValidateAt("/name", nil))
+}
+
+func TestNilSchema(t *testing.T) {
+	var s *Schema
+	assert.Nil(t, s.Validate(1))
+}
+
+func TestMarshalJSON(t *testing.T) {
+	cases := []string{
+		`true`,
+		`{}`,
+		`{"const":null}`,
+		`{"const":1,"type":"integer"}`,
+		`{"type":["string","null"]}`,
+		`{"items":false,"prefixItems":[true]}`,
+	}
+
+	for _, c := range cases {
+		s := MustParse(c)
+		data, err := json.Marshal(s)
+		require.NoError(t, err)
+		assert.JSONEq(t, c, string(data))
+	}
+}
+
+func TestMustParse(t *testing.T) {
+	assert.Panics(t, func() { MustParse(`{"type":1}`) })
+}
+
+func TestPointer(t *testing.T) {
+	assert.Equal(t, "/a~0b~1c", Pointer("", "a~b/c"))
+	assert.Equal(t, "/0/x", Pointer("/0", "x"))
+}
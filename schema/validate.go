@@ -0,0 +1,518 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// ValidationError describes a single failing assertion. Pointer is the JSON
+// pointer (RFC 6901) to the failing instance location.
+type ValidationError struct {
+	Pointer string
+	Message string
+}
+
+func (e ValidationError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Pointer, e.Message)
+}
+
+// Validate validates v against the schema and returns all failing assertions.
+// A nil schema accepts any instance.
+func (s *Schema) Validate(v interface{}) []ValidationError {
+	return s.ValidateAt("", v)
+}
+
+// ValidateAt is like Validate but prefixes the reported pointers with ptr.
+func (s *Schema) ValidateAt(ptr string, v interface{}) []ValidationError {
+	if s == nil {
+		return nil
+	}
+
+	vd := validator{root: s}
+	vd.validate(s, ptr, Normalize(v))
+	return vd.errs
+}
+
+// Pointer returns the JSON pointer of token appended to ptr.
+func Pointer(ptr, token string) string {
+	token = strings.Replace(token, "~", "~0", -1)
+	token = strings.Replace(token, "/", "~1", -1)
+	return ptr + "/" + token
+}
+
+type number interface {
+	CastFloat64() (float64, bool)
+}
+
+// Normalize returns the decoded representation of v, see the package
+// documentation. Numbers are returned as float64.
+func Normalize(v interface{}) interface{} {
+	switch v := v.(type) {
+	case nil, bool, string, float64:
+		return v
+
+	case number:
+		f, ok := v.CastFloat64()
+		if !ok {
+			return math.NaN()
+		}
+		return f
+
+	case json.Number:
+		f, err := v.Float64()
+		if err != nil {
+			return math.NaN()
+		}
+		return f
+
+	case []interface{}:
+		s := make([]interface{}, len(v))
+		for i, e := range v {
+			s[i] = Normalize(e)
+		}
+		return s
+
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			m[k] = Normalize(e)
+		}
+		return m
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(rv.Uint())
+	case reflect.Float32, reflect.Float64:
+		return rv.Float()
+	case reflect.String:
+		return rv.String()
+	case reflect.Bool:
+		return rv.Bool()
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+
+	var n interface{}
+	if json.Unmarshal(data, &n) != nil {
+		return v
+	}
+
+	return n
+}
+
+type validator struct {
+	root  *Schema
+	errs  []ValidationError
+	depth int
+}
+
+func (vd *validator) fail(ptr, format string, args ...interface{}) {
+	vd.errs = append(vd.errs, ValidationError{ptr, fmt.Sprintf(format, args...)})
+}
+
+// valid reports if v is valid against s without recording errors.
+func (vd *validator) valid(s *Schema, ptr string, v interface{}) bool {
+	sub := validator{root: vd.root, depth: vd.depth}
+	sub.validate(s, ptr, v)
+	return len(sub.errs) == 0
+}
+
+// maxDepth limits the $ref recursion depth.
+const maxDepth = 64
+
+func (vd *validator) validate(s *Schema, ptr string, v interface{}) {
+	if s == nil {
+		return
+	}
+
+	if b, ok := s.IsBool(); ok {
+		if !b {
+			vd.fail(ptr, "value is not allowed")
+		}
+		return
+	}
+
+	if s.Ref != "" {
+		ref, err := vd.resolve(s.Ref)
+		if err != nil {
+			vd.fail(ptr, "%v", err)
+		} else if vd.depth >= maxDepth {
+			vd.fail(ptr, "reference depth exceeded")
+		} else {
+			vd.depth++
+			vd.validate(ref, ptr, v)
+			vd.depth--
+		}
+	}
+
+	if len(s.Type) > 0 && !matchesType(s.Type, v) {
+		vd.fail(ptr, "expected %s, got %s", strings.Join(s.Type, " or "), typeOf(v))
+	}
+
+	if s.Enum != nil {
+		found := false
+		for _, e := range s.Enum {
+			if equal(Normalize(e), v) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			vd.fail(ptr, "value is not one of the enumerated values")
+		}
+	}
+
+	if s.HasConst && !equal(Normalize(s.Const), v) {
+		vd.fail(ptr, "value does not equal the constant")
+	}
+
+	switch v := v.(type) {
+	case float64:
+		vd.validateNumber(s, ptr, v)
+	case string:
+		vd.validateString(s, ptr, v)
+	case []interface{}:
+		vd.validateArray(s, ptr, v)
+	case map[string]interface{}:
+		vd.validateObject(s, ptr, v)
+	}
+
+	for _, sub := range s.AllOf {
+		vd.validate(sub, ptr, v)
+	}
+
+	if s.AnyOf != nil {
+		ok := false
+		for _, sub := range s.AnyOf {
+			if vd.valid(sub, ptr, v) {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			vd.fail(ptr, "value does not match any schema of anyOf")
+		}
+	}
+
+	if s.OneOf != nil {
+		n := 0
+		for _, sub := range s.OneOf {
+			if vd.valid(sub, ptr, v) {
+				n++
+			}
+		}
+		if n != 1 {
+			vd.fail(ptr, "value matches %d schemas of oneOf instead of exactly one", n)
+		}
+	}
+
+	if s.Not != nil && vd.valid(s.Not, ptr, v) {
+		vd.fail(ptr, "value should not match schema of not")
+	}
+
+	if s.If != nil {
+		if vd.valid(s.If, ptr, v) {
+			vd.validate(s.Then, ptr, v)
+		} else {
+			vd.validate(s.Else, ptr, v)
+		}
+	}
+}
+
+func (vd *validator) validateNumber(s *Schema, ptr string, v float64) {
+	if math.IsNaN(v) {
+		vd.fail(ptr, "number cannot be represented")
+		return
+	}
+
+	if s.MultipleOf != nil && *s.MultipleOf > 0 {
+		q := v / *s.MultipleOf
+		if q != math.Trunc(q) {
+			vd.fail(ptr, "value should be a multiple of %v", *s.MultipleOf)
+		}
+	}
+
+	if s.Maximum != nil && v > *s.Maximum {
+		vd.fail(ptr, "value should be <= %v", *s.Maximum)
+	}
+
+	if s.ExclusiveMaximum != nil && v >= *s.ExclusiveMaximum {
+		vd.fail(ptr, "value should be < %v", *s.ExclusiveMaximum)
+	}
+
+	if s.Minimum != nil && v < *s.Minimum {
+		vd.fail(ptr, "value should be >= %v", *s.Minimum)
+	}
+
+	if s.ExclusiveMinimum != nil && v <= *s.ExclusiveMinimum {
+		vd.fail(ptr, "value should be > %v", *s.ExclusiveMinimum)
+	}
+}
+
+func (vd *validator) validateString(s *Schema, ptr string, v string) {
+	n := utf8.RuneCountInString(v)
+
+	if s.MaxLength != nil && n > *s.MaxLength {
+		vd.fail(ptr, "length should be <= %d", *s.MaxLength)
+	}
+
+	if s.MinLength != nil && n < *s.MinLength {
+		vd.fail(ptr, "length should be >= %d", *s.MinLength)
+	}
+
+	if s.Pattern != "" {
+		re, err := compile(s.Pattern)
+		if err != nil {
+			vd.fail(ptr, "invalid pattern: %v", err)
+		} else if !re.MatchString(v) {
+			vd.fail(ptr, "value does not match pattern %q", s.Pattern)
+		}
+	}
+}
+
+func (vd *validator) validateArray(s *Schema, ptr string, v []interface{}) {
+	if s.MaxItems != nil && len(v) > *s.MaxItems {
+		vd.fail(ptr, "array should have at most %d items", *s.MaxItems)
+	}
+
+	if s.MinItems != nil && len(v) < *s.MinItems {
+		vd.fail(ptr, "array should have at least %d items", *s.MinItems)
+	}
+
+	if s.UniqueItems {
+	unique:
+		for i := range v {
+			for j := i + 1; j < len(v); j++ {
+				if equal(v[i], v[j]) {
+					vd.fail(ptr, "array items %d and %d are equal", i, j)
+					break unique
+				}
+			}
+		}
+	}
+
+	for i, e := range v {
+		p := Pointer(ptr, strconv.Itoa(i))
+		if i < len(s.PrefixItems) {
+			vd.validate(s.PrefixItems[i], p, e)
+		} else {
+			vd.validate(s.Items, p, e)
+		}
+	}
+
+	if s.Contains != nil {
+		found := false
+		for i, e := range v {
+			if vd.valid(s.Contains, Pointer(ptr, strconv.Itoa(i)), e) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			vd.fail(ptr, "array does not contain a matching item")
+		}
+	}
+}
+
+func (vd *validator) validateObject(s *Schema, ptr string, v map[string]interface{}) {
+	if s.MaxProperties != nil && len(v) > *s.MaxProperties {
+		vd.fail(ptr, "object should have at most %d properties", *s.MaxProperties)
+	}
+
+	if s.MinProperties != nil && len(v) < *s.MinProperties {
+		vd.fail(ptr, "object should have at least %d properties", *s.MinProperties)
+	}
+
+	for _, name := range s.Required {
+		if _, ok := v[name]; !ok {
+			vd.fail(ptr, "required property %q is missing", name)
+		}
+	}
+
+	// Iterate in a stable order so errors are reported deterministically.
+	keys := make([]string, 0, len(v))
+	for k := range v {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		p := Pointer(ptr, k)
+
+		if s.PropertyNames != nil {
+			vd.validate(s.PropertyNames, p, k)
+		}
+
+		matched := false
+		if sub, ok := s.Properties[k]; ok {
+			matched = true
+			vd.validate(sub, p, v[k])
+		}
+
+		for pattern, sub := range s.PatternProperties {
+			re, err := compile(pattern)
+			if err != nil {
+				vd.fail(ptr, "invalid pattern: %v", err)
+				continue
+			}
+			if re.MatchString(k) {
+				matched = true
+				vd.validate(sub, p, v[k])
+			}
+		}
+
+		if !matched && s.AdditionalProperties != nil {
+			vd.validate(s.AdditionalProperties, p, v[k])
+		}
+	}
+}
+
+func (vd *validator) resolve(ref string) (*Schema, error) {
+	if ref == "#" {
+		return vd.root, nil
+	}
+
+	if !strings.HasPrefix(ref, "#/") {
+		return nil, fmt.Errorf("unsupported reference %q", ref)
+	}
+
+	tokens := strings.Split(ref[2:], "/")
+	for i, t := range tokens {
+		t = strings.Replace(t, "~1", "/", -1)
+		tokens[i] = strings.Replace(t, "~0", "~", -1)
+	}
+
+	s := vd.root
+	for i := 0; i < len(tokens) && s != nil; i++ {
+		switch tokens[i] {
+		case "$defs", "properties", "patternProperties":
+			if i+1 == len(tokens) {
+				s = nil
+				break
+			}
+			var m map[string]*Schema
+			switch tokens[i] {
+			case "$defs":
+				m = s.Defs
+			case "properties":
+				m = s.Properties
+			default:
+				m = s.PatternProperties
+			}
+			i++
+			s = m[tokens[i]]
+
+		case "items":
+			s = s.Items
+		case "additionalProperties":
+			s = s.AdditionalProperties
+		case "not":
+			s = s.Not
+
+		default:
+			s = nil
+		}
+	}
+
+	if s == nil {
+		return nil, fmt.Errorf("unresolvable reference %q", ref)
+	}
+
+	return s, nil
+}
+
+var patterns sync.Map // map[string]*regexp.Regexp
+
+func compile(pattern string) (*regexp.Regexp, error) {
+	if re, ok := patterns.Load(pattern); ok {
+		return re.(*regexp.Regexp), nil
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	patterns.Store(pattern, re)
+	return re, nil
+}
+
+func typeOf(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case float64:
+		if v == math.Trunc(v) && !math.IsInf(v, 0) {
+			return "integer"
+		}
+		return "number"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+
+	return fmt.Sprintf("%T", v)
+}
+
+func matchesType(types Types, v interface{}) bool {
+	actual := typeOf(v)
+
+	for _, t := range types {
+		if t == actual || t == "number" && actual == "integer" {
+			return true
+		}
+	}
+
+	return false
+}
+
+func equal(a, b interface{}) bool {
+	switch a := a.(type) {
+	case []interface{}:
+		b, ok := b.([]interface{})
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for i := range a {
+			if !equal(a[i], b[i]) {
+				return false
+			}
+		}
+		return true
+
+	case map[string]interface{}:
+		b, ok := b.(map[string]interface{})
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for k, v := range a {
+			w, ok := b[k]
+			if !ok || !equal(v, w) {
+				return false
+			}
+		}
+		return true
+	}
+
+	return a == b
+}
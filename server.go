@@ -1,11 +1,18 @@
 package generpc
 
 import (
+	"context"
 	"fmt"
+	"log"
 	"net/http"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/dwlnetnl/generpc/coder"
+	"github.com/dwlnetnl/generpc/openrpc"
+	"github.com/dwlnetnl/generpc/schema"
 )
 
 // Method represents a RPC method.
@@ -15,43 +22,193 @@ import (
 //
 // Func is the actual function that is called by the Server. It gets the
 // parameters passed via the slice and should return the result. This may be a
-// coder.Error. The passed parameters are in by-position representation.
+// coder.Error or an error, see Server.ErrorFallback for how errors are mapped.
+// The passed parameters are in by-position representation.
+//
+// FuncContext can be set instead of Func if the method needs the context of
+// the call. The context is cancelled when the call times out, see Timeout.
+//
+// Timeout optionally limits the duration of a call, it overrides
+// Server.Timeout. When it expires the client receives a timeout error, even if
+// the function doesn't return in time.
+//
+// OptionalParams optionally sets the number of trailing parameters that can
+// be omitted. Omitted by-name parameters are passed as nil, omitted
+// by-position parameters aren't passed. Omitted parameters aren't validated.
+//
+// ParamSchemas optionally contains a JSON Schema for each parameter in
+// by-position order, a nil entry accepts any value. Parameters are validated
+// before Func is called. ResultSchema optionally describes the result, it's
+// validated in development mode (see Server.Development). Both are used to
+// describe the method in the rpc.discover document.
+//
+// Errors optionally lists the application errors the method may return, see
+// DefineError. It's only used to describe the method.
+//
+// Deprecated optionally marks the method as deprecated, it contains a notice
+// like "use user.get.v2 instead". See Server.Deprecate.
+//
+// Authorization optionally restricts the method to authenticated callers, see
+// Server.Authenticator.
+//
+// ParamStructure optionally restricts the params to openrpc.ByName or
+// openrpc.ByPosition, other params are rejected. The default accepts either.
+// By-name params require a name for every param in ParamSchemas.
+//
+// CacheTTL optionally marks the method as pure, results are cached for the
+// duration by method name and params. Cached calls don't invoke Func. See
+// Server.InvalidateCache and Server.CacheStats.
 type Method struct {
-	ParamNames []string
-	Func       func([]interface{}) interface{}
+	ParamNames     []string
+	OptionalParams int
+	Func           func([]interface{}) interface{}
+	FuncContext    func(context.Context, []interface{}) interface{}
+	Timeout        time.Duration
+	ParamSchemas   []*schema.Schema
+	ResultSchema   *schema.Schema
+	Errors         []coder.Error
+	Deprecated     string
+	ParamStructure string
+
+	Authorization *Authorization
+	CacheTTL      time.Duration
+
+	aliasOf string  // name of the target method if the method is an alias
+	mount   *Server // mounted server if the entry is a mount point
 }
 
 // Server implements a RPC HTTP handler.
 type Server struct {
-	m map[string]*Method
+	// Info describes the API in the rpc.discover document.
+	Info openrpc.Info
+
+	// Development enables validation of results against Method.ResultSchema.
+	Development bool
+
+	// DeprecationNotices enables adding the deprecation notice to responses
+	// of deprecated methods. It's added as "deprecation" extension, see
+	// coder.Response.
+	DeprecationNotices bool
+
+	// ErrorFallback maps an error returned by Method.Func that isn't a
+	// *coder.Error or RPCError to a RPC error. If nil, an "Internal error" is
+	// returned to the client.
+	ErrorFallback func(err error) *coder.Error
+
+	// Timeout is the default value of Method.Timeout. Zero means no timeout.
+	Timeout time.Duration
+
+	// BatchTimeout optionally limits the duration of a batch. Requests that
+	// have not finished when it expires receive a timeout error.
+	BatchTimeout time.Duration
+
+	// DuplicateIDs controls the handling of requests in a batch that share the
+	// same ID. The default is to allow them.
+	DuplicateIDs DuplicateIDPolicy
+
+	// Authenticator optionally authenticates HTTP requests. The principal is
+	// available via PrincipalFromContext. Calls to methods that require
+	// authorization (see Method.Authorization) fail with an unauthorized or
+	// forbidden error per request, other requests in a batch are unaffected.
+	// If authentication fails, every call fails with an unauthorized error.
+	// Mounted servers use the principal of the parent.
+	Authenticator Authenticator
+
+	// AsyncNotifications enables acknowledging payloads that only contain
+	// notifications immediately with HTTP 204 No Content. The notifications are
+	// processed by NotificationWorkers goroutines (default 1) via a queue with
+	// capacity NotificationQueue (default 64). When the queue is full, the
+	// HTTP request blocks until there is room. See DrainNotifications.
+	AsyncNotifications  bool
+	NotificationWorkers int
+	NotificationQueue   int
+
+	// NotificationError is optionally called when a notification results in a
+	// RPC error, since the client never receives it.
+	NotificationError func(req *coder.Request, e *coder.Error)
+
+	// CacheSize limits the number of results cached for methods with
+	// Method.CacheTTL. The default is 1024.
+	CacheSize int
+
+	// Observer is optionally notified of handled payloads and calls, for
+	// example to collect metrics. Calls of mounted servers are observed by the
+	// parent with their prefixed name.
+	Observer Observer
+
+	// Tracer optionally creates a span for each call, batches have a parent
+	// span. The span context of the client is read from the traceparent and
+	// tracestate headers and is available via SpanContextFromContext.
+	Tracer Tracer
+
+	// AccessLog optionally enables structured logging of each call. Calls of
+	// mounted servers are logged by the parent with their prefixed name.
+	AccessLog *AccessLog
+
+	// ErrorLog specifies an optional logger for errors that are mapped with
+	// ErrorFallback. If nil, logging is done via the log package's standard
+	// logger.
+	ErrorLog *log.Logger
+
+	mu           sync.Mutex   // serializes method table updates
+	table        atomic.Value // methodTable
+	interceptors []Interceptor
+	limits       []*limiter
+	n            notifier
+	life         lifecycle
+	cache        resultCache
+
+	deprecated struct {
+		sync.Mutex
+		calls map[string]uint64
+	}
 }
 
 // NewServer returns an initialized handler.
-func NewServer() *Server { return &Server{m: make(map[string]*Method)} }
+func NewServer() *Server {
+	s := new(Server)
+	s.table.Store(methodTable{})
+	s.life.ctx, s.life.cancel = context.WithCancelCause(context.Background())
+	return s
+}
 
 // Register registers a RPC method for the given name. It panics if name is
-// empty or Method.Func is nil or if there is already a method for the name
-// registered. It's considered a programmer error to register a method after
-// the HTTP server is serving requests.
+// empty, if not exactly one of Method.Func and Method.FuncContext is set or if
+// there is already a method for the name registered. It's safe to register
+// methods while the server is serving requests, see also Unregister, Replace
+// and SwapMethods.
 func (s *Server) Register(name string, m Method) {
-	if name == "" {
-		panic("generpc: name is empty")
-	}
+	checkMethod(name, m)
 
-	if m.Func == nil {
-		panic("generpc: Method.Func is nil")
-	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-	if _, ok := s.m[name]; ok {
+	t := s.methods()
+	if _, ok := t[name]; ok {
 		panic("generpc: method already exists: " + name)
 	}
 
-	s.m[name] = &m
+	t = t.clone()
+	t[name] = &m
+	s.table.Store(t)
 }
 
 func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	serving := s.begin()
+	if serving {
+		defer s.end()
+	}
+
+	info := PayloadInfo{MediaType: r.Header.Get("Content-Type")}
+	defer s.observePayload(time.Now(), &info)
+
+	// Authenticate before the body is read by the coder.
+	ctx := context.WithValue(s.authenticate(r), httpRequestKey{}, r)
+	ctx = extractTrace(ctx, r)
+
 	c := coder.New(w, r)
 	if c == nil {
+		info.MediaType = OtherMediaType
 		ct := r.Header.Get("Content-Type")
 		msg := fmt.Sprintf("media type %q is not supported", ct)
 		http.Error(w, msg, http.StatusUnsupportedMediaType)
@@ -64,30 +221,95 @@ func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusMethodNotAllowed)
 		w.Header().Set("Allow", "POST")
 		e := coder.ParseError.WithString("invalid HTTP method")
+		info.Error = e
 		c.WriteResponse(e.Response(nil))
 		return
 	}
 
 	if r.ContentLength == 0 {
 		e := coder.ParseError.WithString("empty POST body")
+		info.Error = e
 		c.WriteResponse(e.Response(nil))
 		return
 	}
 
 	reqs, batch, e := c.ReadRequests()
 	if e != nil {
+		info.Error = e
 		c.WriteResponse(e.Response(nil))
 		return
 	}
 
+	info.Batch = batch
+	info.Requests = len(reqs)
+
+	if !serving {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+
+	if serving && s.AsyncNotifications && onlyNotifications(reqs) {
+		s.enqueueNotifications(ctx, s.methods(), reqs)
+		w.Header().Del("Content-Type")
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	var dups map[int]bool
+	if batch && s.DuplicateIDs != AllowDuplicateIDs {
+		dups = duplicateIDs(c, reqs)
+		if len(dups) > 0 && s.DuplicateIDs == RejectDuplicateBatch {
+			e := duplicateID
+			info.Error = &e
+			c.WriteResponse(e.Response(nil))
+			return
+		}
+	}
+
+	t := s.methods()
+	ctx, cancel := s.withShutdown(ctx)
+	defer cancel()
+
+	if batch {
+		var span Span
+		ctx, span = s.tracer().Start(ctx, "batch")
+		span.SetAttribute(AttrRPCSystem, "jsonrpc")
+		span.SetAttribute(AttrMediaType, info.MediaType)
+		span.SetAttribute(AttrBatchSize, len(reqs))
+		defer span.End()
+	}
+
+	if batch && s.BatchTimeout > 0 {
+		var cancel context.CancelFunc
+		cause := &timeoutError{s.BatchTimeout, "batch"}
+		ctx, cancel = context.WithTimeoutCause(ctx, s.BatchTimeout, cause)
+		defer cancel()
+	}
+
 	var resps []*coder.Response
-	for _, req := range reqs {
+	for i, req := range reqs {
 		if req == nil {
 			resps = append(resps, coder.InvalidRequest.Response(nil))
 			continue
 		}
 
-		resp := s.invokeRequest(req)
+		if req.Error != nil {
+			resps = append(resps, req.Error.Response(req))
+			continue
+		}
+
+		if dups[i] {
+			resps = append(resps, duplicateID.Response(req))
+			continue
+		}
+
+		if !serving {
+			if *req.ID != nil {
+				resps = append(resps, shuttingDown.Response(req))
+			}
+			continue
+		}
+
+		resp := s.invokeRequest(s.withBatchIndex(ctx, batch, i), t, req)
 		if resp == nil {
 			// Notifications should not return a response.
 			continue
@@ -98,21 +320,27 @@ func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 	var err error
 	if batch {
-		err = c.WriteResponses(resps)
+		// A batch of notifications only has no response, not an empty
+		// array (JSON-RPC 2.0 section 6).
+		if len(resps) > 0 {
+			err = c.WriteResponses(resps)
+		}
 	} else {
 		switch len(resps) {
 		case 0:
 			// Request was notification.
 		case 1:
+			setRetryAfter(w, resps[0])
 			err = c.WriteResponse(resps[0])
 		default:
 			const errorCode = -32091
-			e := coder.ServerError(errorCode).WithString("multiple responses")
+			e := coder.Error{Code: errorCode, Message: "Server error"}.WithString("multiple responses")
 			err = c.WriteResponse(e.Response(nil))
 		}
 	}
 
 	if err != nil {
+		s.logWriteError(ctx, err)
 		err := c.WriteException(nil, err)
 		if err != nil {
 			http.Error(w, "error: "+err.Error(), http.StatusInternalServerError)
@@ -120,36 +348,144 @@ func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// Invoke invokes a single request that wasn't received via HTTP, for example
+// to replay a recorded call. The response is nil if the request is a
+// notification. See ContextWithPrincipal and ContextWithMediaType for the
+// values ServeHTTP adds to the context.
+func (s *Server) Invoke(ctx context.Context, req *coder.Request) *coder.Response {
+	if !s.begin() {
+		if *req.ID == nil {
+			return nil
+		}
+
+		return shuttingDown.Response(req)
+	}
+	defer s.end()
+
+	ctx, cancel := s.withShutdown(ctx)
+	defer cancel()
+
+	return s.invokeRequest(ctx, s.methods(), req)
+}
+
 // JSON-RPC 2.0 specification:
-//   The method does not exist / is not available.
+//
+//	The method does not exist / is not available.
 var methodNotFound = coder.Error{Code: -32601, Message: "Method not found"}
 
 // JSON-RPC 2.0 specification:
-//   Internal JSON-RPC error.
+//
+//	Internal JSON-RPC error.
 var internalError = coder.Error{Code: -32603, Message: "Internal error"}
 
 // JSON-RPC 2.0 specification:
-//   Invalid method parameter(s).
+//
+//	Invalid method parameter(s).
 var invalidParams = coder.Error{Code: -32602, Message: "Invalid params"}
 
-func (s *Server) invokeRequest(req *coder.Request) *coder.Response {
+// invokeRequest invokes the request and returns the response. Nil is returned
+// if the request is a notification, failures are reported to
+// Server.NotificationError.
+func (s *Server) invokeRequest(ctx context.Context, t methodTable, req *coder.Request) *coder.Response {
+	start := time.Now()
+	ctx, span := s.startSpan(ctx, req)
+	resp := s.handle(ctx, t, req)
+	endSpan(span, resp)
+	s.observeCall(ctx, t, start, req, resp)
+	s.logCall(ctx, t, start, req, resp)
+
+	if *req.ID == nil {
+		// Request is a notification.
+		if resp.Error != nil && s.NotificationError != nil {
+			s.NotificationError(req, resp.Error)
+		}
+
+		return nil
+	}
+
+	return resp
+}
+
+func (s *Server) invoke(ctx context.Context, t methodTable, req *coder.Request) *coder.Response {
+	if req.Method == discoverMethod {
+		return coder.NewResult(req, s.discover(t, authorized(ctx)))
+	}
+
 	if req.Method == "" || strings.HasPrefix(req.Method, "rpc.") {
 		return methodNotFound.Response(req)
 	}
 
-	m, ok := s.m[req.Method]
-	if !ok || m == nil {
+	m, name, notice := t.lookup(req.Method)
+	if m == nil {
+		if child, name := t.mount(req.Method); child != nil {
+			sub := *req
+			sub.Method = name
+			ctx = context.WithValue(ctx, mountKey{}, &mountChain{
+				parent: s,
+				prefix: req.Method[:len(req.Method)-len(name)],
+				up:     mountChainFromContext(ctx),
+			})
+			return child.handle(ctx, child.methods(), &sub)
+		}
+
 		return methodNotFound.Response(req)
 	}
 
+	if notice == "" {
+		return s.invokeMethod(ctx, name, m, req)
+	}
+
+	s.deprecatedCall(req.Method, notice)
+	resp := s.invokeMethod(ctx, name, m, req)
+	if s.DeprecationNotices {
+		resp.Extensions = map[string]interface{}{deprecationExtension: notice}
+	}
+
+	return resp
+}
+
+// invokeMethod invokes the method m with the canonical name, see lookup.
+func (s *Server) invokeMethod(ctx context.Context, name string, m *Method, req *coder.Request) *coder.Response {
+	if e := m.authorize(ctx); e != nil {
+		return e.Response(req)
+	}
+
+	release, limited := s.acquireCall(ctx, name)
+	if limited != nil {
+		return limited.Response(req)
+	}
+
+	// The limits are released when the method function returns, which can be
+	// after call returned (see call).
+	calling := false
+	defer func() {
+		if !calling {
+			release()
+		}
+	}()
+
 	var params []interface{}
 	switch v := req.Params.(type) {
 	case []interface{}:
+		if m.ParamStructure == openrpc.ByName {
+			info := "params should be by-name (object)"
+			return invalidParams.WithString(info).Response(req)
+		}
+
 		params = v
 
 	case map[string]interface{}:
-		for _, name := range m.ParamNames {
+		if m.ParamStructure == openrpc.ByPosition {
+			info := "params should be by-position (array)"
+			return invalidParams.WithString(info).Response(req)
+		}
+
+		for i, name := range m.ParamNames {
 			p, ok := v[name]
+			if !ok && m.optional(i) {
+				params = append(params, nil)
+				continue
+			}
 			if !ok {
 				info := fmt.Sprintf("Parameter %q not provided", name)
 				return invalidParams.WithString(info).Response(req)
@@ -163,18 +499,46 @@ func (s *Server) invokeRequest(req *coder.Request) *coder.Response {
 		return invalidParams.WithString(info).Response(req)
 	}
 
-	result := m.Func(params)
+	if errs := m.validateParams(req.Params, params); errs != nil {
+		return validationError(invalidParams, errs).Response(req)
+	}
 
-	if *req.ID == nil {
-		// Request is a notification.
-		return nil
+	var key string
+	if m.CacheTTL > 0 {
+		key = cacheKey(name, params)
 	}
 
+	if key != "" {
+		if result, ok := s.cachedResult(key, name); ok {
+			return coder.NewResult(req, result)
+		}
+	}
+
+	calling = true
+	result := s.call(ctx, m, params, release)
+
+	var e *coder.Error
 	switch v := result.(type) {
 	case coder.Error:
-		return v.Response(req)
+		e = &v
 
-	default:
-		return coder.NewResult(req, result)
+	case error:
+		e = s.mapError(req.Method, v)
+	}
+
+	if e != nil {
+		return e.Response(req)
 	}
+
+	if s.Development && *req.ID != nil {
+		if errs := m.ResultSchema.Validate(result); errs != nil {
+			return validationError(internalError, errs).Response(req)
+		}
+	}
+
+	if key != "" {
+		s.cacheResult(key, result, m.CacheTTL)
+	}
+
+	return coder.NewResult(req, result)
 }
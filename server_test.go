@@ -1,11 +1,17 @@
 package generpc
 
 import (
+	"context"
+	"io"
+	"log"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
 
+	"github.com/dwlnetnl/generpc/coder"
+	"github.com/dwlnetnl/generpc/openrpc"
+	"github.com/dwlnetnl/generpc/schema"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -23,3 +29,148 @@ func TestInvalidContentType(t *testing.T) {
 	want := `media type "invalid/type" is not supported` + "\n"
 	assert.Equal(t, want, w.Body.String())
 }
+
+var discardLog = log.New(io.Discard, "", 0)
+
+func serveJSON(t *testing.T, h http.Handler, body string) *httptest.ResponseRecorder {
+	r, err := http.NewRequest("POST", "/", strings.NewReader(body))
+	r.Header.Add("Content-Type", "application/json")
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+type testResponse struct {
+	Result interface{} `json:"result"`
+	Error  *struct {
+		Code int                    `json:"code"`
+		Data map[string]interface{} `json:"data"`
+	} `json:"error"`
+	ID interface{} `json:"id"`
+}
+
+func TestInvoke(t *testing.T) {
+	o := new(recordObserver)
+	h := NewServer()
+	h.Observer = o
+	h.Register("subtract", subtractMethod())
+	h.Register("whoami", whoamiMethod(&Authorization{Roles: []string{"admin"}}))
+
+	id := coder.RequestID("1")
+	req := &coder.Request{Method: "subtract", Params: []interface{}{jsonNumber{"42"}, jsonNumber{"23"}}, ID: &id}
+	ctx := ContextWithMediaType(context.Background(), "application/test")
+	resp := h.Invoke(ctx, req)
+	assert.Equal(t, 19, resp.Result)
+	assert.Equal(t, &id, resp.ID)
+	assert.Equal(t, "application/test", o.calls[0].MediaType)
+
+	req = &coder.Request{Method: "whoami", Params: []interface{}{}, ID: &id}
+	ctx = ContextWithPrincipal(context.Background(), &Principal{Name: "alice", Roles: []string{"admin"}})
+	assert.Equal(t, "alice", h.Invoke(ctx, req).Result)
+
+	// Notifications have no response.
+	req = &coder.Request{Method: "whoami", Params: []interface{}{}, ID: new(coder.RequestID)}
+	assert.Nil(t, h.Invoke(context.Background(), req))
+}
+
+func TestNotificationBatch(t *testing.T) {
+	var n int64
+	h := NewServer()
+	h.Register("count", counterMethod(&n))
+
+	// A batch of notifications has no response (JSON-RPC 2.0 section 6).
+	w := serveJSON(t, h, `[
+		{"jsonrpc":"2.0","method":"count","params":[]},
+		{"jsonrpc":"2.0","method":"count","params":[]}
+	]`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Body.String())
+	assert.Equal(t, int64(2), n)
+
+	w = serveJSON(t, h, `[
+		{"jsonrpc":"2.0","method":"count","params":[]},
+		{"jsonrpc":"2.0","method":"count","params":[],"id":1}
+	]`)
+	assert.Equal(t, `[{"jsonrpc":"2.0","result":4,"id":1}]`+"\n", w.Body.String())
+}
+
+func TestScalarParams(t *testing.T) {
+	h := NewServer()
+	h.Register("subtract", subtractMethod())
+
+	w := serveJSON(t, h, `{"jsonrpc":"2.0","method":"subtract","params":42,"id":1}`)
+	assert.Equal(t, `{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid Request","data":"invalid params type"},"id":null}`+"\n", w.Body.String())
+
+	w = serveJSON(t, h, `[{"jsonrpc":"2.0","method":"subtract","params":42,"id":2}]`)
+	assert.Equal(t, `[{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid Request","data":"invalid params type"},"id":2}]`+"\n", w.Body.String())
+}
+
+func TestOptionalParams(t *testing.T) {
+	h := NewServer()
+	h.Register("greet", Method{
+		ParamNames:     []string{"name", "greeting"},
+		OptionalParams: 1,
+		ParamSchemas:   []*schema.Schema{{Type: schema.Types{"string"}}, {Type: schema.Types{"string"}}},
+		Func: func(params []interface{}) interface{} {
+			return params
+		},
+	})
+
+	for body, want := range map[string]string{
+		`{"jsonrpc":"2.0","method":"greet","params":{"name":"alice"},"id":1}`:                 `["alice",null]`,
+		`{"jsonrpc":"2.0","method":"greet","params":{"name":"alice","greeting":"hi"},"id":1}`: `["alice","hi"]`,
+		`{"jsonrpc":"2.0","method":"greet","params":["alice"],"id":1}`:                        `["alice"]`,
+	} {
+		assert.Equal(t, `{"jsonrpc":"2.0","result":`+want+`,"id":1}`+"\n", serveJSON(t, h, body).Body.String(), body)
+	}
+
+	w := serveJSON(t, h, `{"jsonrpc":"2.0","method":"greet","params":{"greeting":"hi"},"id":1}`)
+	assert.Equal(t, `{"jsonrpc":"2.0","error":{"code":-32602,"message":"Invalid params","data":"Parameter \"name\" not provided"},"id":1}`+"\n", w.Body.String())
+
+	params := h.Discover().Methods[0].Params
+	assert.True(t, params[0].Required)
+	assert.False(t, params[1].Required)
+}
+
+func TestParamStructure(t *testing.T) {
+	h := NewServer()
+
+	m := subtractMethod()
+	m.ParamStructure = openrpc.ByName
+	h.Register("byName", m)
+
+	m = subtractMethod()
+	m.ParamStructure = openrpc.ByPosition
+	h.Register("byPosition", m)
+
+	w := serveJSON(t, h, `{"jsonrpc":"2.0","method":"byName","params":{"minuend":42,"subtrahend":23},"id":1}`)
+	assert.JSONEq(t, `{"jsonrpc":"2.0","result":19,"id":1}`, w.Body.String())
+
+	w = serveJSON(t, h, `{"jsonrpc":"2.0","method":"byName","params":[42,23],"id":1}`)
+	want := `{"jsonrpc":"2.0","error":{"code":-32602,"message":"Invalid params","data":"params should be by-name (object)"},"id":1}`
+	assert.JSONEq(t, want, w.Body.String())
+
+	w = serveJSON(t, h, `{"jsonrpc":"2.0","method":"byPosition","params":[42,23],"id":1}`)
+	assert.JSONEq(t, `{"jsonrpc":"2.0","result":19,"id":1}`, w.Body.String())
+
+	w = serveJSON(t, h, `{"jsonrpc":"2.0","method":"byPosition","params":{"minuend":42,"subtrahend":23},"id":1}`)
+	want = `{"jsonrpc":"2.0","error":{"code":-32602,"message":"Invalid params","data":"params should be by-position (array)"},"id":1}`
+	assert.JSONEq(t, want, w.Body.String())
+
+	doc := h.Discover()
+	assert.Equal(t, openrpc.ByName, doc.Methods[0].ParamStructure)
+	assert.Equal(t, openrpc.ByPosition, doc.Methods[1].ParamStructure)
+
+	assert.PanicsWithValue(t, "generpc: by-name Method.ParamStructure without Method.ParamNames", func() {
+		h.Register("unnamed", Method{
+			Func:           m.Func,
+			ParamSchemas:   []*schema.Schema{{}},
+			ParamStructure: openrpc.ByName,
+		})
+	})
+	assert.PanicsWithValue(t, "generpc: invalid Method.ParamStructure: named", func() {
+		h.Register("invalid", Method{Func: m.Func, ParamStructure: "named"})
+	})
+}
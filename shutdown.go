@@ -0,0 +1,97 @@
+package generpc
+
+import (
+	"context"
+	"sync"
+
+	"github.com/dwlnetnl/generpc/coder"
+)
+
+var shuttingDown = *coder.ServerError(ShutdownErrorCode).WithString("server shutting down")
+
+// lifecycle tracks the active HTTP requests of a server.
+type lifecycle struct {
+	ctx    context.Context // cancelled when shutdown is forced
+	cancel context.CancelCauseFunc
+
+	mu       sync.Mutex
+	shutdown bool
+	active   int
+	idle     chan struct{} // closed when there are no active requests
+}
+
+// begin registers an active request. It returns false if the server is
+// shutting down.
+func (s *Server) begin() bool {
+	s.life.mu.Lock()
+	defer s.life.mu.Unlock()
+
+	if s.life.shutdown {
+		return false
+	}
+
+	s.life.active++
+	return true
+}
+
+func (s *Server) end() {
+	s.life.mu.Lock()
+	defer s.life.mu.Unlock()
+
+	s.life.active--
+	if s.life.active == 0 && s.life.idle != nil {
+		close(s.life.idle)
+		s.life.idle = nil
+	}
+}
+
+// withShutdown returns a copy of ctx that is cancelled when the shutdown of
+// the server is forced.
+func (s *Server) withShutdown(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	stop := context.AfterFunc(s.life.ctx, func() {
+		cancel(context.Cause(s.life.ctx))
+	})
+
+	return ctx, func() {
+		stop()
+		cancel(context.Canceled)
+	}
+}
+
+// Shutdown gracefully shuts down the server. New requests receive a "server
+// shutting down" error (see ShutdownErrorCode) and HTTP status 503 Service
+// Unavailable. Shutdown waits for active requests and queued notifications
+// to finish. If ctx is done before that, the contexts of the active calls are
+// cancelled, the calls return the shutdown error and ctx.Err() is returned
+// without waiting for them. Functions with a timeout (see Timeout) that
+// ignore cancellation keep running in the background, other functions delay
+// their response until they return.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.life.mu.Lock()
+	s.life.shutdown = true
+	idle := s.life.idle
+	if idle == nil {
+		idle = make(chan struct{})
+		if s.life.active == 0 {
+			close(idle)
+		} else {
+			s.life.idle = idle
+		}
+	}
+	s.life.mu.Unlock()
+
+	err := s.DrainNotifications(ctx)
+	if err == nil {
+		select {
+		case <-idle:
+		case <-ctx.Done():
+			err = ctx.Err()
+		}
+	}
+
+	// DrainNotifications returns when ctx is done, so active calls are
+	// cancelled at the deadline even if draining didn't finish.
+	s.life.cancel(&shuttingDown)
+	return err
+}
@@ -0,0 +1,175 @@
+package generpc
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShutdown(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	h := NewServer()
+	h.Register("block", Method{
+		Func: func([]interface{}) interface{} {
+			close(started)
+			<-release
+			return "done"
+		},
+	})
+
+	var w *testResponse
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		rec := serveJSON(t, h, `{"jsonrpc":"2.0","method":"block","params":[],"id":1}`)
+		w = new(testResponse)
+		json.Unmarshal(rec.Body.Bytes(), w)
+	}()
+	<-started
+
+	shutdown := make(chan error)
+	go func() {
+		shutdown <- h.Shutdown(context.Background())
+	}()
+
+	// Wait until the server is shutting down.
+	for !func() bool {
+		h.life.mu.Lock()
+		defer h.life.mu.Unlock()
+		return h.life.shutdown
+	}() {
+		time.Sleep(time.Millisecond)
+	}
+
+	rec := serveJSON(t, h, `{"jsonrpc":"2.0","method":"block","params":[],"id":2}`)
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	want := `{"jsonrpc":"2.0","error":{"code":-32001,"message":"Server error","data":"server shutting down"},"id":2}` + "\n"
+	assert.Equal(t, want, rec.Body.String())
+
+	select {
+	case <-shutdown:
+		t.Fatal("shutdown didn't wait for active request")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	close(release)
+	require.NoError(t, <-shutdown)
+	<-done
+	assert.Equal(t, "done", w.Result)
+}
+
+func TestShutdown_deadline(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+
+	h := NewServer()
+	h.Register("block", Method{
+		FuncContext: func(ctx context.Context, params []interface{}) interface{} {
+			close(started)
+			select {
+			case <-release:
+			case <-ctx.Done():
+			}
+			return "done"
+		},
+	})
+
+	done := make(chan *testResponse)
+	go func() {
+		rec := serveJSON(t, h, `{"jsonrpc":"2.0","method":"block","params":[],"id":1}`)
+		resp := new(testResponse)
+		json.Unmarshal(rec.Body.Bytes(), resp)
+		done <- resp
+	}()
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, h.Shutdown(ctx))
+
+	resp := <-done
+	require.NotNil(t, resp.Error)
+	assert.Equal(t, ShutdownErrorCode, resp.Error.Code)
+}
+
+func TestShutdown_notifications(t *testing.T) {
+	var calls int32
+
+	h := NewServer()
+	h.AsyncNotifications = true
+	h.Register("count", Method{
+		Func: func([]interface{}) interface{} {
+			time.Sleep(5 * time.Millisecond)
+			atomic.AddInt32(&calls, 1)
+			return nil
+		},
+	})
+
+	serveJSON(t, h, `[
+		{"jsonrpc":"2.0","method":"count","params":[]},
+		{"jsonrpc":"2.0","method":"count","params":[]}
+	]`)
+
+	require.NoError(t, h.Shutdown(context.Background()))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+
+	rec := serveJSON(t, h, `{"jsonrpc":"2.0","method":"count","params":[]}`)
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func TestShutdown_fullQueue(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+
+	cancelled := make(chan struct{})
+	h := NewServer()
+	h.AsyncNotifications = true
+	h.NotificationQueue = 1
+	h.Register("block", Method{
+		Func: func([]interface{}) interface{} {
+			<-release
+			return nil
+		},
+	})
+	h.Register("wait", Method{
+		FuncContext: func(ctx context.Context, params []interface{}) interface{} {
+			<-ctx.Done()
+			close(cancelled)
+			return nil
+		},
+	})
+
+	go serveJSON(t, h, `{"jsonrpc":"2.0","method":"wait","params":[],"id":1}`)
+
+	// One notification is processed, one is queued and one blocks the
+	// request on the full queue.
+	go serveJSON(t, h, `[
+		{"jsonrpc":"2.0","method":"block","params":[]},
+		{"jsonrpc":"2.0","method":"block","params":[]},
+		{"jsonrpc":"2.0","method":"block","params":[]}
+	]`)
+	time.Sleep(10 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	assert.Equal(t, context.DeadlineExceeded, h.Shutdown(ctx))
+	assert.Less(t, time.Since(start), time.Second)
+
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Fatal("active call not cancelled")
+	}
+}
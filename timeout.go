@@ -0,0 +1,117 @@
+package generpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/dwlnetnl/generpc/coder"
+)
+
+// timeoutError is the cancellation cause of a timed out call.
+type timeoutError struct {
+	timeout time.Duration
+	scope   string // "method" or "batch"
+}
+
+func (e *timeoutError) Error() string {
+	return "generpc: " + e.scope + " timeout of " + e.timeout.String() + " exceeded"
+}
+
+// call calls the method function. If the call has a deadline (see Timeout and
+// BatchTimeout), the function is called in a separate goroutine so the call
+// can return when the deadline expires, even if the function ignores
+// cancellation. A panic in that goroutine is returned as error. Without a
+// deadline the function is called directly and the call returns the context
+// error if ctx is done when it returns. Done is called when the function
+// returns, or immediately if it isn't called.
+func (s *Server) call(ctx context.Context, m *Method, params []interface{}, done func()) interface{} {
+	timeout := m.Timeout
+	if timeout == 0 {
+		timeout = s.Timeout
+	}
+
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		cause := &timeoutError{timeout, "method"}
+		ctx, cancel = context.WithTimeoutCause(ctx, timeout, cause)
+		defer cancel()
+	}
+
+	start := time.Now()
+	if ctx.Err() != nil {
+		done()
+		return contextError(ctx, start)
+	}
+
+	if _, ok := ctx.Deadline(); !ok {
+		defer done()
+		result := m.invoke(ctx, params)
+		if ctx.Err() != nil {
+			return contextError(ctx, start)
+		}
+		return result
+	}
+
+	results := make(chan interface{}, 1)
+	go func() {
+		defer done()
+		defer func() {
+			if v := recover(); v != nil {
+				results <- fmt.Errorf("panic: %v", v)
+			}
+		}()
+
+		results <- m.invoke(ctx, params)
+	}()
+
+	select {
+	case result := <-results:
+		return result
+
+	case <-ctx.Done():
+		return contextError(ctx, start)
+	}
+}
+
+func (m *Method) invoke(ctx context.Context, params []interface{}) interface{} {
+	if m.FuncContext != nil {
+		return m.FuncContext(ctx, params)
+	}
+
+	return m.Func(params)
+}
+
+// contextResponse returns the error response for req if ctx is done before
+// the call was made. Errors that aren't RPC errors are internal errors.
+func contextResponse(ctx context.Context, req *coder.Request, start time.Time) *coder.Response {
+	switch v := contextError(ctx, start).(type) {
+	case coder.Error:
+		return v.Response(req)
+
+	case error:
+		var e *coder.Error
+		if errors.As(v, &e) {
+			return e.Response(req)
+		}
+	}
+
+	return internalError.Response(req)
+}
+
+// contextError returns the RPC error for a call whose context is done.
+func contextError(ctx context.Context, start time.Time) interface{} {
+	var te *timeoutError
+	if errors.As(context.Cause(ctx), &te) {
+		e := coder.ServerError(TimeoutErrorCode)
+		e.Data = map[string]interface{}{
+			"scope":   te.scope,
+			"timeout": te.timeout.String(),
+			"elapsed": time.Since(start).String(),
+		}
+		return e
+	}
+
+	return context.Cause(ctx)
+}
@@ -0,0 +1,187 @@
+package generpc
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func sleepMethod(d time.Duration) Method {
+	return Method{
+		Func: func(params []interface{}) interface{} {
+			time.Sleep(d)
+			return "done"
+		},
+	}
+}
+
+func TestMethodTimeout(t *testing.T) {
+	h := NewServer()
+	h.Timeout = time.Hour
+
+	m := sleepMethod(time.Second)
+	m.Timeout = 10 * time.Millisecond
+	h.Register("sleep", m)
+
+	cancelled := make(chan struct{})
+	h.Register("wait", Method{
+		FuncContext: func(ctx context.Context, params []interface{}) interface{} {
+			<-ctx.Done()
+			close(cancelled)
+			return nil
+		},
+		Timeout: 10 * time.Millisecond,
+	})
+
+	start := time.Now()
+	w := serveJSON(t, h, `{"jsonrpc":"2.0","method":"sleep","params":[],"id":1}`)
+	assert.True(t, time.Since(start) < time.Second)
+
+	var resp testResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.NotNil(t, resp.Error)
+	assert.Equal(t, TimeoutErrorCode, resp.Error.Code)
+	assert.Equal(t, "method", resp.Error.Data["scope"])
+	assert.Equal(t, "10ms", resp.Error.Data["timeout"])
+	assert.Contains(t, resp.Error.Data, "elapsed")
+
+	serveJSON(t, h, `{"jsonrpc":"2.0","method":"wait","params":[],"id":1}`)
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Fatal("context of call not cancelled")
+	}
+}
+
+func TestMethodTimeout_panic(t *testing.T) {
+	h := NewServer()
+	h.ErrorLog = log.New(io.Discard, "", 0)
+	h.Register("panic", Method{
+		Func: func(params []interface{}) interface{} { panic("boom") },
+	})
+	m := Method{
+		Func:    func(params []interface{}) interface{} { panic("boom") },
+		Timeout: time.Second,
+	}
+	h.Register("panicTimeout", m)
+	h.Register("ok", valueMethod("ok"))
+
+	ts := httptest.NewServer(h)
+	defer ts.Close()
+	ts.Config.ErrorLog = log.New(io.Discard, "", 0)
+
+	post := func(body string) (string, error) {
+		resp, err := http.Post(ts.URL, "application/json", strings.NewReader(body))
+		if err != nil {
+			return "", err
+		}
+		defer resp.Body.Close()
+
+		b, err := io.ReadAll(resp.Body)
+		return string(b), err
+	}
+
+	// Without timeout the panic is recovered by net/http.
+	_, err := post(`{"jsonrpc":"2.0","method":"panic","params":[],"id":1}`)
+	assert.Error(t, err)
+
+	// With timeout the panic is returned as internal error.
+	got, err := post(`{"jsonrpc":"2.0","method":"panicTimeout","params":[],"id":1}`)
+	require.NoError(t, err)
+	assert.Equal(t, `{"jsonrpc":"2.0","error":{"code":-32603,"message":"Internal error"},"id":1}`+"\n", got)
+
+	got, err = post(`{"jsonrpc":"2.0","method":"ok","params":[],"id":1}`)
+	require.NoError(t, err)
+	assert.Equal(t, `{"jsonrpc":"2.0","result":"ok","id":1}`+"\n", got)
+}
+
+func TestMethodTimeout_lateResult(t *testing.T) {
+	h := NewServer()
+	h.Register("slow", Method{
+		Func: func(params []interface{}) interface{} {
+			time.Sleep(30 * time.Millisecond)
+			return "late"
+		},
+		Timeout: 10 * time.Millisecond,
+	})
+	h.Register("ok", valueMethod("ok"))
+
+	got := serveJSON(t, h, `[
+		{"jsonrpc":"2.0","method":"slow","params":[],"id":1},
+		{"jsonrpc":"2.0","method":"ok","params":[],"id":2}
+	]`).Body.String()
+	assert.NotContains(t, got, "late")
+	assert.Contains(t, got, `{"jsonrpc":"2.0","result":"ok","id":2}`)
+
+	// The result of the timed out call isn't sent to later calls.
+	deadline := time.Now().Add(50 * time.Millisecond)
+	for time.Now().Before(deadline) {
+		got := serveJSON(t, h, `{"jsonrpc":"2.0","method":"ok","params":[],"id":3}`).Body.String()
+		require.Equal(t, `{"jsonrpc":"2.0","result":"ok","id":3}`+"\n", got)
+	}
+}
+
+func TestServerTimeout(t *testing.T) {
+	h := NewServer()
+	h.Timeout = 10 * time.Millisecond
+	h.Register("sleep", sleepMethod(time.Second))
+	h.Register("fast", sleepMethod(0))
+
+	w := serveJSON(t, h, `[
+		{"jsonrpc":"2.0","method":"sleep","params":[],"id":1},
+		{"jsonrpc":"2.0","method":"fast","params":[],"id":2}
+	]`)
+
+	var resps []testResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resps))
+	require.Len(t, resps, 2)
+	require.NotNil(t, resps[0].Error)
+	assert.Equal(t, TimeoutErrorCode, resps[0].Error.Code)
+	assert.Equal(t, "done", resps[1].Result)
+}
+
+func TestBatchTimeout(t *testing.T) {
+	h := NewServer()
+	h.BatchTimeout = 50 * time.Millisecond
+	h.Register("fast", sleepMethod(0))
+	h.Register("sleep", sleepMethod(time.Second))
+
+	start := time.Now()
+	w := serveJSON(t, h, `[
+		{"jsonrpc":"2.0","method":"fast","params":[],"id":1},
+		{"jsonrpc":"2.0","method":"sleep","params":[],"id":2},
+		{"jsonrpc":"2.0","method":"sleep","params":[],"id":3}
+	]`)
+	assert.True(t, time.Since(start) < time.Second)
+
+	var resps []testResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resps))
+	require.Len(t, resps, 3)
+	assert.Equal(t, "done", resps[0].Result)
+
+	for _, resp := range resps[1:] {
+		require.NotNil(t, resp.Error)
+		assert.Equal(t, TimeoutErrorCode, resp.Error.Code)
+		assert.Equal(t, "batch", resp.Error.Data["scope"])
+		assert.Equal(t, "50ms", resp.Error.Data["timeout"])
+	}
+}
+
+func TestRegister_funcContext(t *testing.T) {
+	h := NewServer()
+	assert.Panics(t, func() {
+		h.Register("both", Method{
+			Func:        func([]interface{}) interface{} { return nil },
+			FuncContext: func(context.Context, []interface{}) interface{} { return nil },
+		})
+	})
+}
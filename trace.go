@@ -0,0 +1,195 @@
+package generpc
+
+import (
+	"context"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/dwlnetnl/generpc/coder"
+)
+
+// Span attributes set by the Server, following the OpenTelemetry semantic
+// conventions for JSON-RPC where possible.
+const (
+	AttrRPCSystem    = "rpc.system"
+	AttrRPCMethod    = "rpc.method"
+	AttrRequestID    = "rpc.jsonrpc.request_id"
+	AttrErrorCode    = "rpc.jsonrpc.error_code"
+	AttrErrorMessage = "rpc.jsonrpc.error_message"
+	AttrMediaType    = "generpc.media_type"
+	AttrBatchSize    = "generpc.batch_size"
+)
+
+// W3C Trace Context headers.
+const (
+	TraceparentHeader = "traceparent"
+	TracestateHeader  = "tracestate"
+)
+
+// Tracer creates spans, see Server.Tracer. The otelgenerpc package adapts an
+// OpenTelemetry tracer.
+type Tracer interface {
+	// Start starts a span as child of the span context in ctx, see
+	// SpanContextFromContext. The returned context should contain the span
+	// context of the new span.
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+// Span represents a unit of work.
+type Span interface {
+	SetAttribute(key string, value interface{})
+	End()
+}
+
+type nopTracer struct{}
+
+func (nopTracer) Start(ctx context.Context, name string) (context.Context, Span) {
+	return ctx, nopSpan{}
+}
+
+type nopSpan struct{}
+
+func (nopSpan) SetAttribute(key string, value interface{}) {}
+func (nopSpan) End()                                       {}
+
+// SpanContext identifies a span as defined by W3C Trace Context. Remote is
+// true if the span context was received from the client.
+type SpanContext struct {
+	TraceID    [16]byte
+	SpanID     [8]byte
+	TraceFlags byte
+	TraceState string
+	Remote     bool
+}
+
+// IsValid reports if the trace ID and span ID are set.
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID != [16]byte{} && sc.SpanID != [8]byte{}
+}
+
+// IsSampled reports if the sampled flag is set.
+func (sc SpanContext) IsSampled() bool {
+	return sc.TraceFlags&0x01 != 0
+}
+
+// Traceparent returns the value of the traceparent header.
+func (sc SpanContext) Traceparent() string {
+	return "00-" + hex.EncodeToString(sc.TraceID[:]) + "-" +
+		hex.EncodeToString(sc.SpanID[:]) + "-" +
+		hex.EncodeToString([]byte{sc.TraceFlags})
+}
+
+// ParseTraceparent parses the value of a traceparent header. The trace state
+// isn't set.
+func ParseTraceparent(s string) (SpanContext, bool) {
+	var sc SpanContext
+
+	parts := strings.Split(strings.TrimSpace(s), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" {
+		return sc, false
+	}
+
+	if _, err := strconv.ParseUint(parts[0], 16, 8); err != nil {
+		return sc, false
+	}
+
+	if parts[0] == "00" && len(parts) != 4 {
+		return sc, false
+	}
+
+	if !decodeHex(sc.TraceID[:], parts[1]) || !decodeHex(sc.SpanID[:], parts[2]) {
+		return sc, false
+	}
+
+	var flags [1]byte
+	if !decodeHex(flags[:], parts[3]) {
+		return sc, false
+	}
+
+	sc.TraceFlags = flags[0]
+	return sc, sc.IsValid()
+}
+
+// decodeHex decodes lowercase hex s into dst, which must be filled exactly.
+func decodeHex(dst []byte, s string) bool {
+	if len(s) != 2*len(dst) || strings.ToLower(s) != s {
+		return false
+	}
+
+	_, err := hex.Decode(dst, []byte(s))
+	return err == nil
+}
+
+type spanContextKey struct{}
+
+// SpanContextFromContext returns the span context of the current span. For
+// method calls it's the span of the call, or the span of the client if the
+// tracer doesn't create spans.
+func SpanContextFromContext(ctx context.Context) SpanContext {
+	sc, _ := ctx.Value(spanContextKey{}).(SpanContext)
+	return sc
+}
+
+// ContextWithSpanContext returns a copy of ctx with the span context, it's
+// intended for Tracer implementations.
+func ContextWithSpanContext(ctx context.Context, sc SpanContext) context.Context {
+	return context.WithValue(ctx, spanContextKey{}, sc)
+}
+
+// InjectTraceHeaders sets the traceparent and tracestate headers from the
+// span context in ctx, so outgoing calls continue the trace.
+func InjectTraceHeaders(ctx context.Context, h http.Header) {
+	sc := SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return
+	}
+
+	h.Set(TraceparentHeader, sc.Traceparent())
+	if sc.TraceState != "" {
+		h.Set(TracestateHeader, sc.TraceState)
+	}
+}
+
+// extractTrace returns ctx with the span context of the client, if any.
+func extractTrace(ctx context.Context, r *http.Request) context.Context {
+	sc, ok := ParseTraceparent(r.Header.Get(TraceparentHeader))
+	if !ok {
+		return ctx
+	}
+
+	sc.TraceState = strings.Join(r.Header.Values(TracestateHeader), ",")
+	sc.Remote = true
+	return ContextWithSpanContext(ctx, sc)
+}
+
+func (s *Server) tracer() Tracer {
+	if s.Tracer == nil {
+		return nopTracer{}
+	}
+
+	return s.Tracer
+}
+
+// startSpan starts the span of a call.
+func (s *Server) startSpan(ctx context.Context, req *coder.Request) (context.Context, Span) {
+	ctx, span := s.tracer().Start(ctx, req.Method)
+	span.SetAttribute(AttrRPCSystem, "jsonrpc")
+	span.SetAttribute(AttrRPCMethod, req.Method)
+	span.SetAttribute(AttrMediaType, MediaTypeFromContext(ctx))
+	if *req.ID != nil {
+		span.SetAttribute(AttrRequestID, string(*req.ID))
+	}
+
+	return ctx, span
+}
+
+func endSpan(span Span, resp *coder.Response) {
+	if resp.Error != nil {
+		span.SetAttribute(AttrErrorCode, resp.Error.Code)
+		span.SetAttribute(AttrErrorMessage, resp.Error.Message)
+	}
+
+	span.End()
+}
@@ -0,0 +1,142 @@
+package generpc
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordSpan struct {
+	name   string
+	parent SpanContext
+	sc     SpanContext
+	attrs  map[string]interface{}
+	ended  bool
+}
+
+func (s *recordSpan) SetAttribute(key string, value interface{}) { s.attrs[key] = value }
+func (s *recordSpan) End()                                       { s.ended = true }
+
+type recordTracer struct {
+	mu    sync.Mutex
+	spans []*recordSpan
+}
+
+func (t *recordTracer) Start(ctx context.Context, name string) (context.Context, Span) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	parent := SpanContextFromContext(ctx)
+	s := &recordSpan{name: name, parent: parent, sc: parent, attrs: make(map[string]interface{})}
+	s.sc.SpanID[7] = byte(len(t.spans) + 1)
+	s.sc.Remote = false
+	t.spans = append(t.spans, s)
+	return ContextWithSpanContext(ctx, s.sc), s
+}
+
+func TestParseTraceparent(t *testing.T) {
+	sc, ok := ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	require.True(t, ok)
+	assert.True(t, sc.IsSampled())
+	assert.Equal(t, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", sc.Traceparent())
+
+	// Future versions may append fields.
+	_, ok = ParseTraceparent("01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00-ext")
+	assert.True(t, ok)
+
+	for _, s := range []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-ext",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e473-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-1",
+	} {
+		_, ok := ParseTraceparent(s)
+		assert.False(t, ok, s)
+	}
+}
+
+func TestTracer(t *testing.T) {
+	tr := new(recordTracer)
+	h := NewServer()
+	h.Tracer = tr
+	h.Register("subtract", subtractMethod())
+
+	var got SpanContext
+	h.Register("span", Method{
+		FuncContext: func(ctx context.Context, params []interface{}) interface{} {
+			got = SpanContextFromContext(ctx)
+			return nil
+		},
+	})
+
+	r, err := http.NewRequest("POST", "/", strings.NewReader(`[
+		{"jsonrpc":"2.0","method":"subtract","params":[42,23],"id":1},
+		{"jsonrpc":"2.0","method":"span","params":[],"id":"a"},
+		{"jsonrpc":"2.0","method":"missing","params":[]}
+	]`))
+	require.NoError(t, err)
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	r.Header.Add("tracestate", "a=1")
+	r.Header.Add("tracestate", "b=2")
+	h.ServeHTTP(httptest.NewRecorder(), r)
+
+	require.Len(t, tr.spans, 4)
+	batch := tr.spans[0]
+	assert.Equal(t, "batch", batch.name)
+	assert.True(t, batch.parent.Remote)
+	assert.Equal(t, "a=1,b=2", batch.parent.TraceState)
+	assert.Equal(t, 3, batch.attrs[AttrBatchSize])
+
+	for _, s := range tr.spans {
+		assert.True(t, s.ended)
+	}
+
+	for _, s := range tr.spans[1:] {
+		assert.Equal(t, batch.sc, s.parent)
+		assert.Equal(t, "application/json", s.attrs[AttrMediaType])
+	}
+
+	assert.Equal(t, "subtract", tr.spans[1].attrs[AttrRPCMethod])
+	assert.Equal(t, "1", tr.spans[1].attrs[AttrRequestID])
+	assert.Equal(t, `"a"`, tr.spans[2].attrs[AttrRequestID])
+	assert.Equal(t, tr.spans[2].sc, got)
+	assert.Equal(t, -32601, tr.spans[3].attrs[AttrErrorCode])
+	assert.NotContains(t, tr.spans[3].attrs, AttrRequestID)
+
+	h2 := http.Header{}
+	InjectTraceHeaders(ContextWithSpanContext(context.Background(), got), h2)
+	assert.Equal(t, got.Traceparent(), h2.Get("traceparent"))
+	assert.Equal(t, "a=1,b=2", h2.Get("tracestate"))
+}
+
+func TestTracer_default(t *testing.T) {
+	var got SpanContext
+	h := NewServer()
+	h.Register("span", Method{
+		FuncContext: func(ctx context.Context, params []interface{}) interface{} {
+			got = SpanContextFromContext(ctx)
+			return nil
+		},
+	})
+
+	r, err := http.NewRequest("POST", "/", strings.NewReader(`{"jsonrpc":"2.0","method":"span","params":[],"id":1}`))
+	require.NoError(t, err)
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	h.ServeHTTP(httptest.NewRecorder(), r)
+
+	// Without tracer the span context of the client is propagated.
+	assert.True(t, got.Remote)
+	assert.Equal(t, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", got.Traceparent())
+}
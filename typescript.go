@@ -0,0 +1,41 @@
+package generpc
+
+import (
+	"bytes"
+	"net/http"
+
+	"github.com/dwlnetnl/generpc/typescript"
+)
+
+// serverErrorCodes names the server error codes for generated clients.
+var serverErrorCodes = map[string]int{
+	"Timeout":      TimeoutErrorCode,
+	"Shutdown":     ShutdownErrorCode,
+	"Unauthorized": UnauthorizedErrorCode,
+	"Forbidden":    ForbiddenErrorCode,
+	"Limit":        LimitErrorCode,
+}
+
+// TypeScriptHandler returns a handler that serves a TypeScript client module
+// for the registered methods, see package typescript. The module is generated
+// from the rpc.discover document for each request, so it reflects the current
+// methods. Like rpc.discover it only contains the methods the caller is
+// authorized to call, see Server.Authenticator. The handler should be mounted
+// next to the RPC endpoint:
+//
+//	mux.Handle("/rpc", s)
+//	mux.Handle("/rpc/client.ts", s.TypeScriptHandler())
+func (s *Server) TypeScriptHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var buf bytes.Buffer
+		doc := s.discover(s.methods(), authorized(s.authenticate(r)))
+		if err := typescript.Generate(&buf, doc, serverErrorCodes); err != nil {
+			s.logf("generpc: generate TypeScript client: %v", err)
+			http.Error(w, "internal server error", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/typescript; charset=utf-8")
+		w.Write(buf.Bytes())
+	})
+}
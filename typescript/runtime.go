@@ -0,0 +1,213 @@
+package typescript
+
+// runtime is written after ErrorCode.
+const runtime = `/** RPCError is an error returned by the server, see ErrorCode. */
+export class RPCError extends Error {
+  readonly code: number;
+  readonly data?: unknown;
+
+  constructor(code: number, message: string, data?: unknown) {
+    super(message);
+    this.name = "RPCError";
+    this.code = code;
+    this.data = data;
+  }
+}
+
+/** Params are by-position (array) or by-name (object) params. */
+export type Params = unknown[] | Record<string, unknown>;
+
+/** ClientOptions configures a Client. */
+export interface ClientOptions {
+  /** Additional HTTP headers of each request, like Authorization. */
+  headers?: Record<string, string>;
+  /** The fetch implementation, the default is the global fetch. */
+  fetch?: typeof fetch;
+}
+
+interface RPCResponse {
+  result?: unknown;
+  error?: { code: number; message: string; data?: unknown };
+  id: number | string | null;
+}
+
+function result<T>(resp: RPCResponse): T {
+  if (resp.error) {
+    throw new RPCError(resp.error.code, resp.error.message, resp.error.data);
+  }
+  return resp.result as T;
+}
+
+// request returns a request, trailing undefined params are omitted.
+function request(method: string, params: Params, id?: number): object {
+  if (Array.isArray(params)) {
+    let n = params.length;
+    while (n > 0 && params[n - 1] === undefined) {
+      n--;
+    }
+    params = params.slice(0, n);
+  }
+  return { jsonrpc: "2.0", method, params, id };
+}
+
+`
+
+// clients is written after Methods.
+const clients = `
+/** Client calls the methods of a GeneRPC endpoint with the JSON coder. */
+export class Client extends Methods {
+  readonly url: string;
+  readonly options: ClientOptions;
+  private id = 0;
+
+  constructor(url: string, options: ClientOptions = {}) {
+    super();
+    this.url = url;
+    this.options = options;
+  }
+
+  /** call calls a method and returns its result. */
+  call<T = unknown>(method: string, params: Params = []): Promise<T> {
+    return this.invoke<T>(method, params);
+  }
+
+  /** notify sends a notification. */
+  async notify(method: string, params: Params = []): Promise<void> {
+    await this.post(request(method, params));
+  }
+
+  /** batch returns a new batch of calls, see Batch. */
+  batch(): Batch {
+    return new Batch(this);
+  }
+
+  protected async invoke<T>(method: string, params: Params): Promise<T> {
+    const resp = await this.post(request(method, params, this.newID()));
+    if (resp === undefined || Array.isArray(resp)) {
+      throw new Error("generpc: invalid response");
+    }
+    return result<T>(resp);
+  }
+
+  /** newID returns the ID of a new request. */
+  newID(): number {
+    return ++this.id;
+  }
+
+  /** post sends the request(s) and returns the response(s), if any. */
+  async post(body: unknown): Promise<RPCResponse | RPCResponse[] | undefined> {
+    const f = this.options.fetch ?? fetch;
+    const resp = await f(this.url, {
+      method: "POST",
+      headers: { ...this.options.headers, "Content-Type": "application/json" },
+      body: JSON.stringify(body),
+    });
+
+    const text = await resp.text();
+    if (text === "") {
+      if (!resp.ok) {
+        throw new Error("generpc: HTTP status " + resp.status);
+      }
+      return undefined;
+    }
+
+    try {
+      return JSON.parse(text);
+    } catch {
+      throw new Error("generpc: invalid response (HTTP status " + resp.status + ")");
+    }
+  }
+}
+
+interface Pending {
+  resolve: (v: unknown) => void;
+  reject: (e: unknown) => void;
+}
+
+/**
+ * Batch collects calls and sends them as batch. The promise of a call is
+ * settled when the batch is sent.
+ */
+export class Batch extends Methods {
+  private readonly client: Client;
+  private requests: unknown[] = [];
+  private pending = new Map<number, Pending>();
+
+  constructor(client: Client) {
+    super();
+    this.client = client;
+  }
+
+  /** call adds a call of the method to the batch. */
+  call<T = unknown>(method: string, params: Params = []): Promise<T> {
+    return this.invoke<T>(method, params);
+  }
+
+  /** notify adds a notification to the batch. */
+  notify(method: string, params: Params = []): void {
+    this.requests.push(request(method, params));
+  }
+
+  protected invoke<T>(method: string, params: Params): Promise<T> {
+    const id = this.client.newID();
+    this.requests.push(request(method, params, id));
+    return new Promise<T>((resolve, reject) => {
+      this.pending.set(id, { resolve: resolve as (v: unknown) => void, reject });
+    });
+  }
+
+  /**
+   * send sends the batch and settles the promises of the calls. It rejects if
+   * the batch failed as a whole, the promises of the calls are rejected too.
+   */
+  async send(): Promise<void> {
+    const { requests, pending } = this;
+    this.requests = [];
+    this.pending = new Map();
+    if (requests.length === 0) {
+      return;
+    }
+
+    const fail = (e: unknown): never => {
+      pending.forEach((p) => p.reject(e));
+      throw e;
+    };
+
+    let body: RPCResponse | RPCResponse[] | undefined;
+    try {
+      body = await this.client.post(requests);
+    } catch (e) {
+      return fail(e);
+    }
+
+    if (body === undefined) {
+      if (pending.size > 0) {
+        fail(new Error("generpc: no response"));
+      }
+      return;
+    }
+
+    if (!Array.isArray(body)) {
+      // The batch failed as a whole.
+      const e: NonNullable<RPCResponse["error"]> = body.error ?? { code: ErrorCode.InternalError, message: "invalid response" };
+      return fail(new RPCError(e.code, e.message, e.data));
+    }
+
+    for (const resp of body) {
+      const p = typeof resp.id === "number" ? pending.get(resp.id) : undefined;
+      if (p === undefined) {
+        continue;
+      }
+
+      pending.delete(resp.id as number);
+      try {
+        p.resolve(result(resp));
+      } catch (e) {
+        p.reject(e);
+      }
+    }
+
+    pending.forEach((p) => p.reject(new Error("generpc: no response")));
+  }
+}
+`
@@ -0,0 +1,590 @@
+// Package typescript generates a TypeScript client module from an OpenRPC
+// document, like the result of rpc.discover. See Server.TypeScriptHandler for
+// serving the module of the registered methods.
+//
+// The module calls the endpoint with the JSON coder via fetch:
+//
+//	import { Client, RPCError, ErrorCode } from "./api";
+//
+//	const c = new Client("/rpc", { headers: { Authorization: "Bearer token" } });
+//	const user = await c.userGet(1);
+//
+//	const batch = c.batch();
+//	const a = batch.subtract(42, 23);
+//	const b = batch.subtract(23, 42);
+//	await batch.send();
+//	console.log(await a, await b);
+//
+// A typed function is generated for each method, the param and result types
+// are derived from the schemas. The params are sent by-name if the param
+// structure of the method is by-name and by-position otherwise. RPC errors are
+// thrown as RPCError, ErrorCode contains the codes of the predefined errors
+// and of the errors in the document.
+package typescript
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/dwlnetnl/generpc/coder"
+	"github.com/dwlnetnl/generpc/openrpc"
+	"github.com/dwlnetnl/generpc/schema"
+)
+
+// predefined are the error codes of JSON-RPC 2.0 and package coder.
+var predefined = []errorCode{
+	{"ParseError", coder.ParseError.Code},
+	{"InvalidRequest", coder.InvalidRequest.Code},
+	{"MethodNotFound", -32601},
+	{"InvalidParams", -32602},
+	{"InternalError", -32603},
+	{"Exception", coder.ExceptionErrorCode},
+}
+
+type errorCode struct {
+	name string
+	code int
+}
+
+type generator struct {
+	doc   *openrpc.Document
+	buf   bytes.Buffer
+	types map[string]*schema.Schema // named schemas by TypeScript name
+	codes map[int]string            // error name by code
+}
+
+// Generate writes a TypeScript client module for the methods of doc to w.
+// Codes optionally names additional error codes, like the server error codes
+// of GeneRPC.
+func Generate(w io.Writer, doc *openrpc.Document, codes map[string]int) error {
+	g := &generator{
+		doc:   doc,
+		types: make(map[string]*schema.Schema),
+		codes: make(map[int]string),
+	}
+
+	g.generate(codes)
+	_, err := w.Write(g.buf.Bytes())
+	return err
+}
+
+func (g *generator) p(format string, args ...interface{}) {
+	fmt.Fprintf(&g.buf, format+"\n", args...)
+}
+
+func (g *generator) generate(extra map[string]int) {
+	title := strings.TrimSpace(g.doc.Info.Title + " " + g.doc.Info.Version)
+	g.p("// Code generated by GeneRPC from the OpenRPC document of %s. DO NOT EDIT.", title)
+	g.p("")
+
+	codes := append([]errorCode{}, predefined...)
+	for name, code := range extra {
+		codes = append(codes, errorCode{name, code})
+	}
+	if g.doc.Components != nil {
+		for key, e := range g.doc.Components.Errors {
+			codes = append(codes, errorCode{typeName(key), e.Code})
+		}
+	}
+	sort.Slice(codes, func(i, j int) bool {
+		if codes[i].code != codes[j].code {
+			return codes[i].code < codes[j].code
+		}
+		return codes[i].name < codes[j].name
+	})
+
+	g.p("/** Codes of the RPC errors, see RPCError. */")
+	g.p("export const ErrorCode = {")
+	used := make(map[string]bool)
+	for _, c := range codes {
+		if _, dup := g.codes[c.code]; dup || used[c.name] {
+			continue
+		}
+		g.codes[c.code] = c.name
+		used[c.name] = true
+		g.p("  %s: %d,", c.name, c.code)
+	}
+	g.p("} as const;")
+	g.p("")
+	g.buf.WriteString(runtime)
+
+	var methods bytes.Buffer
+	names := make(map[string]bool)
+	for _, m := range g.doc.Methods {
+		if strings.HasPrefix(m.Name, "rpc.") {
+			continue
+		}
+		g.method(&methods, m, names)
+	}
+
+	if g.doc.Components != nil {
+		for key, s := range g.doc.Components.Schemas {
+			g.types[typeName(key)] = s
+		}
+	}
+
+	// Named types can refer to other named types, so they're collected until
+	// all are declared.
+	declared := make(map[string]bool)
+	for {
+		var names []string
+		for name := range g.types {
+			if !declared[name] {
+				names = append(names, name)
+			}
+		}
+		if len(names) == 0 {
+			break
+		}
+
+		sort.Strings(names)
+		for _, name := range names {
+			declared[name] = true
+			g.declare(name, g.types[name])
+		}
+	}
+
+	g.p("/** Methods contains a function for each method of the API. */")
+	g.p("export abstract class Methods {")
+	g.p("  protected abstract invoke<T>(method: string, params: Params): Promise<T>;")
+	g.buf.Write(methods.Bytes())
+	g.p("}")
+	g.buf.WriteString(clients)
+}
+
+// declare declares a named type.
+func (g *generator) declare(name string, s *schema.Schema) {
+	g.describe(s)
+	if s != nil && s.Ref == "" && len(s.Properties) > 0 && len(s.AnyOf)+len(s.OneOf)+len(s.AllOf) == 0 {
+		g.p("export interface %s %s", name, g.object(s, true))
+	} else {
+		g.p("export type %s = %s;", name, g.tsType(s))
+	}
+	g.p("")
+}
+
+func (g *generator) describe(s *schema.Schema) {
+	if s == nil {
+		return
+	}
+
+	text := s.Description
+	if text == "" {
+		text = s.Title
+	}
+	if text != "" {
+		g.p("%s", comment("", text))
+	}
+}
+
+func (g *generator) method(buf *bytes.Buffer, m openrpc.Method, names map[string]bool) {
+	name := memberName(m.Name)
+	if name == "" || members[name] || reserved[name] {
+		name += "_"
+	}
+	for i := 2; names[name]; i++ {
+		name = fmt.Sprintf("%s%d", memberName(m.Name), i)
+	}
+	names[name] = true
+
+	var params, args []string
+	ids := make(map[string]bool)
+	optional := true
+	for i := len(m.Params) - 1; i >= 0; i-- {
+		p := g.contentDescriptor(m.Params[i])
+		id := memberName(p.Name)
+		if id == "" || reserved[id] || ids[id] {
+			id = fmt.Sprintf("param%d", i)
+		}
+		ids[id] = true
+
+		typ := g.tsType(p.Schema)
+		optional = optional && !p.Required
+		switch {
+		case optional:
+			params = append(params, id+"?: "+typ)
+		case !p.Required:
+			params = append(params, id+": "+typ+" | undefined")
+		default:
+			params = append(params, id+": "+typ)
+		}
+
+		if m.ParamStructure == openrpc.ByName {
+			args = append(args, propertyName(p.Name)+": "+id)
+		} else {
+			args = append(args, id)
+		}
+	}
+	reverse(params)
+	reverse(args)
+
+	result := "unknown"
+	if m.Result != nil {
+		result = g.tsType(g.contentDescriptor(*m.Result).Schema)
+	}
+
+	var text []string
+	text = append(text, m.Name+": "+strings.TrimSpace(m.Summary))
+	if m.Summary == "" {
+		text[0] = "Calls " + m.Name + "."
+	}
+	if m.Description != "" {
+		text = append(text, "", m.Description)
+	}
+	if len(m.Errors) > 0 {
+		text = append(text, "")
+		for _, e := range m.Errors {
+			e = g.error(e)
+			text = append(text, fmt.Sprintf("@throws {RPCError} %s (%d)", g.codeName(e), e.Code))
+		}
+	}
+	if m.Deprecated {
+		notice := m.DeprecationNotice
+		if notice == "" {
+			notice = "Deprecated."
+		}
+		text = append(text, "", "@deprecated "+notice)
+	}
+
+	fmt.Fprintf(buf, "\n%s\n", comment("  ", strings.Join(text, "\n")))
+	fmt.Fprintf(buf, "  %s(%s): Promise<%s> {\n", name, strings.Join(params, ", "), result)
+	if m.ParamStructure == openrpc.ByName {
+		fmt.Fprintf(buf, "    return this.invoke<%s>(%q, { %s });\n", result, m.Name, strings.Join(args, ", "))
+	} else {
+		fmt.Fprintf(buf, "    return this.invoke<%s>(%q, [%s]);\n", result, m.Name, strings.Join(args, ", "))
+	}
+	fmt.Fprintf(buf, "  }\n")
+}
+
+func (g *generator) codeName(e openrpc.Error) string {
+	if name, ok := g.codes[e.Code]; ok {
+		return "ErrorCode." + name
+	}
+
+	return strings.TrimSuffix(e.Message, ".")
+}
+
+func (g *generator) contentDescriptor(cd openrpc.ContentDescriptor) openrpc.ContentDescriptor {
+	if cd.Ref == "" || g.doc.Components == nil {
+		return cd
+	}
+
+	key := strings.TrimPrefix(cd.Ref, "#/components/contentDescriptors/")
+	if c, ok := g.doc.Components.ContentDescriptors[key]; ok {
+		return c
+	}
+
+	return cd
+}
+
+func (g *generator) error(e openrpc.Error) openrpc.Error {
+	if e.Ref == "" || g.doc.Components == nil {
+		return e
+	}
+
+	key := strings.TrimPrefix(e.Ref, "#/components/errors/")
+	if c, ok := g.doc.Components.Errors[key]; ok {
+		return c
+	}
+
+	return e
+}
+
+// tsType returns the TypeScript type of a schema. Schemas in $defs are
+// collected as named types.
+func (g *generator) tsType(s *schema.Schema) string {
+	if s == nil {
+		return "unknown"
+	}
+
+	if v, ok := s.IsBool(); ok {
+		if v {
+			return "unknown"
+		}
+		return "never"
+	}
+
+	for key, d := range s.Defs {
+		if _, ok := g.types[typeName(key)]; !ok {
+			g.types[typeName(key)] = d
+		}
+	}
+
+	if s.Ref != "" {
+		for _, prefix := range []string{"#/components/schemas/", "#/$defs/"} {
+			if key := strings.TrimPrefix(s.Ref, prefix); key != s.Ref {
+				return typeName(key)
+			}
+		}
+		return "unknown"
+	}
+
+	if s.HasConst {
+		return literal(s.Const)
+	}
+
+	if len(s.Enum) > 0 {
+		alts := make([]string, len(s.Enum))
+		for i, v := range s.Enum {
+			alts[i] = literal(v)
+		}
+		return union(alts)
+	}
+
+	if alts := append(s.AnyOf[:len(s.AnyOf):len(s.AnyOf)], s.OneOf...); len(alts) > 0 {
+		types := make([]string, len(alts))
+		for i, a := range alts {
+			types[i] = g.tsType(a)
+		}
+		return union(types)
+	}
+
+	if len(s.AllOf) > 0 {
+		types := make([]string, len(s.AllOf))
+		for i, a := range s.AllOf {
+			types[i] = group(g.tsType(a))
+		}
+		return strings.Join(types, " & ")
+	}
+
+	types := s.Type
+	if len(types) == 0 {
+		if len(s.Properties) == 0 {
+			return "unknown"
+		}
+		types = schema.Types{"object"}
+	}
+
+	alts := make([]string, len(types))
+	for i, t := range types {
+		switch t {
+		case "string", "boolean", "null":
+			alts[i] = t
+		case "integer", "number":
+			alts[i] = "number"
+		case "array":
+			alts[i] = g.array(s)
+		case "object":
+			alts[i] = g.object(s, false)
+		default:
+			alts[i] = "unknown"
+		}
+	}
+
+	return union(alts)
+}
+
+func (g *generator) array(s *schema.Schema) string {
+	if len(s.PrefixItems) == 0 {
+		return group(g.tsType(s.Items)) + "[]"
+	}
+
+	items := make([]string, len(s.PrefixItems))
+	for i, item := range s.PrefixItems {
+		items[i] = g.tsType(item)
+	}
+
+	if v, ok := s.Items.IsBool(); s.Items == nil || !ok || v {
+		items = append(items, "..."+group(g.tsType(s.Items))+"[]")
+	}
+
+	return "[" + strings.Join(items, ", ") + "]"
+}
+
+// object returns an object type. The properties are on separate lines if
+// multiline is set, with their descriptions.
+func (g *generator) object(s *schema.Schema, multiline bool) string {
+	if len(s.Properties) == 0 {
+		if s.AdditionalProperties == nil {
+			return "Record<string, unknown>"
+		}
+		return "Record<string, " + g.tsType(s.AdditionalProperties) + ">"
+	}
+
+	required := make(map[string]bool)
+	for _, key := range s.Required {
+		required[key] = true
+	}
+
+	keys := make([]string, 0, len(s.Properties))
+	for key := range s.Properties {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var fields []string
+	for _, key := range keys {
+		prop := s.Properties[key]
+		name := propertyName(key)
+		if !required[key] {
+			name += "?"
+		}
+
+		field := name + ": " + g.tsType(prop) + ";"
+		if multiline && prop != nil && prop.Description != "" {
+			field = comment("  ", prop.Description) + "\n  " + field
+		} else if multiline {
+			field = "  " + field
+		}
+		fields = append(fields, field)
+	}
+
+	if v, ok := s.AdditionalProperties.IsBool(); s.AdditionalProperties != nil && !(ok && !v) {
+		field := "[key: string]: unknown;"
+		if multiline {
+			field = "  " + field
+		}
+		fields = append(fields, field)
+	}
+
+	if multiline {
+		return "{\n" + strings.Join(fields, "\n") + "\n}"
+	}
+
+	return "{ " + strings.Join(fields, " ") + " }"
+}
+
+func literal(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "unknown"
+	}
+
+	return string(data)
+}
+
+func union(alts []string) string {
+	seen := make(map[string]bool)
+	var s []string
+	for _, a := range alts {
+		if !seen[a] {
+			seen[a] = true
+			s = append(s, group(a))
+		}
+	}
+
+	if len(s) == 1 {
+		return alts[0]
+	}
+
+	return strings.Join(s, " | ")
+}
+
+// group wraps a union or intersection type in parentheses.
+func group(typ string) string {
+	depth := 0
+	for _, r := range typ {
+		switch r {
+		case '{', '[', '(', '<':
+			depth++
+		case '}', ']', ')', '>':
+			depth--
+		case '|', '&':
+			if depth == 0 {
+				return "(" + typ + ")"
+			}
+		}
+	}
+
+	return typ
+}
+
+func comment(indent, text string) string {
+	lines := strings.Split(strings.TrimSpace(text), "\n")
+	if len(lines) == 1 {
+		return indent + "/** " + strings.ReplaceAll(lines[0], "*/", "* /") + " */"
+	}
+
+	var b strings.Builder
+	b.WriteString(indent + "/**\n")
+	for _, line := range lines {
+		line = strings.ReplaceAll(line, "*/", "* /")
+		b.WriteString(strings.TrimRight(indent+" * "+line, " ") + "\n")
+	}
+	b.WriteString(indent + " */")
+	return b.String()
+}
+
+func reverse(s []string) {
+	for i, j := 0, len(s)-1; i < j; i, j = i+1, j-1 {
+		s[i], s[j] = s[j], s[i]
+	}
+}
+
+// members are the members of the client classes, methods with these names
+// get a suffix.
+var members = map[string]bool{
+	"batch": true, "call": true, "client": true, "constructor": true,
+	"id": true, "invoke": true, "newID": true, "notify": true,
+	"options": true, "pending": true, "post": true, "requests": true,
+	"send": true, "url": true,
+}
+
+// reserved are the words that cannot be used as parameter name.
+var reserved = map[string]bool{
+	"break": true, "case": true, "catch": true, "class": true, "const": true,
+	"continue": true, "debugger": true, "default": true, "delete": true,
+	"do": true, "else": true, "enum": true, "export": true, "extends": true,
+	"false": true, "finally": true, "for": true, "function": true, "if": true,
+	"import": true, "in": true, "instanceof": true, "new": true, "null": true,
+	"return": true, "super": true, "switch": true, "this": true, "throw": true,
+	"true": true, "try": true, "typeof": true, "var": true, "void": true,
+	"while": true, "with": true, "let": true, "static": true, "yield": true,
+	"await": true, "implements": true, "interface": true, "package": true,
+	"private": true, "protected": true, "public": true,
+}
+
+func words(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '$'
+	})
+}
+
+// typeName returns a type name for s, like "UserInfo" for "user-info".
+func typeName(s string) string {
+	var b strings.Builder
+	for _, w := range words(s) {
+		r := []rune(w)
+		r[0] = unicode.ToUpper(r[0])
+		b.WriteString(string(r))
+	}
+
+	name := b.String()
+	if name == "" || unicode.IsDigit([]rune(name)[0]) {
+		name = "T" + name
+	}
+
+	return name
+}
+
+// memberName returns a method or parameter name for s, like "userGet" for
+// "user.get".
+func memberName(s string) string {
+	if len(words(s)) == 0 {
+		return ""
+	}
+
+	r := []rune(typeName(s))
+	r[0] = unicode.ToLower(r[0])
+	return string(r)
+}
+
+// propertyName returns s as property name, quoted if it isn't an identifier.
+func propertyName(s string) string {
+	for i, r := range s {
+		if !(unicode.IsLetter(r) || r == '_' || r == '$' || (i > 0 && unicode.IsDigit(r))) {
+			return literal(s)
+		}
+	}
+
+	if s == "" {
+		return `""`
+	}
+
+	return s
+}
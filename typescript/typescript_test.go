@@ -0,0 +1,64 @@
+package typescript
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dwlnetnl/generpc/openrpc"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+func TestGenerate(t *testing.T) {
+	data, err := os.ReadFile("testdata/api.json")
+	require.NoError(t, err)
+
+	var doc openrpc.Document
+	require.NoError(t, json.Unmarshal(data, &doc))
+
+	var buf bytes.Buffer
+	require.NoError(t, Generate(&buf, &doc, map[string]int{"Unauthorized": -32002}))
+
+	if *update {
+		require.NoError(t, os.WriteFile("testdata/api.ts", buf.Bytes(), 0o644))
+	}
+
+	want, err := os.ReadFile("testdata/api.ts")
+	require.NoError(t, err)
+	assert.Equal(t, string(want), buf.String())
+}
+
+func TestNames(t *testing.T) {
+	assert.Equal(t, "UserInfo", typeName("user-info"))
+	assert.Equal(t, "T2fa", typeName("2fa"))
+	assert.Equal(t, "userGet", memberName("user.get"))
+	assert.Equal(t, "", memberName("."))
+	assert.Equal(t, "id", propertyName("id"))
+	assert.Equal(t, `"next-page"`, propertyName("next-page"))
+	assert.Equal(t, `""`, propertyName(""))
+}
+
+func TestMemberNames(t *testing.T) {
+	doc := &openrpc.Document{OpenRPC: openrpc.Version, Info: openrpc.Info{Title: "Test", Version: "1.0.0"}}
+	for _, name := range []string{"id", "client", "requests", "pending", "send"} {
+		doc.Methods = append(doc.Methods, openrpc.Method{Name: name, Params: []openrpc.ContentDescriptor{}})
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, Generate(&buf, doc, nil))
+	for _, name := range []string{"id_", "client_", "requests_", "pending_", "send_"} {
+		assert.Contains(t, buf.String(), "\n  "+name+"(", name)
+	}
+}
+
+func TestGroup(t *testing.T) {
+	assert.Equal(t, "string", group("string"))
+	assert.Equal(t, "(string | null)", group("string | null"))
+	assert.Equal(t, "{ a: string | null; }", group("{ a: string | null; }"))
+}
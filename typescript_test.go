@@ -0,0 +1,56 @@
+package generpc
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/dwlnetnl/generpc/openrpc"
+)
+
+func TestTypeScriptHandler(t *testing.T) {
+	h := NewServer()
+	h.Info = openrpc.Info{Title: "Test", Version: "1.0.0"}
+	h.Register("subtract", schemaSubtractMethod())
+
+	w := httptest.NewRecorder()
+	h.TypeScriptHandler().ServeHTTP(w, httptest.NewRequest("GET", "/client.ts", nil))
+
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, "application/typescript; charset=utf-8", w.Header().Get("Content-Type"))
+
+	body := w.Body.String()
+	assert.Contains(t, body, "// Code generated by GeneRPC from the OpenRPC document of Test 1.0.0. DO NOT EDIT.")
+	assert.Contains(t, body, "  Limit: -32004,\n")
+	assert.Contains(t, body, "  subtract(minuend: number, subtrahend: number): Promise<number> {\n")
+	assert.Contains(t, body, "export class Batch extends Methods {")
+}
+
+func TestTypeScriptHandler_authorization(t *testing.T) {
+	h := NewServer()
+	h.Authenticator = tokenAuth()
+	h.Register("open", valueMethod(nil))
+	admin := valueMethod(nil)
+	admin.Authorization = &Authorization{Roles: []string{"admin"}}
+	h.Register("admin", admin)
+
+	serve := func(auth string) string {
+		r := httptest.NewRequest("GET", "/client.ts", nil)
+		if auth != "" {
+			r.Header.Set("Authorization", auth)
+		}
+
+		w := httptest.NewRecorder()
+		h.TypeScriptHandler().ServeHTTP(w, r)
+		return w.Body.String()
+	}
+
+	body := serve("")
+	assert.Contains(t, body, "  open(")
+	assert.NotContains(t, body, "  admin(")
+
+	body = serve("Bearer admin")
+	assert.Contains(t, body, "  open(")
+	assert.Contains(t, body, "  admin(")
+}
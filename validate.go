@@ -0,0 +1,77 @@
+package generpc
+
+import (
+	"strconv"
+
+	"github.com/dwlnetnl/generpc/coder"
+	"github.com/dwlnetnl/generpc/schema"
+)
+
+// validateParams validates the by-position params against the parameter
+// schemas. The reported pointers use parameter names if the params were
+// passed by-name.
+func (m *Method) validateParams(raw interface{}, params []interface{}) []schema.ValidationError {
+	_, byName := raw.(map[string]interface{})
+
+	var errs []schema.ValidationError
+	for i, s := range m.ParamSchemas {
+		if s == nil {
+			continue
+		}
+
+		token := strconv.Itoa(i)
+		if byName && i < len(m.ParamNames) {
+			token = m.ParamNames[i]
+		}
+
+		ptr := schema.Pointer("", token)
+		if m.optional(i) && (i >= len(params) || (byName && !provided(raw, token))) {
+			continue
+		}
+
+		if i >= len(params) {
+			errs = append(errs, schema.ValidationError{
+				Pointer: ptr,
+				Message: "parameter not provided",
+			})
+			continue
+		}
+
+		errs = append(errs, s.ValidateAt(ptr, params[i])...)
+	}
+
+	return errs
+}
+
+// optional reports if the i-th parameter can be omitted, see
+// Method.OptionalParams.
+func (m *Method) optional(i int) bool {
+	n := len(m.ParamNames)
+	if len(m.ParamSchemas) > n {
+		n = len(m.ParamSchemas)
+	}
+
+	return i >= n-m.OptionalParams
+}
+
+// provided reports if the by-name params contain name.
+func provided(raw interface{}, name string) bool {
+	_, ok := raw.(map[string]interface{})[name]
+	return ok
+}
+
+// validationError returns e with the validation errors as Data. Every error is
+// represented as an object with a pointer and message member.
+func validationError(e coder.Error, errs []schema.ValidationError) *coder.Error {
+	data := make([]interface{}, len(errs))
+	for i, err := range errs {
+		data[i] = map[string]interface{}{
+			"pointer": err.Pointer,
+			"message": err.Message,
+		}
+	}
+
+	v := e
+	v.Data = data
+	return &v
+}
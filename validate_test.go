@@ -0,0 +1,90 @@
+package generpc
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dwlnetnl/generpc/schema"
+)
+
+func schemaSubtractMethod() Method {
+	m := subtractMethod()
+	m.ParamSchemas = []*schema.Schema{
+		schema.MustParse(`{"type":"integer"}`),
+		schema.MustParse(`{"type":"integer","minimum":0}`),
+	}
+	m.ResultSchema = schema.MustParse(`{"type":"integer","minimum":0}`)
+	return m
+}
+
+func TestParamsValidation(t *testing.T) {
+	cases := []struct {
+		body string
+		want string
+	}{
+		{
+			`{"jsonrpc":"2.0","method":"subtract","params":[42,23],"id":1}`,
+			`{"jsonrpc":"2.0","result":19,"id":1}`,
+		},
+		{
+			`{"jsonrpc":"2.0","method":"subtract","params":[42,"23"],"id":1}`,
+			`{"jsonrpc":"2.0","error":{"code":-32602,"message":"Invalid params","data":[{"message":"expected integer, got string","pointer":"/1"}]},"id":1}`,
+		},
+		{
+			`{"jsonrpc":"2.0","method":"subtract","params":{"minuend":4.2,"subtrahend":-1},"id":1}`,
+			`{"jsonrpc":"2.0","error":{"code":-32602,"message":"Invalid params","data":[{"message":"expected integer, got number","pointer":"/minuend"},{"message":"value should be >= 0","pointer":"/subtrahend"}]},"id":1}`,
+		},
+		{
+			`{"jsonrpc":"2.0","method":"subtract","params":[42],"id":1}`,
+			`{"jsonrpc":"2.0","error":{"code":-32602,"message":"Invalid params","data":[{"message":"parameter not provided","pointer":"/1"}]},"id":1}`,
+		},
+	}
+
+	h := NewServer()
+	h.Register("subtract", schemaSubtractMethod())
+
+	for _, c := range cases {
+		r, err := http.NewRequest("POST", "/", strings.NewReader(c.body))
+		r.Header.Add("Content-Type", "application/json")
+		require.NoError(t, err)
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+
+		assert.JSONEq(t, c.want, w.Body.String())
+	}
+}
+
+func TestResultValidation(t *testing.T) {
+	body := `{"jsonrpc":"2.0","method":"subtract","params":[23,42],"id":1}`
+
+	h := NewServer()
+	h.Register("subtract", schemaSubtractMethod())
+
+	r, err := http.NewRequest("POST", "/", strings.NewReader(body))
+	r.Header.Add("Content-Type", "application/json")
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	want := `{"jsonrpc":"2.0","result":-19,"id":1}` + "\n"
+	assert.Equal(t, want, w.Body.String())
+
+	h.Development = true
+
+	r, err = http.NewRequest("POST", "/", strings.NewReader(body))
+	r.Header.Add("Content-Type", "application/json")
+	require.NoError(t, err)
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	want = `{"jsonrpc":"2.0","error":{"code":-32603,"message":"Internal error","data":[{"message":"value should be >= 0","pointer":""}]},"id":1}`
+	assert.JSONEq(t, want, w.Body.String())
+}